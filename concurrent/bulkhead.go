@@ -0,0 +1,141 @@
+package concurrent
+
+import (
+    "context"
+    "errors"
+    "sync"
+)
+
+// ErrBulkheadFull TryExecute 时资源的并发调用数已满
+var ErrBulkheadFull = errors.New("bulkhead is full")
+
+// Bulkhead 舱壁隔离,按资源名称限制并发调用数,避免单个下游变慢拖垮全部调用方
+// 每个资源对应一个 Semaphore,首次使用时按默认上限创建
+// 使用默认上限的资源在没有进行中和等待中的调用时自动移除,资源名可以来自请求数据(如租户ID);
+// 通过 SetLimit 设置过上限的资源会一直保留,直到调用 Remove
+//
+// 示例:
+//
+//	bh := NewBulkhead(10)
+//	bh.SetLimit("payment", 3)
+//	err := bh.Execute(ctx, "payment", func() error {
+//	    return callPayment()
+//	})
+type Bulkhead struct {
+    limit int64
+    mu    sync.Mutex
+    sems  map[string]*bulkheadEntry
+}
+
+type bulkheadEntry struct {
+    sem *Semaphore
+    // refs 进行中和等待中的调用数,由 Bulkhead.mu 保护,归零时使用默认上限的条目被移除
+    refs int
+    // fixed 通过 SetLimit 设置过上限,不自动移除
+    fixed bool
+}
+
+// NewBulkhead 创建舱壁,limit为每个资源默认的最大并发调用数
+func NewBulkhead(limit int64) *Bulkhead {
+    if limit < 1 {
+        panic("limit必须大于0")
+    }
+    return &Bulkhead{limit: limit, sems: map[string]*bulkheadEntry{}}
+}
+
+// SetLimit 设置资源的最大并发调用数,可在运行中调整,设置后的资源不会自动移除
+func (b *Bulkhead) SetLimit(name string, limit int64) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if e, ok := b.sems[name]; ok {
+        e.sem.Resize(limit)
+        e.fixed = true
+        return
+    }
+    b.sems[name] = &bulkheadEntry{sem: NewSemaphore(limit), fixed: true}
+}
+
+// Remove 移除资源,之后的调用按默认上限重新创建信号量,已进行中的调用仍在原信号量上归还许可
+func (b *Bulkhead) Remove(name string) {
+    b.mu.Lock()
+    delete(b.sems, name)
+    b.mu.Unlock()
+}
+
+// Len 返回当前保留的资源数量
+func (b *Bulkhead) Len() int {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    return len(b.sems)
+}
+
+// Semaphore 返回资源对应的信号量,用于查看 InUse/Waiters
+// 资源不存在时返回一个按默认上限创建的空闲信号量,不会保存;不应直接通过返回值获取许可
+func (b *Bulkhead) Semaphore(name string) *Semaphore {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if e, ok := b.sems[name]; ok {
+        return e.sem
+    }
+    return NewSemaphore(b.limit)
+}
+
+// acquireEntry 获取资源对应的条目并增加引用,调用方结束后需调用 releaseEntry
+func (b *Bulkhead) acquireEntry(name string) *bulkheadEntry {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    e, ok := b.sems[name]
+    if !ok {
+        e = &bulkheadEntry{sem: NewSemaphore(b.limit)}
+        b.sems[name] = e
+    }
+    e.refs++
+    return e
+}
+
+func (b *Bulkhead) releaseEntry(name string, e *bulkheadEntry) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    e.refs--
+    // 条目可能已被 Remove 或替换,只移除仍在 map 中的同一条目
+    if e.refs == 0 && !e.fixed && b.sems[name] == e {
+        delete(b.sems, name)
+    }
+}
+
+// Acquire 获取资源的一个调用许可,成功时调用方需调用返回的release
+func (b *Bulkhead) Acquire(ctx context.Context, name string) (release func(), err error) {
+    e := b.acquireEntry(name)
+    if err = e.sem.Acquire(ctx, 1); err != nil {
+        b.releaseEntry(name, e)
+        return nil, err
+    }
+    return func() {
+        e.sem.Release(1)
+        b.releaseEntry(name, e)
+    }, nil
+}
+
+// Execute 等待资源有空闲许可后执行fn,ctx取消时返回ctx.Err()
+func (b *Bulkhead) Execute(ctx context.Context, name string, fn func() error) error {
+    release, err := b.Acquire(ctx, name)
+    if err != nil {
+        return err
+    }
+    defer release()
+    return fn()
+}
+
+// TryExecute 资源有空闲许可时执行fn,否则立即返回 ErrBulkheadFull
+func (b *Bulkhead) TryExecute(name string, fn func() error) error {
+    e := b.acquireEntry(name)
+    if !e.sem.TryAcquire(1) {
+        b.releaseEntry(name, e)
+        return ErrBulkheadFull
+    }
+    defer func() {
+        e.sem.Release(1)
+        b.releaseEntry(name, e)
+    }()
+    return fn()
+}
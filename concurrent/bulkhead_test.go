@@ -0,0 +1,125 @@
+package concurrent
+
+import (
+    "context"
+    "errors"
+    "runtime"
+    "strconv"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+func Test_Bulkhead_Limit(t *testing.T) {
+    t.Parallel()
+    bh := NewBulkhead(10)
+    bh.SetLimit("slow", 2)
+    var running, maxRunning int32
+    var wg sync.WaitGroup
+    for i := 0; i < 8; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            err := bh.Execute(context.Background(), "slow", func() error {
+                n := atomic.AddInt32(&running, 1)
+                for {
+                    m := atomic.LoadInt32(&maxRunning)
+                    if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+                        break
+                    }
+                }
+                time.Sleep(5 * time.Millisecond)
+                atomic.AddInt32(&running, -1)
+                return nil
+            })
+            if err != nil {
+                t.Error(err)
+            }
+        }()
+    }
+    wg.Wait()
+    if maxRunning != 2 {
+        t.Fatalf("maxRunning=%d", maxRunning)
+    }
+}
+
+func Test_Bulkhead_TryExecute(t *testing.T) {
+    bh := NewBulkhead(1)
+    release, err := bh.Acquire(context.Background(), "db")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err = bh.TryExecute("db", func() error { return nil }); !errors.Is(err, ErrBulkheadFull) {
+        t.Fatalf("err=%v", err)
+    }
+    // 不同资源互不影响
+    if err = bh.TryExecute("cache", func() error { return nil }); err != nil {
+        t.Fatal(err)
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+    defer cancel()
+    if err = bh.Execute(ctx, "db", func() error { return nil }); !errors.Is(err, context.DeadlineExceeded) {
+        t.Fatalf("err=%v", err)
+    }
+    release()
+    errFn := errors.New("fn")
+    if err = bh.TryExecute("db", func() error { return errFn }); err != errFn {
+        t.Fatalf("err=%v", err)
+    }
+    if bh.Semaphore("db").InUse() != 0 {
+        t.Fatal("许可未归还")
+    }
+}
+
+func Test_Bulkhead_Evict(t *testing.T) {
+    bh := NewBulkhead(1)
+    bh.SetLimit("fixed", 2)
+    for i := 0; i < 100; i++ {
+        _ = bh.TryExecute(strconv.Itoa(i), func() error { return nil })
+    }
+    // 使用默认上限的资源空闲后移除, SetLimit 设置的资源保留
+    if n := bh.Len(); n != 1 {
+        t.Fatalf("len=%d", n)
+    }
+    release, err := bh.Acquire(context.Background(), "tenant")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if bh.Len() != 2 || bh.Semaphore("tenant").InUse() != 1 {
+        t.Fatal("进行中的资源不应移除")
+    }
+    release()
+    if bh.Len() != 1 || bh.Semaphore("tenant").InUse() != 0 {
+        t.Fatal("空闲资源应移除")
+    }
+    bh.Remove("fixed")
+    if bh.Len() != 0 {
+        t.Fatal("Remove 后应移除")
+    }
+
+    // 资源被反复移除与重建时, 同一资源的并发数仍不超过上限
+    var running, maxRunning int32
+    var wg sync.WaitGroup
+    for i := 0; i < 8; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for j := 0; j < 200; j++ {
+                _ = bh.Execute(context.Background(), "hot", func() error {
+                    n := atomic.AddInt32(&running, 1)
+                    if n > atomic.LoadInt32(&maxRunning) {
+                        atomic.StoreInt32(&maxRunning, n)
+                    }
+                    runtime.Gosched()
+                    atomic.AddInt32(&running, -1)
+                    return nil
+                })
+            }
+        }()
+    }
+    wg.Wait()
+    if maxRunning != 1 || bh.Len() != 0 {
+        t.Fatalf("maxRunning=%d len=%d", maxRunning, bh.Len())
+    }
+}
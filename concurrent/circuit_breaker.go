@@ -0,0 +1,393 @@
+package concurrent
+
+import (
+    "errors"
+    "sync"
+    "time"
+)
+
+// ErrBreakerOpen 熔断器打开或半开状态探测许可已用完时返回此错误
+var ErrBreakerOpen = errors.New("circuit breaker is open")
+
+// BreakerState 熔断器状态
+type BreakerState int32
+
+const (
+    // BreakerClosed 关闭,请求正常通过并统计结果
+    BreakerClosed BreakerState = iota
+    // BreakerOpen 打开,请求直接失败,经过openTimeout后进入半开
+    BreakerOpen
+    // BreakerHalfOpen 半开,只放行有限个探测请求,根据探测结果关闭或重新打开
+    BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+    switch s {
+    case BreakerClosed:
+        return "closed"
+    case BreakerOpen:
+        return "open"
+    case BreakerHalfOpen:
+        return "half-open"
+    }
+    return "unknown"
+}
+
+// BreakerCounts 统计窗口内的调用计数
+type BreakerCounts struct {
+    Total    int64
+    Failures int64
+    Slow     int64
+}
+
+// BreakerOpt 熔断器配置
+type BreakerOpt func(*CircuitBreaker)
+
+// WithBreakerWindow 统计窗口长度及分桶数量,默认10秒,10个桶
+func WithBreakerWindow(window time.Duration, buckets int) BreakerOpt {
+    return func(b *CircuitBreaker) {
+        if buckets < 1 || window < time.Duration(buckets) {
+            panic("窗口长度必须大于分桶数量,分桶数量必须大于0")
+        }
+        b.window = newBreakerWindow(int64(window)/int64(buckets), buckets)
+    }
+}
+
+// WithBreakerMinCalls 窗口内至少有n次调用才计算失败率,默认20
+func WithBreakerMinCalls(n int) BreakerOpt {
+    return func(b *CircuitBreaker) {
+        b.minCalls = int64(n)
+    }
+}
+
+// WithBreakerFailureRatio 失败率达到ratio时打开熔断器,默认0.5
+func WithBreakerFailureRatio(ratio float64) BreakerOpt {
+    return func(b *CircuitBreaker) {
+        b.failureRatio = ratio
+    }
+}
+
+// WithBreakerSlowCall 耗时超过threshold的调用视为慢调用,慢调用率达到ratio时打开熔断器,默认不统计慢调用
+func WithBreakerSlowCall(threshold time.Duration, ratio float64) BreakerOpt {
+    return func(b *CircuitBreaker) {
+        b.slowThreshold = threshold
+        b.slowRatio = ratio
+    }
+}
+
+// WithBreakerOpenTimeout 打开状态持续d后进入半开,默认5秒
+func WithBreakerOpenTimeout(d time.Duration) BreakerOpt {
+    return func(b *CircuitBreaker) {
+        b.openTimeout = int64(d)
+    }
+}
+
+// WithBreakerHalfOpenPermits 半开状态放行的探测请求数量,默认3
+func WithBreakerHalfOpenPermits(n int) BreakerOpt {
+    return func(b *CircuitBreaker) {
+        if n < 1 {
+            panic("探测请求数量必须大于0")
+        }
+        b.halfOpenPermits = int64(n)
+    }
+}
+
+// WithBreakerIsFailure 判断错误是否计为失败,默认所有非nil错误均为失败
+func WithBreakerIsFailure(isFailure func(err error) bool) BreakerOpt {
+    return func(b *CircuitBreaker) {
+        b.isFailure = isFailure
+    }
+}
+
+// WithBreakerOnStateChange 状态变化回调,在锁外同步调用
+func WithBreakerOnStateChange(fn func(from, to BreakerState)) BreakerOpt {
+    return func(b *CircuitBreaker) {
+        b.onStateChange = fn
+    }
+}
+
+// WithBreakerClock 设置时钟,返回unix纳秒,用于测试时注入时钟
+func WithBreakerClock(now func() int64) BreakerOpt {
+    return func(b *CircuitBreaker) {
+        b.now = now
+    }
+}
+
+// CircuitBreaker 熔断器,按时间分桶的滑动窗口统计失败率与慢调用率
+//
+//   - 关闭: 窗口内调用数达到minCalls且失败率或慢调用率达到阈值时打开
+//   - 打开: 请求直接返回 ErrBreakerOpen,持续openTimeout后进入半开
+//   - 半开: 放行halfOpenPermits个探测请求,全部完成后按相同阈值决定关闭或重新打开
+//
+// 示例:
+//
+//	cb := NewCircuitBreaker(WithBreakerMinCalls(10), WithBreakerOpenTimeout(time.Second))
+//	err := cb.Execute(func() error {
+//	    return callDownstream()
+//	})
+//	if errors.Is(err, ErrBreakerOpen) {
+//	    // 降级
+//	}
+type CircuitBreaker struct {
+    mu     sync.Mutex
+    state  BreakerState
+    window *breakerWindow
+    // 进入打开状态的时间(纳秒)
+    openedAt int64
+    // 状态代数,每次状态变化加1,用于丢弃跨状态完成的调用结果
+    generation uint64
+    // 半开状态已发放的许可数与已完成的探测结果
+    halfOpenIssued int64
+    halfOpen       BreakerCounts
+
+    minCalls        int64
+    failureRatio    float64
+    slowThreshold   time.Duration
+    slowRatio       float64
+    openTimeout     int64
+    halfOpenPermits int64
+    isFailure       func(err error) bool
+    onStateChange   func(from, to BreakerState)
+    now             func() int64
+}
+
+// NewCircuitBreaker 创建熔断器
+func NewCircuitBreaker(opts ...BreakerOpt) *CircuitBreaker {
+    b := &CircuitBreaker{
+        minCalls:        20,
+        failureRatio:    0.5,
+        openTimeout:     int64(5 * time.Second),
+        halfOpenPermits: 3,
+    }
+    for _, o := range opts {
+        o(b)
+    }
+    if b.window == nil {
+        b.window = newBreakerWindow(int64(time.Second), 10)
+    }
+    return b
+}
+
+func (b *CircuitBreaker) nowNano() int64 {
+    if b.now == nil {
+        return time.Now().UnixNano()
+    }
+    return b.now()
+}
+
+// Execute 熔断器允许时执行fn并记录结果,否则返回 ErrBreakerOpen
+// fn发生panic时计为失败并继续panic
+func (b *CircuitBreaker) Execute(fn func() error) (err error) {
+    gen, ok := b.allow()
+    if !ok {
+        return ErrBreakerOpen
+    }
+    start := b.nowNano()
+    defer func() {
+        if r := recover(); r != nil {
+            b.done(gen, errBreakerPanic, time.Duration(b.nowNano()-start))
+            panic(r)
+        }
+    }()
+    err = fn()
+    b.done(gen, err, time.Duration(b.nowNano()-start))
+    return err
+}
+
+var errBreakerPanic = errors.New("circuit breaker: fn panic")
+
+// BreakerToken 手动模式下 Allow 发放的许可,记录放行时的状态代数
+type BreakerToken struct {
+    gen uint64
+}
+
+// Allow 手动模式: 判断是否允许请求,返回true时调用方必须在请求结束后用返回的许可调用 Done 或 DoneDuration
+func (b *CircuitBreaker) Allow() (BreakerToken, bool) {
+    gen, ok := b.allow()
+    return BreakerToken{gen: gen}, ok
+}
+
+// Done 手动模式: 记录请求结果,不统计慢调用
+func (b *CircuitBreaker) Done(token BreakerToken, err error) {
+    b.DoneDuration(token, err, 0)
+}
+
+// DoneDuration 手动模式: 记录请求结果及耗时,放行后状态已变化的结果被丢弃,不计入新的窗口或半开探测
+func (b *CircuitBreaker) DoneDuration(token BreakerToken, err error, d time.Duration) {
+    b.done(token.gen, err, d)
+}
+
+// State 返回当前状态
+func (b *CircuitBreaker) State() BreakerState {
+    b.mu.Lock()
+    from, to := b.refresh(b.nowNano())
+    state := b.state
+    b.mu.Unlock()
+    b.notify(from, to)
+    return state
+}
+
+// Counts 返回当前窗口内的统计
+func (b *CircuitBreaker) Counts() BreakerCounts {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    return b.window.sum(b.nowNano())
+}
+
+func (b *CircuitBreaker) allow() (uint64, bool) {
+    b.mu.Lock()
+    from, to := b.refresh(b.nowNano())
+    gen, ok := b.generation, true
+    switch b.state {
+    case BreakerOpen:
+        ok = false
+    case BreakerHalfOpen:
+        if b.halfOpenIssued >= b.halfOpenPermits {
+            ok = false
+        } else {
+            b.halfOpenIssued++
+        }
+    }
+    b.mu.Unlock()
+    b.notify(from, to)
+    return gen, ok
+}
+
+func (b *CircuitBreaker) done(gen uint64, err error, d time.Duration) {
+    var c BreakerCounts
+    c.Total = 1
+    if err != nil && (b.isFailure == nil || err == errBreakerPanic || b.isFailure(err)) {
+        c.Failures = 1
+    }
+    if b.slowThreshold > 0 && d >= b.slowThreshold {
+        c.Slow = 1
+    }
+    now := b.nowNano()
+    b.mu.Lock()
+    var from, to BreakerState
+    if gen == b.generation {
+        switch b.state {
+        case BreakerClosed:
+            b.window.add(now, c)
+            if b.tripped(b.window.sum(now), b.minCalls) {
+                from, to = b.setState(BreakerOpen, now)
+            }
+        case BreakerHalfOpen:
+            b.halfOpen.Total += c.Total
+            b.halfOpen.Failures += c.Failures
+            b.halfOpen.Slow += c.Slow
+            if b.halfOpen.Total >= b.halfOpenPermits {
+                if b.tripped(b.halfOpen, 0) {
+                    from, to = b.setState(BreakerOpen, now)
+                } else {
+                    from, to = b.setState(BreakerClosed, now)
+                }
+            }
+        }
+    }
+    b.mu.Unlock()
+    b.notify(from, to)
+}
+
+// tripped 调用方需持有锁
+func (b *CircuitBreaker) tripped(c BreakerCounts, minCalls int64) bool {
+    if c.Total == 0 || c.Total < minCalls {
+        return false
+    }
+    if float64(c.Failures)/float64(c.Total) >= b.failureRatio {
+        return true
+    }
+    return b.slowThreshold > 0 && float64(c.Slow)/float64(c.Total) >= b.slowRatio
+}
+
+// refresh 打开状态超时后进入半开,调用方需持有锁
+func (b *CircuitBreaker) refresh(now int64) (from, to BreakerState) {
+    if b.state == BreakerOpen && now-b.openedAt >= b.openTimeout {
+        return b.setState(BreakerHalfOpen, now)
+    }
+    return
+}
+
+// setState 调用方需持有锁,返回的状态变化需在锁外通过 notify 回调
+func (b *CircuitBreaker) setState(state BreakerState, now int64) (from, to BreakerState) {
+    from = b.state
+    b.state = state
+    b.generation++
+    b.halfOpenIssued = 0
+    b.halfOpen = BreakerCounts{}
+    switch state {
+    case BreakerOpen:
+        b.openedAt = now
+    case BreakerClosed:
+        b.window.reset()
+    }
+    return from, state
+}
+
+func (b *CircuitBreaker) notify(from, to BreakerState) {
+    if from != to && b.onStateChange != nil {
+        b.onStateChange(from, to)
+    }
+}
+
+// breakerWindow 按时间分桶的环形窗口,过期分桶在推进时清空,与 SlidingWindow 的滑动方式相同
+type breakerWindow struct {
+    buckets []BreakerCounts
+    // 每个桶负责的时间(纳秒)
+    bucketTime int64
+    // 当前桶下标及其起始时间
+    index   int
+    start   int64
+    started bool
+}
+
+func newBreakerWindow(bucketTime int64, n int) *breakerWindow {
+    return &breakerWindow{buckets: make([]BreakerCounts, n), bucketTime: bucketTime}
+}
+
+// advance 推进到now所在的桶,清空已经过期的桶
+func (w *breakerWindow) advance(now int64) {
+    if !w.started {
+        w.started = true
+        w.start = now - now%w.bucketTime
+        return
+    }
+    num := (now - w.start) / w.bucketTime
+    if num <= 0 {
+        return
+    }
+    n := int64(len(w.buckets))
+    if num > n {
+        num = n
+    }
+    for l := int64(1); l <= num; l++ {
+        w.buckets[(w.index+int(l))%len(w.buckets)] = BreakerCounts{}
+    }
+    w.index = (w.index + int(num)) % len(w.buckets)
+    w.start = now - now%w.bucketTime
+}
+
+func (w *breakerWindow) add(now int64, c BreakerCounts) {
+    w.advance(now)
+    b := &w.buckets[w.index]
+    b.Total += c.Total
+    b.Failures += c.Failures
+    b.Slow += c.Slow
+}
+
+func (w *breakerWindow) sum(now int64) BreakerCounts {
+    w.advance(now)
+    var r BreakerCounts
+    for _, b := range w.buckets {
+        r.Total += b.Total
+        r.Failures += b.Failures
+        r.Slow += b.Slow
+    }
+    return r
+}
+
+func (w *breakerWindow) reset() {
+    for i := range w.buckets {
+        w.buckets[i] = BreakerCounts{}
+    }
+}
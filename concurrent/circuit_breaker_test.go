@@ -0,0 +1,203 @@
+package concurrent
+
+import (
+    "errors"
+    "reflect"
+    "testing"
+    "time"
+)
+
+var errDownstream = errors.New("downstream")
+
+func newTestBreaker(clock *fakeClock, opts ...BreakerOpt) (*CircuitBreaker, *[]string) {
+    var changes []string
+    opts = append([]BreakerOpt{
+        WithBreakerClock(clock.now),
+        WithBreakerWindow(time.Second, 10),
+        WithBreakerMinCalls(4),
+        WithBreakerOpenTimeout(time.Second),
+        WithBreakerHalfOpenPermits(2),
+        WithBreakerOnStateChange(func(from, to BreakerState) {
+            changes = append(changes, from.String()+"->"+to.String())
+        }),
+    }, opts...)
+    return NewCircuitBreaker(opts...), &changes
+}
+
+func Test_CircuitBreaker_OpenAndRecover(t *testing.T) {
+    clock := &fakeClock{}
+    cb, changes := newTestBreaker(clock)
+    fail := func() error { return errDownstream }
+    ok := func() error { return nil }
+    // 调用数不足minCalls时不熔断
+    for i := 0; i < 3; i++ {
+        if err := cb.Execute(fail); err != errDownstream {
+            t.Fatalf("err=%v", err)
+        }
+    }
+    if cb.State() != BreakerClosed {
+        t.Fatal("调用数不足时不应熔断")
+    }
+    cb.Execute(ok)
+    // 3/4失败 >= 0.5
+    if cb.State() != BreakerOpen {
+        t.Fatalf("state=%v counts=%+v", cb.State(), cb.Counts())
+    }
+    if err := cb.Execute(ok); !errors.Is(err, ErrBreakerOpen) {
+        t.Fatalf("打开状态应直接失败, err=%v", err)
+    }
+    clock.add(time.Second)
+    if cb.State() != BreakerHalfOpen {
+        t.Fatalf("state=%v", cb.State())
+    }
+    // 半开只放行2个探测
+    t1, ok1 := cb.Allow()
+    t2, ok2 := cb.Allow()
+    if _, ok3 := cb.Allow(); !ok1 || !ok2 || ok3 {
+        t.Fatal("半开应只放行2个探测")
+    }
+    cb.Done(t1, nil)
+    cb.Done(t2, nil)
+    if cb.State() != BreakerClosed {
+        t.Fatalf("探测成功应关闭, state=%v", cb.State())
+    }
+    if c := cb.Counts(); c.Total != 0 {
+        t.Fatalf("关闭后窗口应清空, counts=%+v", c)
+    }
+    want := []string{"closed->open", "open->half-open", "half-open->closed"}
+    if !reflect.DeepEqual(*changes, want) {
+        t.Fatalf("changes=%v", *changes)
+    }
+}
+
+func Test_CircuitBreaker_HalfOpenFail(t *testing.T) {
+    clock := &fakeClock{}
+    cb, _ := newTestBreaker(clock)
+    for i := 0; i < 4; i++ {
+        cb.Execute(func() error { return errDownstream })
+    }
+    clock.add(time.Second)
+    cb.Execute(func() error { return errDownstream })
+    cb.Execute(func() error { return nil })
+    // 1/2失败 >= 0.5, 重新打开
+    if cb.State() != BreakerOpen {
+        t.Fatalf("state=%v", cb.State())
+    }
+}
+
+func Test_CircuitBreaker_WindowExpire(t *testing.T) {
+    clock := &fakeClock{}
+    cb, _ := newTestBreaker(clock)
+    for i := 0; i < 3; i++ {
+        cb.Execute(func() error { return errDownstream })
+    }
+    // 窗口滑过后旧的失败被清空
+    clock.add(1100 * time.Millisecond)
+    if c := cb.Counts(); c.Total != 0 {
+        t.Fatalf("counts=%+v", c)
+    }
+    for i := 0; i < 4; i++ {
+        cb.Execute(func() error { return nil })
+    }
+    cb.Execute(func() error { return errDownstream })
+    if cb.State() != BreakerClosed {
+        t.Fatalf("state=%v counts=%+v", cb.State(), cb.Counts())
+    }
+}
+
+func Test_CircuitBreaker_IsFailure(t *testing.T) {
+    clock := &fakeClock{}
+    ignored := errors.New("not found")
+    cb, _ := newTestBreaker(clock, WithBreakerIsFailure(func(err error) bool { return err != ignored }))
+    for i := 0; i < 4; i++ {
+        if err := cb.Execute(func() error { return ignored }); err != ignored {
+            t.Fatalf("err=%v", err)
+        }
+    }
+    if c := cb.Counts(); c.Total != 4 || c.Failures != 0 || cb.State() != BreakerClosed {
+        t.Fatalf("counts=%+v state=%v", c, cb.State())
+    }
+}
+
+func Test_CircuitBreaker_SlowCall(t *testing.T) {
+    clock := &fakeClock{}
+    cb, _ := newTestBreaker(clock, WithBreakerSlowCall(100*time.Millisecond, 0.5))
+    for i := 0; i < 3; i++ {
+        cb.Execute(func() error {
+            clock.add(10 * time.Millisecond)
+            return nil
+        })
+    }
+    if token, ok := cb.Allow(); ok {
+        cb.DoneDuration(token, nil, time.Second)
+    }
+    if c := cb.Counts(); c.Slow != 1 || cb.State() != BreakerClosed {
+        t.Fatalf("counts=%+v state=%v", c, cb.State())
+    }
+    cb.Execute(func() error {
+        clock.add(150 * time.Millisecond)
+        return nil
+    })
+    // 2/5 < 0.5
+    if cb.State() != BreakerClosed {
+        t.Fatalf("counts=%+v", cb.Counts())
+    }
+    cb.Execute(func() error {
+        clock.add(150 * time.Millisecond)
+        return nil
+    })
+    // 3/6 >= 0.5
+    if cb.State() != BreakerOpen {
+        t.Fatalf("慢调用率达到阈值应熔断, counts=%+v", cb.Counts())
+    }
+}
+
+func Test_CircuitBreaker_Panic(t *testing.T) {
+    clock := &fakeClock{}
+    cb, _ := newTestBreaker(clock, WithBreakerMinCalls(1))
+    func() {
+        defer func() {
+            if recover() == nil {
+                t.Fatal("应继续panic")
+            }
+        }()
+        cb.Execute(func() error { panic("boom") })
+    }()
+    if cb.State() != BreakerOpen {
+        t.Fatal("panic应计为失败")
+    }
+}
+
+func Test_CircuitBreaker_StaleToken(t *testing.T) {
+    clock := &fakeClock{}
+    cb, _ := newTestBreaker(clock)
+    // 关闭状态放行, 完成前熔断器打开并进入半开
+    stale, ok := cb.Allow()
+    if !ok {
+        t.Fatal("关闭状态应放行")
+    }
+    for i := 0; i < 4; i++ {
+        cb.Execute(func() error { return errDownstream })
+    }
+    clock.add(time.Second)
+    if cb.State() != BreakerHalfOpen {
+        t.Fatalf("state=%v", cb.State())
+    }
+    probe, _ := cb.Allow()
+    // 旧许可的结果不计入半开探测
+    cb.Done(stale, nil)
+    cb.Done(stale, nil)
+    if cb.State() != BreakerHalfOpen {
+        t.Fatalf("旧许可不应影响半开探测, state=%v", cb.State())
+    }
+    cb.Done(probe, nil)
+    cb.Execute(func() error { return nil })
+    if cb.State() != BreakerClosed {
+        t.Fatalf("state=%v", cb.State())
+    }
+    // 关闭后旧许可的结果也不计入新窗口
+    cb.Done(stale, errDownstream)
+    if c := cb.Counts(); c.Total != 0 {
+        t.Fatalf("counts=%+v", c)
+    }
+}
@@ -1,5 +1,6 @@
-//go:build !concurrent_fast && !concurrent_memory
-
 package concurrent
 
-const cpuCacheKillerPaddingLength = 56
+import "github.com/mzzsfy/go-util/unsafe"
+
+// cpuCacheKillerPaddingLength 由 concurrent_fast / concurrent_memory 编译标签调整, 与其他包共用 unsafe.CachePaddingLength
+const cpuCacheKillerPaddingLength = unsafe.CachePaddingLength
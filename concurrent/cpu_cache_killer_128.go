@@ -1,5 +0,0 @@
-//go:build concurrent_fast
-
-package concurrent
-
-const cpuCacheKillerPaddingLength = 120
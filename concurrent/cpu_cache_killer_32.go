@@ -1,5 +0,0 @@
-//go:build concurrent_memory
-
-package concurrent
-
-const cpuCacheKillerPaddingLength = 24
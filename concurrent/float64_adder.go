@@ -0,0 +1,63 @@
+package concurrent
+
+import (
+    "math"
+    "sync/atomic"
+)
+
+// Float64Adder 用于统计float64类型的数据,与 Int64Adder 相同的分段设计
+// 值以 math.Float64bits 存储在分段单元中,出现竞争后各goroutine在自己的分段单元上CAS累加
+//
+// 注意: 浮点加法不满足结合律,并发累加的结果可能与串行累加存在舍入误差
+type Float64Adder struct {
+    base int64
+    adderCells
+}
+
+// Add 增加v,手动提供goid来提高性能
+func (f *Float64Adder) Add(goid int64, v float64) {
+    if f.addNoCompete(v) {
+        return
+    }
+    f.updateCell(goid, func(old int64) int64 {
+        return addFloat64(old, v)
+    })
+}
+
+func (f *Float64Adder) AddSimple(v float64) {
+    if f.addNoCompete(v) {
+        return
+    }
+    f.updateCell(GoID(), func(old int64) int64 {
+        return addFloat64(old, v)
+    })
+}
+
+func (f *Float64Adder) addNoCompete(v float64) bool {
+    if atomic.LoadInt32(&f.init) == 0 {
+        old := atomic.LoadInt64(&f.base)
+        if atomic.CompareAndSwapInt64(&f.base, old, addFloat64(old, v)) {
+            return true
+        }
+    }
+    f.contended(0)
+    return false
+}
+
+func (f *Float64Adder) Sum() float64 {
+    return math.Float64frombits(uint64(f.fold(atomic.LoadInt64(&f.base), addFloat64Bits)))
+}
+
+func (f *Float64Adder) Reset() {
+    atomic.StoreInt64(&f.base, 0)
+    f.reset(0)
+}
+
+// addFloat64 bits为float64的位表示,返回加上v后的位表示
+func addFloat64(bits int64, v float64) int64 {
+    return int64(math.Float64bits(math.Float64frombits(uint64(bits)) + v))
+}
+
+func addFloat64Bits(a, b int64) int64 {
+    return addFloat64(a, math.Float64frombits(uint64(b)))
+}
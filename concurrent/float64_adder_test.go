@@ -0,0 +1,34 @@
+package concurrent
+
+import (
+    "math"
+    "sync"
+    "testing"
+)
+
+func Test_Float64Adder(t *testing.T) {
+    t.Parallel()
+    adder := &Float64Adder{}
+    var wg sync.WaitGroup
+    for i := 0; i < 8; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for j := 0; j < 1000; j++ {
+                adder.AddSimple(0.5)
+            }
+        }()
+    }
+    wg.Wait()
+    if s := adder.Sum(); s != 4000 {
+        t.Fatalf("sum=%v", s)
+    }
+    adder.Add(GoID(), -0.25)
+    if s := adder.Sum(); math.Abs(s-3999.75) > 1e-9 {
+        t.Fatalf("sum=%v", s)
+    }
+    adder.Reset()
+    if adder.Sum() != 0 {
+        t.Fatalf("sum=%v", adder.Sum())
+    }
+}
@@ -0,0 +1,190 @@
+package concurrent
+
+import (
+    "math"
+    "sort"
+    "sync/atomic"
+    "unsafe"
+)
+
+// Histogram 无锁分桶直方图,用于统计延迟等数值分布
+//
+// 桶按上界(包含)划分,超过最大上界的值落入溢出桶; 计数按goroutine分段存放在同一个数组中,
+// 每个分段按缓存行对齐并占用整数个缓存行,避免不同分段之间伪共享
+//
+// 示例:
+//
+//	h := NewHistogram(ExponentialBounds(1, 2, 20)...) // 1,2,4...524288
+//	h.Record(time.Since(start).Microseconds())
+//	s := h.Snapshot()
+//	p99 := s.Percentile(0.99)
+type Histogram struct {
+    bounds []int64
+    // counts 所有分段的桶计数,第i个分段为 counts[i*stride:i*stride+len(bounds)+1],最后一个为溢出桶
+    counts []int64
+    // stride 分段间隔,为缓存行可容纳的 int64 数量的整数倍
+    stride int
+    sum    Int64Adder
+    min     *Int64Accumulator
+    max     *Int64Accumulator
+}
+
+// histogramLineInts 一个缓存行可容纳的 int64 数量
+const histogramLineInts = (cpuCacheKillerPaddingLength + 8) / 8
+
+// NewHistogram 创建直方图,bounds为严格递增的桶上界
+func NewHistogram(bounds ...int64) *Histogram {
+    if len(bounds) == 0 {
+        panic("bounds不能为空")
+    }
+    for i := 1; i < len(bounds); i++ {
+        if bounds[i] <= bounds[i-1] {
+            panic("bounds必须严格递增")
+        }
+    }
+    n := len(bounds) + 1
+    stride := (n + histogramLineInts - 1) / histogramLineInts * histogramLineInts
+    // 多分配一个缓存行,将起始位置对齐到缓存行
+    buf := make([]int64, slotNumber*stride+histogramLineInts)
+    lineBytes := uintptr(histogramLineInts * 8)
+    off := int((lineBytes - uintptr(unsafe.Pointer(&buf[0]))%lineBytes) % lineBytes / 8)
+    return &Histogram{
+        bounds: append([]int64(nil), bounds...),
+        counts: buf[off : off+slotNumber*stride],
+        stride: stride,
+        min:    NewMinAccumulator(),
+        max:    NewMaxAccumulator(),
+    }
+}
+
+// stripe 返回第i个分段的桶计数
+func (h *Histogram) stripe(i int) []int64 {
+    start := i * h.stride
+    return h.counts[start : start+len(h.bounds)+1]
+}
+
+// LinearBounds 返回从start开始,间隔为width的n个桶上界
+func LinearBounds(start, width int64, n int) []int64 {
+    r := make([]int64, n)
+    for i := range r {
+        r[i] = start + width*int64(i)
+    }
+    return r
+}
+
+// ExponentialBounds 返回从start开始,每次乘以factor的n个桶上界
+func ExponentialBounds(start int64, factor float64, n int) []int64 {
+    if start <= 0 || factor <= 1 {
+        panic("start必须大于0,factor必须大于1")
+    }
+    r := make([]int64, 0, n)
+    v := float64(start)
+    for len(r) < n {
+        b := int64(math.Round(v))
+        if len(r) == 0 || b > r[len(r)-1] {
+            r = append(r, b)
+        }
+        v *= factor
+    }
+    return r
+}
+
+// Record 记录一个值
+func (h *Histogram) Record(v int64) {
+    h.RecordWithGoid(GoID(), v)
+}
+
+// RecordWithGoid 记录一个值,手动提供goid来提高性能
+func (h *Histogram) RecordWithGoid(goid int64, v int64) {
+    i := sort.Search(len(h.bounds), func(i int) bool { return h.bounds[i] >= v })
+    atomic.AddInt64(&h.counts[(int(goid)&modNumber)*h.stride+i], 1)
+    h.sum.Add(goid, v)
+    h.min.Accumulate(goid, v)
+    h.max.Accumulate(goid, v)
+}
+
+// Reset 清空所有统计,与并发的 Record 之间不保证原子性
+func (h *Histogram) Reset() {
+    for i := range h.counts {
+        atomic.StoreInt64(&h.counts[i], 0)
+    }
+    h.sum.Reset()
+    h.min.Reset()
+    h.max.Reset()
+}
+
+// Snapshot 返回当前统计快照,并发 Record 时各字段之间可能存在细微不一致
+func (h *Histogram) Snapshot() HistogramSnapshot {
+    s := HistogramSnapshot{
+        Bounds: h.bounds,
+        Counts: make([]int64, len(h.bounds)+1),
+    }
+    for i := 0; i < slotNumber; i++ {
+        counts := h.stripe(i)
+        for j := range counts {
+            n := atomic.LoadInt64(&counts[j])
+            s.Counts[j] += n
+            s.Count += n
+        }
+    }
+    if s.Count > 0 {
+        s.Sum = h.sum.Sum()
+        s.Min = h.min.Get()
+        s.Max = h.max.Get()
+    }
+    return s
+}
+
+// HistogramSnapshot 直方图快照
+type HistogramSnapshot struct {
+    // Bounds 桶上界,只读
+    Bounds []int64
+    // Counts 各桶计数,长度为len(Bounds)+1,最后一个为溢出桶
+    Counts []int64
+    Count  int64
+    Sum    int64
+    Min    int64
+    Max    int64
+}
+
+// Mean 平均值
+func (s HistogramSnapshot) Mean() float64 {
+    if s.Count == 0 {
+        return 0
+    }
+    return float64(s.Sum) / float64(s.Count)
+}
+
+// Percentile 返回分位数的估计值,q取值[0,1],例如0.99
+// 在目标值所在桶内按线性插值估计,结果限制在[Min,Max]之间
+func (s HistogramSnapshot) Percentile(q float64) int64 {
+    if s.Count == 0 {
+        return 0
+    }
+    if q <= 0 {
+        return s.Min
+    }
+    if q >= 1 {
+        return s.Max
+    }
+    rank := q * float64(s.Count)
+    var seen int64
+    for i, n := range s.Counts {
+        if n == 0 || float64(seen+n) < rank {
+            seen += n
+            continue
+        }
+        lower, upper := s.Min, s.Max
+        if i > 0 && s.Bounds[i-1] > lower {
+            lower = s.Bounds[i-1]
+        }
+        if i < len(s.Bounds) && s.Bounds[i] < upper {
+            upper = s.Bounds[i]
+        }
+        if upper <= lower {
+            return upper
+        }
+        return lower + int64(float64(upper-lower)*(rank-float64(seen))/float64(n))
+    }
+    return s.Max
+}
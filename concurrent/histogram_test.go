@@ -0,0 +1,122 @@
+package concurrent
+
+import (
+    "reflect"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "unsafe"
+)
+
+func Test_Histogram_Bounds(t *testing.T) {
+    if b := LinearBounds(10, 5, 3); !reflect.DeepEqual(b, []int64{10, 15, 20}) {
+        t.Fatalf("linear=%v", b)
+    }
+    if b := ExponentialBounds(1, 2, 5); !reflect.DeepEqual(b, []int64{1, 2, 4, 8, 16}) {
+        t.Fatalf("exponential=%v", b)
+    }
+    // 取整后重复的上界被跳过
+    if b := ExponentialBounds(1, 1.5, 4); !reflect.DeepEqual(b, []int64{1, 2, 3, 5}) {
+        t.Fatalf("exponential=%v", b)
+    }
+}
+
+func Test_Histogram_Snapshot(t *testing.T) {
+    t.Parallel()
+    h := NewHistogram(LinearBounds(10, 10, 10)...) // 10,20...100
+    var wg sync.WaitGroup
+    for i := 0; i < 4; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for v := int64(1); v <= 100; v++ {
+                h.Record(v)
+            }
+        }()
+    }
+    wg.Wait()
+    s := h.Snapshot()
+    if s.Count != 400 || s.Sum != 4*5050 || s.Min != 1 || s.Max != 100 {
+        t.Fatalf("snapshot=%+v", s)
+    }
+    for i, n := range s.Counts[:10] {
+        if n != 40 {
+            t.Fatalf("bucket %d count=%d", i, n)
+        }
+    }
+    if s.Counts[10] != 0 {
+        t.Fatalf("overflow=%d", s.Counts[10])
+    }
+    if m := s.Mean(); m != 50.5 {
+        t.Fatalf("mean=%v", m)
+    }
+    for q, want := range map[float64]int64{0: 1, 0.5: 50, 0.9: 90, 0.99: 99, 1: 100} {
+        if p := s.Percentile(q); p != want {
+            t.Fatalf("p%v=%d want %d", q, p, want)
+        }
+    }
+}
+
+func Test_Histogram_OverflowAndReset(t *testing.T) {
+    h := NewHistogram(10, 100)
+    h.Record(5)
+    h.Record(1000)
+    h.Record(3000)
+    s := h.Snapshot()
+    if s.Counts[0] != 1 || s.Counts[2] != 2 || s.Max != 3000 {
+        t.Fatalf("snapshot=%+v", s)
+    }
+    // 溢出桶在(100,Max]之间插值
+    if p := s.Percentile(0.99); p <= 100 || p > 3000 {
+        t.Fatalf("p99=%d", p)
+    }
+    h.Reset()
+    if s = h.Snapshot(); s.Count != 0 || s.Percentile(0.5) != 0 {
+        t.Fatalf("snapshot=%+v", s)
+    }
+}
+
+func Test_Histogram_StripeLayout(t *testing.T) {
+    for _, n := range []int{1, 7, 8, 30} {
+        h := NewHistogram(LinearBounds(1, 1, n)...)
+        line := uintptr(histogramLineInts * 8)
+        if h.stride%histogramLineInts != 0 || h.stride < n+1 {
+            t.Fatalf("n=%d stride=%d", n, h.stride)
+        }
+        // 每个分段从缓存行起始位置开始, 相邻分段不共享缓存行
+        for i := 0; i < slotNumber; i++ {
+            if p := uintptr(unsafe.Pointer(&h.stripe(i)[0])); p%line != 0 {
+                t.Fatalf("n=%d stripe %d 未对齐", n, i)
+            }
+        }
+    }
+}
+
+// packHistogram 将分段改为不填充的紧凑布局, 用于对比伪共享的影响
+func packHistogram(h *Histogram) *Histogram {
+    h.stride = len(h.bounds) + 1
+    h.counts = make([]int64, slotNumber*h.stride)
+    return h
+}
+
+func BenchmarkHistogram_Record(b *testing.B) {
+    for _, c := range []struct {
+        name string
+        h    *Histogram
+    }{
+        {"padded", NewHistogram(10, 100, 1000)},
+        {"packed", packHistogram(NewHistogram(10, 100, 1000))},
+    } {
+        h := c.h
+        b.Run(c.name, func(b *testing.B) {
+            var id int64
+            b.RunParallel(func(pb *testing.PB) {
+                // 每个 goroutine 固定使用不同的分段, 多核下紧凑布局的相邻分段会竞争同一缓存行
+                goid := atomic.AddInt64(&id, 1)
+                for pb.Next() {
+                    h.RecordWithGoid(goid, 5)
+                }
+            })
+        })
+    }
+}
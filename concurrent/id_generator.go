@@ -1,8 +1,9 @@
 package concurrent
 
 import (
+	"errors"
+	"fmt"
 	"runtime"
-	"strconv"
 	"sync/atomic"
 	"time"
 )
@@ -12,6 +13,14 @@ type IdGenerator interface {
 	NextId() uint64
 }
 
+// ErrClockRollback 时钟回退超出容忍范围,或回退策略为 RollbackError 时返回此错误
+var ErrClockRollback = errors.New("clock rollback")
+
+// NewAtomIdGenerator 创建原子递增的ID生成器,第一个ID为start+1
+func NewAtomIdGenerator(start uint64) IdGenerator {
+	return &atomIdGenerator{g: start}
+}
+
 // atomIdGenerator 原子计数器实现的ID生成器
 type atomIdGenerator struct {
 	g uint64
@@ -22,81 +31,221 @@ func (a *atomIdGenerator) NextId() uint64 {
 	return atomic.AddUint64(&a.g, 1)
 }
 
-// snowFlake CAS实现的雪花算法,高并发时比原生雪花算法性能更好
-type snowFlake struct {
-	timestamp uint64
-	_         [56]byte //优化伪共享
-	sequence0 uint64
-	sequence1 uint64
-	_         [56]byte
-	workerId  uint64
-	timeShift uint64
-	lastId    uint64
+// RollbackPolicy 时钟回退处理策略
+type RollbackPolicy int
+
+const (
+	// RollbackWait 等待时钟追上上次生成ID的时间,回退超过容忍范围时返回 ErrClockRollback
+	RollbackWait RollbackPolicy = iota
+	// RollbackError 立即返回 ErrClockRollback
+	RollbackError
+	// RollbackBorrow 继续使用上次的时间戳并借用未来时间,回退超过容忍范围时返回 ErrClockRollback
+	// 同一毫秒序列号用尽时也会借用下一毫秒而不是等待,借用的时间超出时钟最大观测值达到容忍范围时等待时钟追上
+	RollbackBorrow
+)
+
+// SnowflakeOpt 雪花算法配置
+type SnowflakeOpt func(*Snowflake)
+
+// WithSnowflakeEpoch 设置起始时间,默认为unix纪元(1970-01-01)
+func WithSnowflakeEpoch(epoch time.Time) SnowflakeOpt {
+	return func(s *Snowflake) {
+		s.epoch = epoch.UnixMilli()
+	}
+}
+
+// WithSnowflakeBits 设置机器id与序列号的位数,剩余高位为毫秒时间戳,默认为7位机器id,16位序列号
+func WithSnowflakeBits(workerBits, sequenceBits uint8) SnowflakeOpt {
+	return func(s *Snowflake) {
+		s.workerBits = uint64(workerBits)
+		s.seqBits = uint64(sequenceBits)
+	}
+}
+
+// WithSnowflakeWorkerId 设置机器id,不能超过机器id位数的表示范围
+func WithSnowflakeWorkerId(workerId uint64) SnowflakeOpt {
+	return func(s *Snowflake) {
+		s.workerId = workerId
+	}
+}
 
-	seqBit uint64
-	seqMax uint64
+// WithSnowflakeRollback 设置时钟回退策略与容忍的最大回退时间,默认为 RollbackWait, 200ms
+func WithSnowflakeRollback(policy RollbackPolicy, maxRollback time.Duration) SnowflakeOpt {
+	return func(s *Snowflake) {
+		s.policy = policy
+		s.maxRollback = maxRollback.Milliseconds()
+	}
 }
 
-func (s *snowFlake) gen(time, sequence uint64) uint64 {
-	return (time << 23) | (s.workerId << s.seqBit) | sequence
+// WithSnowflakeClock 设置时钟,返回unix纳秒,用于测试时注入时钟
+func WithSnowflakeClock(now func() int64) SnowflakeOpt {
+	return func(s *Snowflake) {
+		s.now = now
+	}
 }
 
-func (s *snowFlake) init() {
-	if s.seqBit == 0 {
-		s.seqBit = 16 //约为普通机器极限值,每秒最多能产生65w(65535*1000)个id
+// SnowflakeId Decode 解析出的ID组成部分
+type SnowflakeId struct {
+	Time     time.Time
+	WorkerId uint64
+	Sequence uint64
+}
+
+// Snowflake CAS实现的雪花算法,时间戳与序列号合并为一个字,每次生成ID仅需一次CAS
+//
+// ID布局(从高到低): 毫秒时间戳 | 机器id | 序列号
+type Snowflake struct {
+	// 上次生成ID的状态: 时间戳<<seqBits | 序列号
+	state uint64
+	// wall 观测到的最大时钟毫秒数,借用未来时间时 state 中的时间戳可能超过它,回退以它为准
+	wall uint64
+	_    [cpuCacheKillerPaddingLength]byte
+
+	epoch       int64
+	workerId    uint64
+	workerBits  uint64
+	seqBits     uint64
+	seqMask     uint64
+	policy      RollbackPolicy
+	maxRollback int64
+	now         func() int64
+	// seqSeed 每毫秒序列号的初始值,为nil时从0开始
+	seqSeed func() uint64
+}
+
+// NewSnowflake 创建雪花算法ID生成器
+//
+// 示例:
+//
+//	s := NewSnowflake(
+//	    WithSnowflakeEpoch(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+//	    WithSnowflakeBits(10, 12),
+//	    WithSnowflakeWorkerId(3),
+//	)
+//	id := s.NextId()
+//	parts := s.Decode(id)
+func NewSnowflake(opts ...SnowflakeOpt) *Snowflake {
+	s := &Snowflake{
+		workerBits:  7,
+		seqBits:     16, //约为普通机器极限值,每秒最多能产生65w(65535*1000)个id
+		policy:      RollbackWait,
+		maxRollback: 200,
+	}
+	for _, o := range opts {
+		o(s)
 	}
-	s.seqMax = 1 << s.seqBit
+	if s.seqBits == 0 || s.workerBits+s.seqBits >= 64 {
+		panic(fmt.Sprintf("雪花算法位数错误: workerBits=%d, sequenceBits=%d", s.workerBits, s.seqBits))
+	}
+	if s.workerId >= 1<<s.workerBits {
+		panic(fmt.Sprintf("workerId超出范围: %d, workerBits=%d", s.workerId, s.workerBits))
+	}
+	s.seqMask = 1<<s.seqBits - 1
+	return s
 }
 
-func (s *snowFlake) NextId() uint64 {
+func (s *Snowflake) millis() uint64 {
+	var ms int64
+	if s.now == nil {
+		ms = time.Now().UnixMilli()
+	} else {
+		ms = s.now() / int64(time.Millisecond)
+	}
+	ms -= s.epoch
+	if ms < 0 {
+		panic("当前时间早于雪花算法起始时间")
+	}
+	return uint64(ms)
+}
+
+// NextId 生成下一个ID,时钟回退超出容忍范围时panic
+func (s *Snowflake) NextId() uint64 {
+	id, err := s.TryNextId()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// TryNextId 生成下一个ID,时钟回退无法按策略处理时返回 ErrClockRollback
+func (s *Snowflake) TryNextId() (uint64, error) {
 	for {
-		timestamp := uint64(time.Now().UnixMilli()) - s.timeShift
-		//同一毫秒,高并发时大概率走这个分支
-		if atomic.CompareAndSwapUint64(&s.timestamp, timestamp, timestamp) {
-			if timestamp&1 == 0 {
-				sequence := atomic.AddUint64(&s.sequence0, 1)
-				if sequence < s.seqMax {
-					return s.gen(timestamp, sequence)
-				} else {
-					if s.seqMax == 0 {
-						s.init()
-					}
-					runtime.Gosched()
-				}
+		old := atomic.LoadUint64(&s.state)
+		last, seq := old>>s.seqBits, old&s.seqMask
+		now := s.millis()
+		var next uint64
+		switch {
+		case now > last:
+			if s.policy == RollbackBorrow {
+				s.observe(now)
+			}
+			next = now<<s.seqBits | s.seed()
+		case s.policy == RollbackBorrow:
+			// last 可能因借用而超前于时钟, 只有时钟相对最大观测值回退才算回退
+			wall := s.observe(now)
+			if int64(wall-now) > s.maxRollback {
+				return 0, s.rollbackErr(wall, now)
+			}
+			if seq < s.seqMask {
+				next = old + 1
+			} else if int64(last+1-wall) <= s.maxRollback {
+				next = (last+1)<<s.seqBits | s.seed()
 			} else {
-				sequence := atomic.AddUint64(&s.sequence1, 1)
-				if sequence < s.seqMax {
-					return s.gen(timestamp, sequence)
-				} else {
-					if s.seqMax == 0 {
-						s.init()
-					}
-					runtime.Gosched()
-				}
+				// 借用的时间已达到容忍范围, 等待时钟前进
+				runtime.Gosched()
+				continue
 			}
-		} else {
-			if timestamp < s.timestamp {
-				timestamp = uint64(time.Now().UnixMilli()) - s.timeShift
-				x := atomic.LoadUint64(&s.timestamp) - timestamp
-				if x > 0 {
-					if x > 200 {
-						panic("时钟回退: " + strconv.Itoa(int(atomic.LoadUint64(&s.timestamp))) + "->" + strconv.Itoa(int(timestamp)))
-					} else if x > 10 {
-						time.Sleep(time.Millisecond*time.Duration(x) - time.Millisecond*10)
-					}
-					runtime.Gosched()
-				}
+		case now == last:
+			if seq < s.seqMask {
+				next = old + 1
+			} else {
+				// 当前毫秒序列号用尽,等待下一毫秒
+				runtime.Gosched()
 				continue
 			}
-			if atomic.CompareAndSwapUint64(&s.timestamp, s.timestamp, timestamp) {
-				if timestamp&1 == 0 {
-					atomic.StoreUint64(&s.sequence0, 1)
-					return s.gen(timestamp, 1)
-				} else {
-					atomic.StoreUint64(&s.sequence1, 0)
-					return s.gen(timestamp, 0)
-				}
+		default:
+			back := int64(last - now)
+			if s.policy == RollbackError || back > s.maxRollback {
+				return 0, s.rollbackErr(last, now)
 			}
+			time.Sleep(time.Duration(back) * time.Millisecond)
+			continue
 		}
+		if atomic.CompareAndSwapUint64(&s.state, old, next) {
+			return (next>>s.seqBits)<<(s.workerBits+s.seqBits) | s.workerId<<s.seqBits | next&s.seqMask, nil
+		}
+	}
+}
+
+// observe 更新并返回时钟的最大观测值
+func (s *Snowflake) observe(now uint64) uint64 {
+	for {
+		wall := atomic.LoadUint64(&s.wall)
+		if now <= wall || atomic.CompareAndSwapUint64(&s.wall, wall, now) {
+			if now > wall {
+				return now
+			}
+			return wall
+		}
+	}
+}
+
+func (s *Snowflake) seed() uint64 {
+	if s.seqSeed == nil {
+		return 0
+	}
+	return s.seqSeed()
+}
+
+func (s *Snowflake) rollbackErr(last, now uint64) error {
+	return fmt.Errorf("%w: %dms -> %dms", ErrClockRollback, int64(last)+s.epoch, int64(now)+s.epoch)
+}
+
+// Decode 按当前生成器的布局解析ID
+func (s *Snowflake) Decode(id uint64) SnowflakeId {
+	return SnowflakeId{
+		Time:     time.UnixMilli(int64(id>>(s.workerBits+s.seqBits)) + s.epoch),
+		WorkerId: id >> s.seqBits & (1<<s.workerBits - 1),
+		Sequence: id & s.seqMask,
 	}
 }
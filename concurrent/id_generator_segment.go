@@ -0,0 +1,166 @@
+package concurrent
+
+import (
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// SegmentAllocator 号段分配回调,返回长度为step的号段起始值,号段为 [start, start+step)
+// 通常由数据库或文件实现,例如 UPDATE t SET max_id = max_id + step 后返回 max_id - step
+type SegmentAllocator func(step uint64) (start uint64, err error)
+
+// SegmentIdGenerator 号段模式(leaf-segment)ID生成器
+//
+// 每次从 SegmentAllocator 批量获取step个ID,号段内通过原子递增发号;
+// 当前号段消耗超过一定比例时异步预取下一号段(双buffer),号段切换时不阻塞发号
+//
+// 示例:
+//
+//	g := NewSegmentIdGenerator(1000, func(step uint64) (uint64, error) {
+//	    return db.IncrBy("order_id", step) - step, nil
+//	})
+//	id := g.NextId()
+type SegmentIdGenerator struct {
+	// 当前号段 *idSegment
+	current unsafe.Pointer
+	_       [cpuCacheKillerPaddingLength]byte
+
+	step      uint64
+	threshold uint64
+	allocator SegmentAllocator
+
+	mu       sync.Mutex
+	next     *idSegment
+	loading  bool
+	loadDone *sync.Cond
+	// prefetchErr 异步预取失败的错误,由下一个需要新号段的调用方返回
+	prefetchErr error
+}
+
+type idSegment struct {
+	// 已发出的ID数量
+	used  uint64
+	_     [cpuCacheKillerPaddingLength]byte
+	start uint64
+	size  uint64
+}
+
+// NewSegmentIdGenerator 创建号段ID生成器,step为每次分配的号段长度
+// 首个号段在第一次发号时同步分配,当前号段消耗过半后异步预取下一号段
+func NewSegmentIdGenerator(step uint64, allocator SegmentAllocator) *SegmentIdGenerator {
+	if step == 0 {
+		panic("step必须大于0")
+	}
+	if allocator == nil {
+		panic("allocator不能为nil")
+	}
+	g := &SegmentIdGenerator{
+		step:      step,
+		threshold: step / 2,
+		allocator: allocator,
+	}
+	g.loadDone = sync.NewCond(&g.mu)
+	return g
+}
+
+// NextId 生成下一个ID,分配号段失败时panic
+func (g *SegmentIdGenerator) NextId() uint64 {
+	id, err := g.TryNextId()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// TryNextId 生成下一个ID,当前号段用尽且分配新号段失败时返回分配器的错误
+// 异步预取失败(包括分配器panic)时,错误由之后第一个需要新号段的调用返回,再之后的调用同步重新分配
+func (g *SegmentIdGenerator) TryNextId() (uint64, error) {
+	for {
+		seg := (*idSegment)(atomic.LoadPointer(&g.current))
+		if seg != nil {
+			n := atomic.AddUint64(&seg.used, 1)
+			if n <= seg.size {
+				if n == g.threshold+1 {
+					// 恰好一个调用方触发预取
+					g.prefetch()
+				}
+				return seg.start + n - 1, nil
+			}
+		}
+		if err := g.switchSegment(seg); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// prefetch 异步分配下一号段
+func (g *SegmentIdGenerator) prefetch() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.next != nil || g.loading {
+		return
+	}
+	g.loading = true
+	go func() {
+		seg, err := g.allocate()
+		g.mu.Lock()
+		g.loading = false
+		g.next = seg
+		g.prefetchErr = err
+		g.loadDone.Broadcast()
+		g.mu.Unlock()
+	}()
+}
+
+// switchSegment 当前号段old用尽,切换到预取的号段,无预取号段时同步分配
+func (g *SegmentIdGenerator) switchSegment(old *idSegment) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for {
+		if (*idSegment)(atomic.LoadPointer(&g.current)) != old {
+			// 已被其他调用方切换
+			return nil
+		}
+		if g.next != nil {
+			atomic.StorePointer(&g.current, unsafe.Pointer(g.next))
+			g.next = nil
+			return nil
+		}
+		if g.loading {
+			g.loadDone.Wait()
+			continue
+		}
+		if err := g.prefetchErr; err != nil {
+			g.prefetchErr = nil
+			return err
+		}
+		// 无可用号段,同步分配; 标记loading使并发的调用方等待本次结果
+		g.loading = true
+		g.mu.Unlock()
+		seg, err := g.allocate()
+		g.mu.Lock()
+		g.loading = false
+		g.loadDone.Broadcast()
+		if err != nil {
+			return err
+		}
+		atomic.StorePointer(&g.current, unsafe.Pointer(seg))
+		return nil
+	}
+}
+
+// allocate 调用分配器分配号段,分配器panic时转换为 *PanicError 返回
+func (g *SegmentIdGenerator) allocate() (seg *idSegment, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			seg, err = nil, &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	start, err := g.allocator(g.step)
+	if err != nil {
+		return nil, err
+	}
+	return &idSegment{start: start, size: g.step}, nil
+}
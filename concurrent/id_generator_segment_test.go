@@ -0,0 +1,109 @@
+package concurrent
+
+import (
+    "errors"
+    "sync"
+    "sync/atomic"
+    "testing"
+)
+
+func Test_SegmentIdGenerator_Sequential(t *testing.T) {
+    var maxId uint64 = 1000
+    var calls int32
+    g := NewSegmentIdGenerator(10, func(step uint64) (uint64, error) {
+        atomic.AddInt32(&calls, 1)
+        return atomic.AddUint64(&maxId, step) - step, nil
+    })
+    for i := uint64(0); i < 35; i++ {
+        if id := g.NextId(); id != 1000+i {
+            t.Fatalf("i=%d id=%d", i, id)
+        }
+    }
+    if c := atomic.LoadInt32(&calls); c < 4 || c > 5 {
+        t.Fatalf("calls=%d", c)
+    }
+}
+
+func Test_SegmentIdGenerator_Error(t *testing.T) {
+    var fail int32 = 1
+    errDb := errors.New("db down")
+    var maxId uint64
+    g := NewSegmentIdGenerator(4, func(step uint64) (uint64, error) {
+        if atomic.LoadInt32(&fail) == 1 {
+            return 0, errDb
+        }
+        return atomic.AddUint64(&maxId, step) - step, nil
+    })
+    if _, err := g.TryNextId(); !errors.Is(err, errDb) {
+        t.Fatalf("err=%v", err)
+    }
+    atomic.StoreInt32(&fail, 0)
+    id, err := g.TryNextId()
+    if err != nil || id != 0 {
+        t.Fatalf("id=%d err=%v", id, err)
+    }
+}
+
+func Test_SegmentIdGenerator_PrefetchError(t *testing.T) {
+    var calls int32
+    errDb := errors.New("db down")
+    g := NewSegmentIdGenerator(4, func(step uint64) (uint64, error) {
+        switch atomic.AddInt32(&calls, 1) {
+        case 2:
+            panic("allocator panic")
+        case 3:
+            return 0, errDb
+        }
+        return uint64(atomic.LoadInt32(&calls)) * 100, nil
+    })
+    // 第一个号段同步分配, 消耗过半触发的预取panic, 错误在号段用尽时返回
+    for i := 0; i < 4; i++ {
+        if _, err := g.TryNextId(); err != nil {
+            t.Fatalf("i=%d err=%v", i, err)
+        }
+    }
+    var pe *PanicError
+    if _, err := g.TryNextId(); !errors.As(err, &pe) || pe.Value != "allocator panic" {
+        t.Fatalf("err=%v", err)
+    }
+    // 错误只返回一次, 之后同步重新分配
+    if _, err := g.TryNextId(); !errors.Is(err, errDb) {
+        t.Fatalf("err=%v", err)
+    }
+    id, err := g.TryNextId()
+    if err != nil || id != 400 {
+        t.Fatalf("id=%d err=%v", id, err)
+    }
+}
+
+func Test_SegmentIdGenerator_ConcurrentUnique(t *testing.T) {
+    t.Parallel()
+    var maxId uint64
+    g := NewSegmentIdGenerator(64, func(step uint64) (uint64, error) {
+        return atomic.AddUint64(&maxId, step) - step, nil
+    })
+    const goroutines, n = 8, 1000
+    var mu sync.Mutex
+    seen := make(map[uint64]bool, goroutines*n)
+    var wg sync.WaitGroup
+    wg.Add(goroutines)
+    for i := 0; i < goroutines; i++ {
+        go func() {
+            defer wg.Done()
+            ids := make([]uint64, n)
+            for j := range ids {
+                ids[j] = g.NextId()
+            }
+            mu.Lock()
+            defer mu.Unlock()
+            for _, id := range ids {
+                if seen[id] {
+                    t.Errorf("重复id: %d", id)
+                    return
+                }
+                seen[id] = true
+            }
+        }()
+    }
+    wg.Wait()
+}
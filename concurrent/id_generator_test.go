@@ -1,13 +1,14 @@
 package concurrent
 
 import (
+    "errors"
     "sync"
     "testing"
     "time"
 )
 
 func TestSnowFlake(t *testing.T) {
-    s := snowFlake{}
+    s := NewSnowflake()
     for i := 0; i < 10; i++ {
         t.Log(s.NextId())
     }
@@ -69,7 +70,7 @@ func BenchmarkAtomIdGenerator(b *testing.B) {
 }
 
 func BenchmarkSnowFlake(b *testing.B) {
-    s := snowFlake{}
+    s := NewSnowflake()
     b.SetParallelism(128)
     b.RunParallel(func(pb *testing.PB) {
         for pb.Next() {
@@ -87,3 +88,196 @@ func BenchmarkSnowFlake_lock(b *testing.B) {
         }
     })
 }
+
+func Test_Snowflake_LayoutAndDecode(t *testing.T) {
+    epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+    clock := &fakeClock{t: epoch.Add(time.Second).UnixNano()}
+    s := NewSnowflake(
+        WithSnowflakeEpoch(epoch),
+        WithSnowflakeBits(10, 12),
+        WithSnowflakeWorkerId(513),
+        WithSnowflakeClock(clock.now),
+    )
+    for i := uint64(0); i < 3; i++ {
+        id := s.NextId()
+        if id != 1000<<22|513<<12|i {
+            t.Fatalf("i=%d id=%b", i, id)
+        }
+        d := s.Decode(id)
+        if !d.Time.Equal(epoch.Add(time.Second)) || d.WorkerId != 513 || d.Sequence != i {
+            t.Fatalf("decode=%+v", d)
+        }
+    }
+    clock.add(time.Millisecond)
+    if d := s.Decode(s.NextId()); d.Sequence != 0 || !d.Time.Equal(epoch.Add(1001*time.Millisecond)) {
+        t.Fatalf("新的一毫秒序列号应归零, decode=%+v", d)
+    }
+}
+
+func Test_Snowflake_InvalidConfig(t *testing.T) {
+    for _, opts := range [][]SnowflakeOpt{
+        {WithSnowflakeBits(4, 0)},
+        {WithSnowflakeBits(40, 24)},
+        {WithSnowflakeBits(4, 8), WithSnowflakeWorkerId(16)},
+    } {
+        func() {
+            defer func() {
+                if recover() == nil {
+                    t.Fatal("应panic")
+                }
+            }()
+            NewSnowflake(opts...)
+        }()
+    }
+}
+
+func Test_Snowflake_Unique(t *testing.T) {
+    t.Parallel()
+    // 序列号仅4位,频繁跨毫秒
+    s := NewSnowflake(WithSnowflakeBits(2, 4))
+    const goroutines, n = 4, 500
+    ids := make(chan uint64, goroutines*n)
+    var wg sync.WaitGroup
+    wg.Add(goroutines)
+    for i := 0; i < goroutines; i++ {
+        go func() {
+            defer wg.Done()
+            for j := 0; j < n; j++ {
+                ids <- s.NextId()
+            }
+        }()
+    }
+    wg.Wait()
+    close(ids)
+    seen := make(map[uint64]bool, goroutines*n)
+    for id := range ids {
+        if seen[id] {
+            t.Fatalf("重复id: %d", id)
+        }
+        seen[id] = true
+    }
+}
+
+func Test_Snowflake_RollbackError(t *testing.T) {
+    clock := &fakeClock{t: int64(10 * time.Second)}
+    s := NewSnowflake(WithSnowflakeClock(clock.now), WithSnowflakeRollback(RollbackError, time.Second))
+    s.NextId()
+    clock.add(-time.Millisecond)
+    if _, err := s.TryNextId(); !errors.Is(err, ErrClockRollback) {
+        t.Fatalf("err=%v", err)
+    }
+    func() {
+        defer func() {
+            if recover() == nil {
+                t.Fatal("NextId应panic")
+            }
+        }()
+        s.NextId()
+    }()
+    clock.add(2 * time.Millisecond)
+    if _, err := s.TryNextId(); err != nil {
+        t.Fatal(err)
+    }
+}
+
+func Test_Snowflake_RollbackWait(t *testing.T) {
+    clock := &fakeClock{t: int64(10 * time.Second)}
+    s := NewSnowflake(WithSnowflakeClock(clock.now), WithSnowflakeRollback(RollbackWait, 50*time.Millisecond))
+    first := s.NextId()
+    clock.add(-10 * time.Millisecond)
+    go func() {
+        time.Sleep(5 * time.Millisecond)
+        clock.add(11 * time.Millisecond)
+    }()
+    id, err := s.TryNextId()
+    if err != nil || id <= first {
+        t.Fatalf("id=%d first=%d err=%v", id, first, err)
+    }
+    clock.add(-1001 * time.Millisecond)
+    if _, err = s.TryNextId(); !errors.Is(err, ErrClockRollback) {
+        t.Fatalf("超出容忍范围应返回错误, err=%v", err)
+    }
+}
+
+func Test_Snowflake_RollbackBorrow(t *testing.T) {
+    clock := &fakeClock{t: int64(10 * time.Second)}
+    s := NewSnowflake(
+        WithSnowflakeBits(0, 2),
+        WithSnowflakeClock(clock.now),
+        WithSnowflakeRollback(RollbackBorrow, 5*time.Millisecond),
+    )
+    last := s.NextId()
+    clock.add(-time.Millisecond)
+    // 回退期间继续递增,序列号用尽后借用下一毫秒
+    for i := 0; i < 8; i++ {
+        id, err := s.TryNextId()
+        if err != nil || id <= last {
+            t.Fatalf("i=%d id=%d last=%d err=%v", i, id, last, err)
+        }
+        last = id
+    }
+    if ts := s.Decode(last).Time.UnixMilli(); ts != 10002 {
+        t.Fatalf("ts=%d", ts)
+    }
+    clock.add(-9 * time.Millisecond)
+    if _, err := s.TryNextId(); !errors.Is(err, ErrClockRollback) {
+        t.Fatalf("err=%v", err)
+    }
+}
+
+func Test_AtomIdGenerator(t *testing.T) {
+    g := NewAtomIdGenerator(100)
+    if g.NextId() != 101 || g.NextId() != 102 {
+        t.Fatal("id应从start+1开始递增")
+    }
+}
+
+func Test_Snowflake_BorrowBurst(t *testing.T) {
+    const maxRollback = 20 * time.Millisecond
+    s := NewSnowflake(WithSnowflakeBits(10, 2), WithSnowflakeRollback(RollbackBorrow, maxRollback))
+    // 突发数量远超 seqMask*maxRollback, 借用超前达到容忍范围后应等待而不是报错
+    n := int(s.seqMask)*int(maxRollback/time.Millisecond)*4 + 1
+    var wg sync.WaitGroup
+    ids := make(chan uint64, n+4)
+    for g := 0; g < 4; g++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for i := 0; i < n/4+1; i++ {
+                id, err := s.TryNextId()
+                if err != nil {
+                    t.Error(err)
+                    return
+                }
+                // 借用的时间不超过当前时间加容忍范围(含1ms取整误差)
+                if ahead := s.Decode(id).Time.Sub(time.Now()); ahead > maxRollback+time.Millisecond {
+                    t.Errorf("ahead=%v", ahead)
+                    return
+                }
+                ids <- id
+            }
+        }()
+    }
+    wg.Wait()
+    close(ids)
+    seen := map[uint64]bool{}
+    for id := range ids {
+        if seen[id] {
+            t.Fatalf("重复id: %d", id)
+        }
+        seen[id] = true
+    }
+    // 借用后真实时钟未回退, 仍然可以继续生成
+    clock := &fakeClock{t: int64(10 * time.Second)}
+    s = NewSnowflake(WithSnowflakeBits(0, 2), WithSnowflakeClock(clock.now), WithSnowflakeRollback(RollbackBorrow, 5*time.Millisecond))
+    s.NextId()
+    clock.add(-time.Millisecond)
+    // 最大观测值为10000, 回退1ms在容忍范围内
+    if _, err := s.TryNextId(); err != nil {
+        t.Fatal(err)
+    }
+    clock.add(-5 * time.Millisecond)
+    if _, err := s.TryNextId(); !errors.Is(err, ErrClockRollback) {
+        t.Fatalf("回退6ms应返回错误, err=%v", err)
+    }
+}
@@ -0,0 +1,147 @@
+package concurrent
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+)
+
+// UUID 128位UUID
+type UUID [16]byte
+
+// String 返回标准的 8-4-4-4-12 格式
+func (u UUID) String() string {
+	var b [36]byte
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b[:])
+}
+
+// Time 返回UUIDv7中的毫秒时间戳
+func (u UUID) Time() time.Time {
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(u[:8]) >> 16))
+}
+
+// ULID 128位ULID,48位毫秒时间戳 + 80位随机数
+type ULID [16]byte
+
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// String 返回26位 Crockford Base32 编码
+func (u ULID) String() string {
+	hi, lo := binary.BigEndian.Uint64(u[:8]), binary.BigEndian.Uint64(u[8:])
+	var b [26]byte
+	for i := 25; i >= 0; i-- {
+		b[i] = crockfordBase32[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(b[:])
+}
+
+// Time 返回ULID中的毫秒时间戳
+func (u ULID) Time() time.Time {
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(u[:8]) >> 16))
+}
+
+// UUIDv7Generator RFC 9562 UUIDv7 生成器,同一生成器产生的UUID严格递增
+//
+// rand_a 的12位作为毫秒内计数器(每毫秒以随机值起始),与时间戳一起通过 Snowflake 的单字CAS推进,
+// 计数器用尽时等待下一毫秒, 时间戳始终不超前于时钟; rand_b 的62位为随机数
+type UUIDv7Generator struct {
+	s *Snowflake
+}
+
+// NewUUIDv7Generator 创建UUIDv7生成器,默认时钟回退策略为 RollbackWait, 1s
+// opts 中仅 WithSnowflakeClock 与 WithSnowflakeRollback 生效
+func NewUUIDv7Generator(opts ...SnowflakeOpt) *UUIDv7Generator {
+	return &UUIDv7Generator{s: newMillisCounter(12, opts)}
+}
+
+// NextId 返回UUID的高64位(时间戳|版本|计数器),单调递增,可作为 IdGenerator 使用
+func (g *UUIDv7Generator) NextId() uint64 {
+	return g.hi(g.s.NextId())
+}
+
+func (g *UUIDv7Generator) hi(id uint64) uint64 {
+	return id>>12<<16 | 0x7<<12 | id&0xfff
+}
+
+// Next 生成UUIDv7
+func (g *UUIDv7Generator) Next() UUID {
+	var u UUID
+	binary.BigEndian.PutUint64(u[:8], g.NextId())
+	randomBytes(u[8:])
+	// RFC 9562 variant: 10xx
+	u[8] = u[8]&0x3f | 0x80
+	return u
+}
+
+// NextString 生成UUIDv7字符串
+func (g *UUIDv7Generator) NextString() string {
+	return g.Next().String()
+}
+
+// ULIDGenerator 单调ULID生成器,同一生成器产生的ULID严格递增
+//
+// 随机部分的高16位作为毫秒内计数器(每毫秒以随机值起始),与时间戳一起通过 Snowflake 的单字CAS推进,
+// 计数器用尽时等待下一毫秒, 时间戳始终不超前于时钟; 其余64位为随机数
+type ULIDGenerator struct {
+	s *Snowflake
+}
+
+// NewULIDGenerator 创建ULID生成器,默认时钟回退策略为 RollbackWait, 1s
+// opts 中仅 WithSnowflakeClock 与 WithSnowflakeRollback 生效
+func NewULIDGenerator(opts ...SnowflakeOpt) *ULIDGenerator {
+	return &ULIDGenerator{s: newMillisCounter(16, opts)}
+}
+
+// NextId 返回ULID的高64位(时间戳|计数器),单调递增,可作为 IdGenerator 使用
+func (g *ULIDGenerator) NextId() uint64 {
+	return g.s.NextId()
+}
+
+// Next 生成ULID
+func (g *ULIDGenerator) Next() ULID {
+	var u ULID
+	binary.BigEndian.PutUint64(u[:8], g.NextId())
+	randomBytes(u[8:])
+	return u
+}
+
+// NextString 生成ULID字符串
+func (g *ULIDGenerator) NextString() string {
+	return g.Next().String()
+}
+
+// newMillisCounter 创建布局为 48位unix毫秒 | counterBits位计数器 的 Snowflake
+// 计数器每毫秒以最高位为0的随机值起始,保留至少一半的空间用于递增
+// 默认用尽时等待下一毫秒而不是借用未来时间, 持续高负载下时间戳不会逐渐超前而被误判为时钟回退
+func newMillisCounter(counterBits uint8, opts []SnowflakeOpt) *Snowflake {
+	all := make([]SnowflakeOpt, 0, len(opts)+3)
+	all = append(all, WithSnowflakeRollback(RollbackWait, time.Second))
+	all = append(all, opts...)
+	all = append(all, WithSnowflakeBits(0, counterBits), WithSnowflakeEpoch(time.UnixMilli(0)), WithSnowflakeWorkerId(0))
+	s := NewSnowflake(all...)
+	half := uint64(1)<<(counterBits-1) - 1
+	s.seqSeed = func() uint64 {
+		var b [8]byte
+		randomBytes(b[:])
+		return binary.BigEndian.Uint64(b[:]) & half
+	}
+	return s
+}
+
+func randomBytes(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+}
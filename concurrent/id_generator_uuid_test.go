@@ -0,0 +1,127 @@
+package concurrent
+
+import (
+    "regexp"
+    "sync"
+    "testing"
+    "time"
+)
+
+var (
+    _ IdGenerator = (*UUIDv7Generator)(nil)
+    _ IdGenerator = (*ULIDGenerator)(nil)
+    _ IdGenerator = (*SegmentIdGenerator)(nil)
+)
+
+func Test_UUIDv7_Format(t *testing.T) {
+    clock := &fakeClock{t: time.UnixMilli(1700000000123).UnixNano()}
+    g := NewUUIDv7Generator(WithSnowflakeClock(clock.now))
+    u := g.Next()
+    if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(u.String()) {
+        t.Fatalf("格式错误: %s", u)
+    }
+    if u.Time().UnixMilli() != 1700000000123 {
+        t.Fatalf("time=%v", u.Time())
+    }
+}
+
+func Test_ULID_Format(t *testing.T) {
+    clock := &fakeClock{t: time.UnixMilli(1700000000123).UnixNano()}
+    g := NewULIDGenerator(WithSnowflakeClock(clock.now))
+    u := g.Next()
+    s := u.String()
+    if !regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`).MatchString(s) {
+        t.Fatalf("格式错误: %s", s)
+    }
+    if u.Time().UnixMilli() != 1700000000123 {
+        t.Fatalf("time=%v", u.Time())
+    }
+    // 时间戳占前10个字符
+    var zero ULID
+    zero[5] = 1
+    if zero.String() != "00000000010000000000000000" {
+        t.Fatalf("编码错误: %s", zero.String())
+    }
+}
+
+func Test_UUIDv7_ULID_Monotonic(t *testing.T) {
+    t.Parallel()
+    // 固定时钟, 显式使用 RollbackBorrow 时计数器用尽后借用未来时间, 仍需严格递增
+    clock := &fakeClock{t: time.UnixMilli(1700000000000).UnixNano()}
+    uuid := NewUUIDv7Generator(WithSnowflakeClock(clock.now), WithSnowflakeRollback(RollbackBorrow, time.Hour))
+    ulid := NewULIDGenerator(WithSnowflakeClock(clock.now), WithSnowflakeRollback(RollbackBorrow, time.Hour))
+    var lastUUID, lastULID string
+    for i := 0; i < 20000; i++ {
+        u, l := uuid.NextString(), ulid.NextString()
+        if u <= lastUUID || l <= lastULID {
+            t.Fatalf("i=%d uuid %s<=%s ulid %s<=%s", i, u, lastUUID, l, lastULID)
+        }
+        lastUUID, lastULID = u, l
+    }
+}
+
+func Test_UUIDv7_ConcurrentUnique(t *testing.T) {
+    t.Parallel()
+    g := NewUUIDv7Generator()
+    const goroutines, n = 4, 2000
+    var mu sync.Mutex
+    seen := make(map[UUID]bool, goroutines*n)
+    var wg sync.WaitGroup
+    wg.Add(goroutines)
+    for i := 0; i < goroutines; i++ {
+        go func() {
+            defer wg.Done()
+            ids := make([]UUID, n)
+            for j := range ids {
+                ids[j] = g.Next()
+            }
+            mu.Lock()
+            defer mu.Unlock()
+            for _, id := range ids {
+                if seen[id] {
+                    t.Errorf("重复id: %s", id)
+                    return
+                }
+                seen[id] = true
+            }
+        }()
+    }
+    wg.Wait()
+}
+
+func Test_UUIDv7_ULID_SustainedLoad(t *testing.T) {
+    t.Parallel()
+    // 持续高负载超过1s, 计数器频繁用尽, 不能因时间戳超前被误判为时钟回退而panic
+    uuid, ulid := NewUUIDv7Generator(), NewULIDGenerator()
+    deadline := time.Now().Add(1200 * time.Millisecond)
+    const goroutines = 8
+    var wg sync.WaitGroup
+    wg.Add(goroutines)
+    for i := 0; i < goroutines; i++ {
+        go func() {
+            defer wg.Done()
+            defer func() {
+                if a := recover(); a != nil {
+                    t.Errorf("panic: %v", a)
+                }
+            }()
+            var lastUUID, lastULID uint64
+            for time.Now().Before(deadline) {
+                for j := 0; j < 1000; j++ {
+                    u, l := uuid.NextId(), ulid.NextId()
+                    // 同一goroutine中观察到的ID递增
+                    if u <= lastUUID || l <= lastULID {
+                        t.Errorf("未递增: uuid %x<=%x ulid %x<=%x", u, lastUUID, l, lastULID)
+                        return
+                    }
+                    lastUUID, lastULID = u, l
+                }
+            }
+            // 时间戳不超前于时钟
+            if ms := uuid.Next().Time().UnixMilli(); ms > time.Now().UnixMilli() {
+                t.Errorf("uuid时间戳超前: %d", ms)
+            }
+        }()
+    }
+    wg.Wait()
+}
@@ -0,0 +1,108 @@
+package concurrent
+
+import (
+    "math"
+    "sync/atomic"
+)
+
+// Int64Accumulator 使用自定义二元运算累积int64,与 Int64Adder 相同的分段设计
+// 作用类似于java.util.concurrent.atomic.LongAccumulator
+//
+// op 必须满足交换律与结合律,identity 为op的单位元(op(identity, x) == x),例如:
+//   - 最大值: op=max, identity=math.MinInt64
+//   - 最小值: op=min, identity=math.MaxInt64
+//
+// 必须使用 NewInt64Accumulator 等构造函数创建
+type Int64Accumulator struct {
+    base int64
+    adderCells
+    op       func(a, b int64) int64
+    identity int64
+}
+
+// NewInt64Accumulator 创建自定义运算的累加器,初始值为identity
+func NewInt64Accumulator(op func(a, b int64) int64, identity int64) *Int64Accumulator {
+    if op == nil {
+        panic("op不能为nil")
+    }
+    return &Int64Accumulator{base: identity, op: op, identity: identity}
+}
+
+// NewMaxAccumulator 创建统计最大值的累加器,未累积任何值时 Get 返回 math.MinInt64
+func NewMaxAccumulator() *Int64Accumulator {
+    return NewInt64Accumulator(maxInt64, math.MinInt64)
+}
+
+// NewMinAccumulator 创建统计最小值的累加器,未累积任何值时 Get 返回 math.MaxInt64
+func NewMinAccumulator() *Int64Accumulator {
+    return NewInt64Accumulator(minInt64, math.MaxInt64)
+}
+
+// Accumulate 累积v,手动提供goid来提高性能
+func (a *Int64Accumulator) Accumulate(goid int64, v int64) {
+    if a.accumulateNoCompete(v) {
+        return
+    }
+    a.updateCell(goid, func(old int64) int64 {
+        return a.op(old, v)
+    })
+}
+
+func (a *Int64Accumulator) AccumulateSimple(v int64) {
+    if a.accumulateNoCompete(v) {
+        return
+    }
+    a.updateCell(GoID(), func(old int64) int64 {
+        return a.op(old, v)
+    })
+}
+
+func (a *Int64Accumulator) accumulateNoCompete(v int64) bool {
+    if atomic.LoadInt32(&a.init) == 0 {
+        old := atomic.LoadInt64(&a.base)
+        next := a.op(old, v)
+        // 值未变化时无需写入, 例如max/min的大部分调用
+        if next == old || atomic.CompareAndSwapInt64(&a.base, old, next) {
+            return true
+        }
+    }
+    a.contended(a.identity)
+    return false
+}
+
+// Get 返回当前累积结果
+func (a *Int64Accumulator) Get() int64 {
+    return a.fold(atomic.LoadInt64(&a.base), a.op)
+}
+
+// Reset 重置为identity
+func (a *Int64Accumulator) Reset() {
+    atomic.StoreInt64(&a.base, a.identity)
+    a.reset(a.identity)
+}
+
+// GetAndReset 返回当前累积结果并重置,与并发的 Accumulate 之间不保证原子性
+func (a *Int64Accumulator) GetAndReset() int64 {
+    r := atomic.SwapInt64(&a.base, a.identity)
+    if atomic.LoadInt32(&a.init) != 2 {
+        return r
+    }
+    for i := range a.values {
+        r = a.op(r, atomic.SwapInt64(&a.values[i].int64, a.identity))
+    }
+    return r
+}
+
+func maxInt64(a, b int64) int64 {
+    if a > b {
+        return a
+    }
+    return b
+}
+
+func minInt64(a, b int64) int64 {
+    if a < b {
+        return a
+    }
+    return b
+}
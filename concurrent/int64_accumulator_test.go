@@ -0,0 +1,51 @@
+package concurrent
+
+import (
+    "math"
+    "sync"
+    "testing"
+)
+
+func Test_Int64Accumulator_MaxMin(t *testing.T) {
+    t.Parallel()
+    maxA, minA := NewMaxAccumulator(), NewMinAccumulator()
+    if maxA.Get() != math.MinInt64 || minA.Get() != math.MaxInt64 {
+        t.Fatal("初始值应为identity")
+    }
+    var wg sync.WaitGroup
+    for i := 0; i < 8; i++ {
+        wg.Add(1)
+        i := i
+        go func() {
+            defer wg.Done()
+            for j := 0; j < 1000; j++ {
+                v := int64(i*1000 + j)
+                maxA.AccumulateSimple(v)
+                minA.Accumulate(GoID(), v)
+            }
+        }()
+    }
+    wg.Wait()
+    if maxA.Get() != 7999 || minA.Get() != 0 {
+        t.Fatalf("max=%d min=%d", maxA.Get(), minA.Get())
+    }
+    if v := maxA.GetAndReset(); v != 7999 || maxA.Get() != math.MinInt64 {
+        t.Fatalf("v=%d after=%d", v, maxA.Get())
+    }
+    minA.Reset()
+    minA.AccumulateSimple(5)
+    if minA.Get() != 5 {
+        t.Fatalf("min=%d", minA.Get())
+    }
+}
+
+func Test_Int64Accumulator_Custom(t *testing.T) {
+    // 按位或
+    a := NewInt64Accumulator(func(a, b int64) int64 { return a | b }, 0)
+    for i := 0; i < 8; i++ {
+        a.AccumulateSimple(1 << i)
+    }
+    if a.Get() != 255 {
+        t.Fatalf("get=%d", a.Get())
+    }
+}
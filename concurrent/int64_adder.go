@@ -42,12 +42,76 @@ type int64AdderCeil struct {
     _ [cpuCacheKillerPaddingLength]byte
 }
 
+// adderCells Int64Adder 系列共用的分段单元,首次出现竞争时才分配
+type adderCells struct {
+    // init 三态: 0=未初始化, 1=初始化中, 2=就绪
+    init   int32
+    values []int64AdderCeil
+}
+
+// contended base的CAS出现竞争时调用,确保分段单元初始化完成,identity 为分段单元的初始值
+func (c *adderCells) contended(identity int64) {
+    if atomic.CompareAndSwapInt32(&c.init, 0, 1) {
+        //无扩容功能,使用该工具场景,并不会特别需要节省内存
+        ceils := make([]int64AdderCeil, slotNumber)
+        for i := range ceils {
+            ceils[i] = int64AdderCeil{int64: identity}
+        }
+        c.values = ceils
+        // 必须先写 values 再发布: store 之前的写入对读到该值的 load 可见
+        atomic.StoreInt32(&c.init, 2)
+        return
+    }
+    //等待初始化完成, init==2 后 values 必然可见
+    for i := 0; atomic.LoadInt32(&c.init) != 2; i++ {
+        if i > 10 {
+            runtime.Gosched()
+        }
+    }
+}
+
+// cell 返回goid对应的分段单元,调用方需保证已初始化
+func (c *adderCells) cell(goid int64) *int64 {
+    return &c.values[int(goid)&modNumber].int64
+}
+
+// updateCell 对分段单元执行CAS循环更新
+func (c *adderCells) updateCell(goid int64, f func(old int64) int64) {
+    p := c.cell(goid)
+    for {
+        old := atomic.LoadInt64(p)
+        if atomic.CompareAndSwapInt64(p, old, f(old)) {
+            return
+        }
+    }
+}
+
+// fold 以base为初始值,用op合并所有分段单元
+func (c *adderCells) fold(base int64, op func(a, b int64) int64) int64 {
+    if atomic.LoadInt32(&c.init) != 2 {
+        return base
+    }
+    for i := range c.values {
+        base = op(base, atomic.LoadInt64(&c.values[i].int64))
+    }
+    return base
+}
+
+// reset 将所有分段单元设为identity
+func (c *adderCells) reset(identity int64) {
+    if atomic.LoadInt32(&c.init) != 2 {
+        return
+    }
+    for i := range c.values {
+        atomic.StoreInt64(&c.values[i].int64, identity)
+    }
+}
+
 // Int64Adder 用于统计int64类型的数据
 // 作用类似于java.util.concurrent.atomic.LongAdder,并参考了部分代码
 type Int64Adder struct {
-    init   int32
-    base   int64
-    values []int64AdderCeil
+    base int64
+    adderCells
 }
 
 // Add 增加v,手动提供goid来提高性能
@@ -59,41 +123,19 @@ func (l *Int64Adder) Add(goid int64, v int64) {
 }
 
 func (l *Int64Adder) addNoCompete(v int64) bool {
-    // init 三态: 0=未初始化, 1=初始化中, 2=就绪
     if atomic.LoadInt32(&l.init) == 0 {
         old := atomic.LoadInt64(&l.base)
         //没有并发竞争的场景下,直接CAS
         if atomic.CompareAndSwapInt64(&l.base, old, old+v) {
             return true
         }
-        if atomic.CompareAndSwapInt32(&l.init, 0, 1) {
-            //无扩容功能,使用该工具场景,并不会特别需要节省内存
-            ceils := make([]int64AdderCeil, slotNumber)
-            for i := range ceils {
-                ceils[i] = int64AdderCeil{int64: 0}
-            }
-            l.values = ceils
-            // 必须先写 values 再发布: store 之前的写入对读到该值的 load 可见
-            atomic.StoreInt32(&l.init, 2)
-            return false
-        }
-    }
-    if atomic.LoadInt32(&l.init) != 2 {
-        //等待初始化完成, init==2 后 values 必然可见
-        for i := 0; ; i++ {
-            if atomic.LoadInt32(&l.init) == 2 {
-                break
-            }
-            if i > 10 {
-                runtime.Gosched()
-            }
-        }
     }
+    l.contended(0)
     return false
 }
 
 func (l *Int64Adder) addCompete(goid int64, v int64) {
-    atomic.AddInt64(&l.values[int(goid)&modNumber].int64, v)
+    atomic.AddInt64(l.cell(goid), v)
 }
 
 func (l *Int64Adder) Decrement(goid int64) {
@@ -126,14 +168,7 @@ func (l *Int64Adder) AddSimple(v int64) {
 }
 
 func (l *Int64Adder) Sum() int64 {
-    r := atomic.LoadInt64(&l.base)
-    if atomic.LoadInt32(&l.init) != 2 {
-        return r
-    }
-    for i := range l.values {
-        r += atomic.LoadInt64(&l.values[i].int64)
-    }
-    return r
+    return l.fold(atomic.LoadInt64(&l.base), addInt64)
 }
 
 func (l *Int64Adder) SumInt() int {
@@ -141,11 +176,10 @@ func (l *Int64Adder) SumInt() int {
 }
 
 func (l *Int64Adder) Reset() {
-    l.base = 0
-    if atomic.LoadInt32(&l.init) != 2 {
-        return
-    }
-    for i := range l.values {
-        atomic.StoreInt64(&l.values[i].int64, 0)
-    }
+    atomic.StoreInt64(&l.base, 0)
+    l.reset(0)
+}
+
+func addInt64(a, b int64) int64 {
+    return a + b
 }
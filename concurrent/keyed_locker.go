@@ -0,0 +1,150 @@
+package concurrent
+
+import (
+    "sync"
+
+    "github.com/mzzsfy/go-util/unsafe"
+)
+
+// keyedLockerShards 精确模式的分片数
+const keyedLockerShards = 32
+
+// KeyedLocker 按key加锁,相同key互斥,不同key可并发
+//
+// 两种模式:
+//   - 分段模式(NewStripedKeyedLocker): 固定数量的锁,key按hash映射到其中一个,内存固定,不同key可能共享同一把锁
+//   - 精确模式(NewKeyedLocker): 每个key一把锁,引用计数归零(最后一个持有/等待者释放)时删除,内存随活跃key数量变化
+//
+// 注意: 分段模式下不同key可能映射到同一把锁,同一goroutine嵌套锁定多个key可能死锁
+type KeyedLocker[K comparable] struct {
+    hasher  unsafe.Hasher[K]
+    mask    uint64
+    striped []paddedMutex
+    shards  []keyedLockerShard[K]
+}
+
+type paddedMutex struct {
+    sync.Mutex
+    _ [cpuCacheKillerPaddingLength]byte
+}
+
+type keyedLockerShard[K comparable] struct {
+    mu    sync.Mutex
+    locks map[K]*refMutex
+    _     [cpuCacheKillerPaddingLength]byte
+}
+
+type refMutex struct {
+    sync.Mutex
+    // 持有及等待该锁的数量
+    ref int32
+}
+
+// NewStripedKeyedLocker 创建分段模式的按key锁,stripes会被向上取整到最近的2的幂
+func NewStripedKeyedLocker[K comparable](stripes int) *KeyedLocker[K] {
+    if stripes < 1 {
+        stripes = 1
+    }
+    stripes = nextPow2(stripes)
+    return &KeyedLocker[K]{
+        hasher:  unsafe.NewHasher[K](),
+        mask:    uint64(stripes - 1),
+        striped: make([]paddedMutex, stripes),
+    }
+}
+
+// NewKeyedLocker 创建精确模式的按key锁,key的锁在无人持有及等待时被回收
+func NewKeyedLocker[K comparable]() *KeyedLocker[K] {
+    shards := make([]keyedLockerShard[K], keyedLockerShards)
+    for i := range shards {
+        shards[i].locks = map[K]*refMutex{}
+    }
+    return &KeyedLocker[K]{
+        hasher: unsafe.NewHasher[K](),
+        mask:   keyedLockerShards - 1,
+        shards: shards,
+    }
+}
+
+func (l *KeyedLocker[K]) index(key K) uint64 {
+    return l.hasher.Hash(key) & l.mask
+}
+
+func (l *KeyedLocker[K]) Lock(key K) {
+    if l.striped != nil {
+        l.striped[l.index(key)].Lock()
+        return
+    }
+    s := &l.shards[l.index(key)]
+    s.mu.Lock()
+    m := s.locks[key]
+    if m == nil {
+        m = &refMutex{}
+        s.locks[key] = m
+    }
+    m.ref++
+    s.mu.Unlock()
+    m.Lock()
+}
+
+// TryLock 非阻塞加锁
+func (l *KeyedLocker[K]) TryLock(key K) bool {
+    if l.striped != nil {
+        return l.striped[l.index(key)].TryLock()
+    }
+    s := &l.shards[l.index(key)]
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    m := s.locks[key]
+    if m == nil {
+        m = &refMutex{}
+        s.locks[key] = m
+    }
+    if !m.TryLock() {
+        return false
+    }
+    m.ref++
+    return true
+}
+
+func (l *KeyedLocker[K]) Unlock(key K) {
+    if l.striped != nil {
+        l.striped[l.index(key)].Unlock()
+        return
+    }
+    s := &l.shards[l.index(key)]
+    s.mu.Lock()
+    m := s.locks[key]
+    if m == nil {
+        s.mu.Unlock()
+        panic("unlock of unlocked lock")
+    }
+    m.ref--
+    if m.ref == 0 {
+        delete(s.locks, key)
+    }
+    s.mu.Unlock()
+    m.Unlock()
+}
+
+// RunWithLock 持有key的锁执行f
+func (l *KeyedLocker[K]) RunWithLock(key K, f func()) {
+    l.Lock(key)
+    defer l.Unlock(key)
+    f()
+}
+
+// Len 精确模式下返回当前持有锁的key数量(包含等待中的),分段模式返回锁的数量
+func (l *KeyedLocker[K]) Len() int {
+    if l.striped != nil {
+        return len(l.striped)
+    }
+    n := 0
+    for i := range l.shards {
+        s := &l.shards[i]
+        s.mu.Lock()
+        n += len(s.locks)
+        s.mu.Unlock()
+    }
+    return n
+}
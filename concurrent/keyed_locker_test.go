@@ -0,0 +1,97 @@
+package concurrent
+
+import (
+    "strconv"
+    "sync"
+    "testing"
+)
+
+func keyedLockers() map[string]func() *KeyedLocker[string] {
+    return map[string]func() *KeyedLocker[string]{
+        "striped": func() *KeyedLocker[string] { return NewStripedKeyedLocker[string](16) },
+        "exact":   NewKeyedLocker[string],
+    }
+}
+
+func Test_KeyedLocker_Mutex(t *testing.T) {
+    t.Parallel()
+    for name, newLocker := range keyedLockers() {
+        newLocker := newLocker
+        t.Run(name, func(t *testing.T) {
+            t.Parallel()
+            l := newLocker()
+            counters := make([]int, 4)
+            var wg sync.WaitGroup
+            for i := 0; i < 8; i++ {
+                wg.Add(1)
+                go func() {
+                    defer wg.Done()
+                    for j := 0; j < 500; j++ {
+                        k := j % len(counters)
+                        l.RunWithLock("order-"+strconv.Itoa(k), func() {
+                            counters[k]++
+                        })
+                    }
+                }()
+            }
+            wg.Wait()
+            for k, c := range counters {
+                if c != 1000 {
+                    t.Fatalf("key=%d count=%d", k, c)
+                }
+            }
+        })
+    }
+}
+
+func Test_KeyedLocker_TryLock(t *testing.T) {
+    for name, newLocker := range keyedLockers() {
+        t.Run(name, func(t *testing.T) {
+            l := newLocker()
+            if !l.TryLock("a") {
+                t.Fatal("TryLock应成功")
+            }
+            done := make(chan bool)
+            go func() { done <- l.TryLock("a") }()
+            if <-done {
+                t.Fatal("key已被锁定")
+            }
+            l.Unlock("a")
+            go func() { done <- l.TryLock("a") }()
+            if !<-done {
+                t.Fatal("释放后TryLock应成功")
+            }
+            l.Unlock("a")
+        })
+    }
+}
+
+func Test_KeyedLocker_ExactRelease(t *testing.T) {
+    l := NewKeyedLocker[int]()
+    for i := 0; i < 100; i++ {
+        l.Lock(i)
+    }
+    if l.Len() != 100 {
+        t.Fatalf("len=%d", l.Len())
+    }
+    for i := 0; i < 100; i++ {
+        l.Unlock(i)
+    }
+    if l.Len() != 0 {
+        t.Fatalf("最后一个持有者释放后应回收, len=%d", l.Len())
+    }
+    func() {
+        defer func() {
+            if recover() == nil {
+                t.Fatal("应panic")
+            }
+        }()
+        l.Unlock(1)
+    }()
+}
+
+func Test_KeyedLocker_StripedLen(t *testing.T) {
+    if n := NewStripedKeyedLocker[int](10).Len(); n != 16 {
+        t.Fatalf("len=%d", n)
+    }
+}
@@ -0,0 +1,93 @@
+package concurrent
+
+import (
+    "context"
+    "errors"
+    "sync/atomic"
+    "time"
+)
+
+// ErrLimitExceeded 请求数量超过限流器容量,等待也无法满足时返回此错误
+var ErrLimitExceeded = errors.New("rate limit exceeded")
+
+// Limiter 限流器接口,TokenBucket、LeakyBucket、SlidingWindow 均实现了该接口
+type Limiter interface {
+    // Allow 非阻塞获取1个许可
+    Allow() bool
+    // AllowN 非阻塞获取n个许可,n超过限流器容量时恒为false
+    AllowN(n int) bool
+    // Wait 阻塞直到获取1个许可,ctx取消时返回ctx.Err(),无法满足时返回 ErrLimitExceeded
+    Wait(ctx context.Context) error
+    // Reserve 预约1个许可,调用方按 Reservation.Delay 自行等待
+    Reserve() *Reservation
+}
+
+// Reservation 许可预约结果
+type Reservation struct {
+    ok bool
+    // 许可可用时间(纳秒),与now使用同一时钟
+    at       int64
+    now      func() int64
+    canceled int32
+    cancel   func()
+}
+
+// OK 预约是否成功,失败时 Delay 无意义
+func (r *Reservation) OK() bool {
+    return r.ok
+}
+
+// Delay 距离许可可用还需等待的时间
+func (r *Reservation) Delay() time.Duration {
+    if !r.ok {
+        return 0
+    }
+    if d := r.at - r.now(); d > 0 {
+        return time.Duration(d)
+    }
+    return 0
+}
+
+// Cancel 放弃预约,许可尚未到期时归还给限流器,重复调用无效
+func (r *Reservation) Cancel() {
+    if !r.ok || r.cancel == nil || !atomic.CompareAndSwapInt32(&r.canceled, 0, 1) {
+        return
+    }
+    r.cancel()
+}
+
+// waitReservation 等待预约到期,ctx取消时归还预约
+func waitReservation(ctx context.Context, r *Reservation) error {
+    if err := ctx.Err(); err != nil {
+        r.Cancel()
+        return err
+    }
+    if !r.OK() {
+        return ErrLimitExceeded
+    }
+    d := r.Delay()
+    if d <= 0 {
+        return nil
+    }
+    t := time.NewTimer(d)
+    defer t.Stop()
+    select {
+    case <-t.C:
+        return nil
+    case <-ctx.Done():
+        r.Cancel()
+        return ctx.Err()
+    }
+}
+
+// rateInterval 每秒rate个许可对应的发放间隔(纳秒)
+func rateInterval(rate float64) int64 {
+    if rate <= 0 {
+        panic("rate必须大于0")
+    }
+    interval := int64(float64(time.Second) / rate)
+    if interval < 1 {
+        interval = 1
+    }
+    return interval
+}
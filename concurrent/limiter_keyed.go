@@ -0,0 +1,203 @@
+package concurrent
+
+import (
+    "context"
+    "math"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "github.com/mzzsfy/go-util/storage"
+)
+
+// KeyedLimiter 按key分别限流的限流器集合,例如按用户/IP限流
+//
+// 每个key的限流器在首次访问时由factory创建,超过ttl未被访问的key会被淘汰,
+// 淘汰在访问时惰性触发(每ttl/2最多一次,在后台goroutine中扫描,不阻塞访问方),也可调用 Sweep 主动清理
+type KeyedLimiter[K comparable] struct {
+    limiters storage.Map[K, *keyedLimiterEntry]
+    factory  func(K) Limiter
+    ttl      int64
+    // 上次扫描时间(纳秒)
+    lastSweep int64
+    mu        sync.Mutex
+
+    hits      Int64Adder
+    rejects   Int64Adder
+    evictions Int64Adder
+
+    // Now 返回当前时间(纳秒),为nil时使用 time.Now,用于测试时注入时钟
+    Now func() int64
+}
+
+// keyedLimiterEvicted 已淘汰的 keyedLimiterEntry 的 lastUsed
+const keyedLimiterEvicted = math.MinInt64
+
+type keyedLimiterEntry struct {
+    limiter Limiter
+    // 最后访问时间(纳秒),淘汰时通过CAS置为 keyedLimiterEvicted
+    lastUsed int64
+}
+
+// touch 刷新访问时间,已被淘汰时返回false
+// 与淘汰的CAS互斥: 刷新成功的entry不会被之前判断为过期的扫描删除,返回的限流器不会成为map之外的孤儿
+func (e *keyedLimiterEntry) touch(now int64) bool {
+    for {
+        last := atomic.LoadInt64(&e.lastUsed)
+        if last == keyedLimiterEvicted {
+            return false
+        }
+        if last >= now || atomic.CompareAndSwapInt64(&e.lastUsed, last, now) {
+            return true
+        }
+    }
+}
+
+// KeyedLimiterStats 限流统计
+type KeyedLimiterStats struct {
+    // Keys 当前持有的key数量
+    Keys int
+    // Hits 获取许可成功次数
+    Hits int64
+    // Rejects 获取许可失败次数
+    Rejects int64
+    // Evictions 因空闲被淘汰的key数量
+    Evictions int64
+}
+
+// NewKeyedLimiter 创建按key限流的限流器集合
+// ttl: key空闲超过该时间后被淘汰,下次访问时重新创建
+// factory: 为新key创建限流器
+func NewKeyedLimiter[K comparable](ttl time.Duration, factory func(key K) Limiter) *KeyedLimiter[K] {
+    if ttl <= 0 {
+        panic("ttl必须大于0")
+    }
+    if factory == nil {
+        panic("factory不能为nil")
+    }
+    return &KeyedLimiter[K]{
+        limiters: storage.NewMap(storage.MapTypeSwissConcurrent[K, *keyedLimiterEntry]()),
+        factory:  factory,
+        ttl:      int64(ttl),
+    }
+}
+
+func (k *KeyedLimiter[K]) now() int64 {
+    if k.Now == nil {
+        return time.Now().UnixNano()
+    }
+    return k.Now()
+}
+
+// Get 返回key对应的限流器,不存在时创建
+func (k *KeyedLimiter[K]) Get(key K) Limiter {
+    now := k.now()
+    k.maybeSweep(now)
+    if e, ok := k.limiters.Get(key); ok && e.touch(now) {
+        return e.limiter
+    }
+    k.mu.Lock()
+    defer k.mu.Unlock()
+    e, ok := k.limiters.Get(key)
+    if !ok || !e.touch(now) {
+        e = &keyedLimiterEntry{limiter: k.factory(key), lastUsed: now}
+        k.limiters.Put(key, e)
+    }
+    return e.limiter
+}
+
+func (k *KeyedLimiter[K]) Allow(key K) bool {
+    return k.count(k.Get(key).Allow())
+}
+
+func (k *KeyedLimiter[K]) AllowN(key K, n int) bool {
+    return k.count(k.Get(key).AllowN(n))
+}
+
+func (k *KeyedLimiter[K]) Wait(ctx context.Context, key K) error {
+    err := k.Get(key).Wait(ctx)
+    k.count(err == nil)
+    return err
+}
+
+func (k *KeyedLimiter[K]) Reserve(key K) *Reservation {
+    r := k.Get(key).Reserve()
+    k.count(r.OK())
+    return r
+}
+
+func (k *KeyedLimiter[K]) count(ok bool) bool {
+    if ok {
+        k.hits.IncrementSimple()
+    } else {
+        k.rejects.IncrementSimple()
+    }
+    return ok
+}
+
+// Delete 立即移除key对应的限流器
+func (k *KeyedLimiter[K]) Delete(key K) {
+    k.mu.Lock()
+    if e, ok := k.limiters.Get(key); ok {
+        atomic.StoreInt64(&e.lastUsed, keyedLimiterEvicted)
+        k.limiters.Delete(key)
+    }
+    k.mu.Unlock()
+}
+
+// Len 当前持有的key数量
+func (k *KeyedLimiter[K]) Len() int {
+    return k.limiters.Count()
+}
+
+// Stats 返回统计信息
+func (k *KeyedLimiter[K]) Stats() KeyedLimiterStats {
+    return KeyedLimiterStats{
+        Keys:      k.limiters.Count(),
+        Hits:      k.hits.Sum(),
+        Rejects:   k.rejects.Sum(),
+        Evictions: k.evictions.Sum(),
+    }
+}
+
+// maybeSweep 距上次扫描超过ttl/2时由一个调用方启动后台扫描
+func (k *KeyedLimiter[K]) maybeSweep(now int64) {
+    last := atomic.LoadInt64(&k.lastSweep)
+    if now-last < k.ttl/2 || !atomic.CompareAndSwapInt64(&k.lastSweep, last, now) {
+        return
+    }
+    go k.sweep(now)
+}
+
+// Sweep 立即淘汰空闲超过ttl的key,返回淘汰数量
+func (k *KeyedLimiter[K]) Sweep() int {
+    now := k.now()
+    atomic.StoreInt64(&k.lastSweep, now)
+    return k.sweep(now)
+}
+
+func (k *KeyedLimiter[K]) sweep(now int64) int {
+    n := 0
+    expire := now - k.ttl
+    // 持有创建锁,避免与Get的创建路径交错导致刚创建的限流器被误删
+    k.mu.Lock()
+    defer k.mu.Unlock()
+    cb := func(_ K, e *keyedLimiterEntry) (bool, bool) {
+        // 先标记再删除,并发的Get刷新成功则保留,看到标记则走创建路径
+        last := atomic.LoadInt64(&e.lastUsed)
+        if last <= expire && atomic.CompareAndSwapInt64(&e.lastUsed, last, keyedLimiterEvicted) {
+            n++
+            return true, false
+        }
+        return false, false
+    }
+    if m, ok := k.limiters.(storage.IterDeleteMap[K, *keyedLimiterEntry]); ok {
+        m.IterDelete(cb)
+    } else {
+        storage.IterDelete(k.limiters, cb)
+    }
+    if n > 0 {
+        k.evictions.AddSimple(int64(n))
+    }
+    return n
+}
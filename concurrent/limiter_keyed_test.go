@@ -0,0 +1,191 @@
+package concurrent
+
+import (
+    "context"
+    "runtime"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+func Test_KeyedLimiter_PerKey(t *testing.T) {
+    clock := &fakeClock{t: time.Now().UnixNano()}
+    created := 0
+    kl := NewKeyedLimiter(time.Minute, func(key string) Limiter {
+        created++
+        b := NewTokenBucket(1, 2)
+        b.Now = clock.now
+        return b
+    })
+    kl.Now = clock.now
+    for _, key := range []string{"a", "b"} {
+        for i := 0; i < 2; i++ {
+            if !kl.Allow(key) {
+                t.Fatalf("key=%s 第%d次应通过", key, i)
+            }
+        }
+        if kl.Allow(key) {
+            t.Fatalf("key=%s 应被限流", key)
+        }
+    }
+    if created != 2 || kl.Len() != 2 {
+        t.Fatalf("created=%d len=%d", created, kl.Len())
+    }
+    s := kl.Stats()
+    if s.Keys != 2 || s.Hits != 4 || s.Rejects != 2 || s.Evictions != 0 {
+        t.Fatalf("stats=%+v", s)
+    }
+}
+
+func Test_KeyedLimiter_IdleEviction(t *testing.T) {
+    clock := &fakeClock{t: time.Now().UnixNano()}
+    kl := NewKeyedLimiter(time.Second, func(key int) Limiter {
+        return NewTokenBucket(1, 1)
+    })
+    kl.Now = clock.now
+    kl.Allow(1)
+    kl.Allow(2)
+    clock.add(600 * time.Millisecond)
+    // 访问key 2,刷新其访问时间;同时触发惰性扫描,此时均未过期
+    kl.Allow(2)
+    if kl.Len() != 2 {
+        t.Fatalf("len=%d", kl.Len())
+    }
+    clock.add(600 * time.Millisecond)
+    // key 1 已空闲1.2s,被后台的惰性扫描淘汰
+    kl.Allow(3)
+    for i := 0; i < 1000 && kl.Stats().Evictions == 0; i++ {
+        time.Sleep(time.Millisecond)
+    }
+    if kl.Len() != 2 || kl.Stats().Evictions != 1 {
+        t.Fatalf("len=%d stats=%+v", kl.Len(), kl.Stats())
+    }
+    clock.add(2 * time.Second)
+    if n := kl.Sweep(); n != 2 {
+        t.Fatalf("sweep=%d", n)
+    }
+    if s := kl.Stats(); s.Keys != 0 || s.Evictions != 3 {
+        t.Fatalf("stats=%+v", s)
+    }
+    // 淘汰后重新创建,限流状态重置
+    if !kl.Allow(1) {
+        t.Fatal("重新创建的限流器应通过")
+    }
+}
+
+func Test_KeyedLimiter_WaitAndReserve(t *testing.T) {
+    kl := NewKeyedLimiter(time.Minute, func(key string) Limiter {
+        return NewLeakyBucket(1, 0)
+    })
+    if err := kl.Wait(context.Background(), "a"); err != nil {
+        t.Fatal(err)
+    }
+    if r := kl.Reserve("a"); r.OK() {
+        t.Fatal("漏桶无排队容量,预约应失败")
+    }
+    if s := kl.Stats(); s.Hits != 1 || s.Rejects != 1 {
+        t.Fatalf("stats=%+v", s)
+    }
+}
+
+func Test_KeyedLimiter_ConcurrentCreate(t *testing.T) {
+    t.Parallel()
+    var mu sync.Mutex
+    created := map[int]int{}
+    kl := NewKeyedLimiter(time.Minute, func(key int) Limiter {
+        mu.Lock()
+        created[key]++
+        mu.Unlock()
+        return NewTokenBucket(1, 100)
+    })
+    var wg sync.WaitGroup
+    for i := 0; i < 8; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for k := 0; k < 10; k++ {
+                kl.Allow(k)
+            }
+        }()
+    }
+    wg.Wait()
+    for k := 0; k < 10; k++ {
+        if created[k] != 1 {
+            t.Fatalf("key=%d 创建了%d次", k, created[k])
+        }
+    }
+    if s := kl.Stats(); s.Hits != 80 || s.Keys != 10 {
+        t.Fatalf("stats=%+v", s)
+    }
+}
+
+func Test_KeyedLimiter_NoOrphan(t *testing.T) {
+    t.Parallel()
+    clock := &fakeClock{t: time.Now().UnixNano()}
+    kl := NewKeyedLimiter(time.Second, func(key int) Limiter {
+        return NewTokenBucket(1, 1)
+    })
+    kl.Now = clock.now
+    // Get 读取到entry后、刷新访问时间前entry被扫描淘汰: 刷新失败, 不返回已淘汰的限流器
+    old := kl.Get(100)
+    e, _ := kl.limiters.Get(100)
+    clock.add(2 * time.Second)
+    if kl.Sweep() != 1 || e.touch(clock.now()) {
+        t.Fatal("已淘汰的entry不应刷新成功")
+    }
+    if l := kl.Get(100); l == old || l != kl.Get(100) {
+        t.Fatal("应重新创建限流器")
+    }
+    kl.Delete(100)
+
+    // 时钟只在没有Get进行时前进, 同一时刻内连续两次Get必须返回同一个限流器,
+    // 即刷新访问时间的Get不能返回被并发扫描删除的限流器
+    var clockMu sync.RWMutex
+    var rounds, step int64
+    stop := make(chan struct{})
+    var wg sync.WaitGroup
+    for i := 0; i < 4; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for {
+                select {
+                case <-stop:
+                    return
+                default:
+                }
+                clockMu.RLock()
+                // 两组key交替访问, 每组空闲超过ttl后在被访问的同时被扫描
+                base := int(atomic.LoadInt64(&step)%2) * 8
+                for k := base; k < base+8; k++ {
+                    if l1, l2 := kl.Get(k), kl.Get(k); l1 != l2 {
+                        t.Errorf("key=%d 返回了已被淘汰的限流器", k)
+                    }
+                }
+                clockMu.RUnlock()
+                atomic.AddInt64(&rounds, 1)
+                runtime.Gosched()
+            }
+        }()
+    }
+    for i := 0; i < 100; i++ {
+        // 等待 worker 在当前时刻访问过
+        for r := atomic.LoadInt64(&rounds); atomic.LoadInt64(&rounds) == r; {
+            runtime.Gosched()
+        }
+        clockMu.Lock()
+        clock.add(1100 * time.Millisecond)
+        atomic.AddInt64(&step, 1)
+        clockMu.Unlock()
+        // 让 worker 在扫描前后都有机会访问
+        runtime.Gosched()
+        kl.Sweep()
+        runtime.Gosched()
+    }
+    close(stop)
+    wg.Wait()
+    if kl.Stats().Evictions == 0 {
+        t.Fatal("应有淘汰")
+    }
+}
@@ -0,0 +1,102 @@
+package concurrent
+
+import (
+    "context"
+    "sync/atomic"
+    "time"
+)
+
+// LeakyBucket 无锁漏桶限流器,请求以固定间隔匀速通过,不允许突发
+//
+// capacity为桶内最多可排队等待的请求数,Wait/Reserve 排队超过该数量时失败;
+// AllowN(n)一次通过n个请求(n不超过capacity+1),之后的请求需等待n个间隔,平均速率保持不变
+type LeakyBucket struct {
+    // 最后一个已分配的通过时间(纳秒)
+    last int64
+    _    [cpuCacheKillerPaddingLength]byte
+    // 请求通过的间隔(纳秒)
+    interval int64
+    // 最大排队等待时间(纳秒)
+    maxWait int64
+
+    // Now 返回当前时间(纳秒),为nil时使用 time.Now,用于测试时注入时钟
+    Now func() int64
+}
+
+// NewLeakyBucket 创建漏桶
+// rate: 每秒通过的请求数
+// capacity: 最多排队等待的请求数,0表示不排队
+func NewLeakyBucket(rate float64, capacity int) *LeakyBucket {
+    if capacity < 0 {
+        panic("capacity不能小于0")
+    }
+    interval := rateInterval(rate)
+    return &LeakyBucket{
+        interval: interval,
+        maxWait:  int64(capacity) * interval,
+    }
+}
+
+func (b *LeakyBucket) now() int64 {
+    if b.Now == nil {
+        return time.Now().UnixNano()
+    }
+    return b.Now()
+}
+
+func (b *LeakyBucket) Allow() bool {
+    return b.AllowN(1)
+}
+
+// AllowN 漏桶空闲时立即通过n个请求
+// 之后的n-1个间隔相当于排队,超过capacity(即n>capacity+1)时与 Reserve 一致直接失败
+func (b *LeakyBucket) AllowN(n int) bool {
+    if n <= 0 {
+        return true
+    }
+    // 用除法比较,避免n很大时乘法溢出
+    if int64(n-1) > b.maxWait/b.interval {
+        return false
+    }
+    for {
+        now := b.now()
+        last := atomic.LoadInt64(&b.last)
+        if last+b.interval > now {
+            return false
+        }
+        if atomic.CompareAndSwapInt64(&b.last, last, now+int64(n-1)*b.interval) {
+            return true
+        }
+    }
+}
+
+// Reserve 预约下一个通过时间,排队超过capacity时预约失败
+func (b *LeakyBucket) Reserve() *Reservation {
+    for {
+        now := b.now()
+        last := atomic.LoadInt64(&b.last)
+        next := last + b.interval
+        if next < now {
+            next = now
+        }
+        if next-now > b.maxWait {
+            return &Reservation{}
+        }
+        if atomic.CompareAndSwapInt64(&b.last, last, next) {
+            return &Reservation{
+                ok:  true,
+                at:  next,
+                now: b.now,
+                cancel: func() {
+                    // 仍是最后一个预约时才能归还, 否则会打乱后续预约的间隔
+                    atomic.CompareAndSwapInt64(&b.last, next, next-b.interval)
+                },
+            }
+        }
+    }
+}
+
+// Wait 阻塞直到轮到当前请求通过
+func (b *LeakyBucket) Wait(ctx context.Context) error {
+    return waitReservation(ctx, b.Reserve())
+}
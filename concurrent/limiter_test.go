@@ -0,0 +1,224 @@
+package concurrent
+
+import (
+    "context"
+    "errors"
+    "math"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+var (
+    _ Limiter = (*TokenBucket)(nil)
+    _ Limiter = (*LeakyBucket)(nil)
+    _ Limiter = (*SlidingWindow)(nil)
+)
+
+// fakeClock 可手动推进的时钟(纳秒)
+type fakeClock struct {
+    t int64
+}
+
+func (c *fakeClock) now() int64 {
+    return atomic.LoadInt64(&c.t)
+}
+
+func (c *fakeClock) add(d time.Duration) {
+    atomic.AddInt64(&c.t, int64(d))
+}
+
+func Test_TokenBucket_Burst(t *testing.T) {
+    clock := &fakeClock{t: time.Now().UnixNano()}
+    b := NewTokenBucket(10, 5)
+    b.Now = clock.now
+    for i := 0; i < 5; i++ {
+        if !b.Allow() {
+            t.Fatalf("第%d次突发请求应通过", i)
+        }
+    }
+    if b.Allow() {
+        t.Fatal("桶已空,应拒绝")
+    }
+    // 10/s, 100ms补充1个令牌
+    clock.add(100 * time.Millisecond)
+    if !b.Allow() {
+        t.Fatal("补充后应通过")
+    }
+    if b.Allow() {
+        t.Fatal("只补充了1个令牌")
+    }
+    clock.add(time.Second)
+    if !b.AllowN(5) {
+        t.Fatal("满桶时AllowN(5)应通过")
+    }
+    if b.AllowN(6) {
+        t.Fatal("AllowN超过burst应恒为false")
+    }
+}
+
+func Test_TokenBucket_Reserve(t *testing.T) {
+    clock := &fakeClock{t: time.Now().UnixNano()}
+    b := NewTokenBucket(10, 1)
+    b.Now = clock.now
+    r := b.Reserve()
+    if !r.OK() || r.Delay() != 0 {
+        t.Fatalf("ok=%v delay=%v", r.OK(), r.Delay())
+    }
+    r = b.Reserve()
+    if !r.OK() || r.Delay() != 100*time.Millisecond {
+        t.Fatalf("ok=%v delay=%v", r.OK(), r.Delay())
+    }
+    r2 := b.Reserve()
+    if r2.Delay() != 200*time.Millisecond {
+        t.Fatalf("delay=%v", r2.Delay())
+    }
+    r2.Cancel()
+    r2.Cancel()
+    if d := b.Reserve().Delay(); d != 200*time.Millisecond {
+        t.Fatalf("取消后令牌应归还, delay=%v", d)
+    }
+}
+
+func Test_TokenBucket_Wait(t *testing.T) {
+    t.Parallel()
+    b := NewTokenBucket(50, 1)
+    start := time.Now()
+    for i := 0; i < 5; i++ {
+        if err := b.Wait(context.Background()); err != nil {
+            t.Fatal(err)
+        }
+    }
+    // 首个立即通过, 之后每20ms一个
+    if cost := time.Since(start); cost < 70*time.Millisecond {
+        t.Fatalf("等待时间过短: %v", cost)
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+    defer cancel()
+    b = NewTokenBucket(1, 1)
+    b.Allow()
+    if err := b.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+        t.Fatalf("got %v", err)
+    }
+}
+
+func Test_TokenBucket_Concurrent(t *testing.T) {
+    t.Parallel()
+    clock := &fakeClock{t: time.Now().UnixNano()}
+    b := NewTokenBucket(1, 100)
+    b.Now = clock.now
+    var allowed int64
+    var wg sync.WaitGroup
+    for i := 0; i < 8; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for j := 0; j < 100; j++ {
+                if b.Allow() {
+                    atomic.AddInt64(&allowed, 1)
+                }
+            }
+        }()
+    }
+    wg.Wait()
+    if allowed != 100 {
+        t.Fatalf("allowed=%d want=100", allowed)
+    }
+}
+
+func Test_LeakyBucket_Pacing(t *testing.T) {
+    clock := &fakeClock{t: time.Now().UnixNano()}
+    b := NewLeakyBucket(10, 2)
+    b.Now = clock.now
+    if !b.Allow() {
+        t.Fatal("空桶应立即通过")
+    }
+    if b.Allow() {
+        t.Fatal("漏桶不允许突发")
+    }
+    r1, r2, r3 := b.Reserve(), b.Reserve(), b.Reserve()
+    if !r1.OK() || r1.Delay() != 100*time.Millisecond {
+        t.Fatalf("r1 ok=%v delay=%v", r1.OK(), r1.Delay())
+    }
+    if !r2.OK() || r2.Delay() != 200*time.Millisecond {
+        t.Fatalf("r2 ok=%v delay=%v", r2.OK(), r2.Delay())
+    }
+    if r3.OK() {
+        t.Fatal("排队超过capacity应预约失败")
+    }
+    if err := b.Wait(context.Background()); !errors.Is(err, ErrLimitExceeded) {
+        t.Fatalf("got %v", err)
+    }
+    r2.Cancel()
+    if r := b.Reserve(); !r.OK() || r.Delay() != 200*time.Millisecond {
+        t.Fatalf("取消最后一个预约后应可重新预约, ok=%v delay=%v", r.OK(), r.Delay())
+    }
+    clock.add(time.Second)
+    // n-1个间隔超过排队容量时与 Reserve 一致直接失败, 不占用时间
+    if b.AllowN(4) || b.AllowN(math.MaxInt) {
+        t.Fatal("AllowN超过capacity+1应失败")
+    }
+    if !b.AllowN(3) {
+        t.Fatal("空闲时AllowN应通过")
+    }
+    clock.add(200 * time.Millisecond)
+    if b.Allow() {
+        t.Fatal("AllowN(3)后需等待3个间隔")
+    }
+    clock.add(100 * time.Millisecond)
+    if !b.Allow() {
+        t.Fatal("间隔已过应通过")
+    }
+}
+
+func Test_LeakyBucket_Wait(t *testing.T) {
+    t.Parallel()
+    b := NewLeakyBucket(100, 10)
+    start := time.Now()
+    var wg sync.WaitGroup
+    for i := 0; i < 5; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            if err := b.Wait(context.Background()); err != nil {
+                t.Error(err)
+            }
+        }()
+    }
+    wg.Wait()
+    if cost := time.Since(start); cost < 35*time.Millisecond {
+        t.Fatalf("5个请求间隔10ms,耗时过短: %v", cost)
+    }
+}
+
+func Test_SlidingWindow_Limiter(t *testing.T) {
+    var now int64
+    sw := NewSlidingWindow(1000, 10, 5)
+    sw.Now = func() int64 { return atomic.LoadInt64(&now) }
+    // 每个子窗口限额2
+    if !sw.AllowN(2) {
+        t.Fatal("AllowN(2)应通过")
+    }
+    if sw.AllowN(2) {
+        t.Fatal("当前子窗口已满")
+    }
+    if sw.AllowN(11) {
+        t.Fatal("超过总限额应恒为false")
+    }
+    if r := sw.Reserve(); r.OK() {
+        t.Fatal("无配额时预约应失败")
+    }
+    atomic.AddInt64(&now, 200)
+    if r := sw.Reserve(); !r.OK() || r.Delay() != 0 {
+        t.Fatalf("窗口滑动后预约应立即可用, ok=%v delay=%v", r.OK(), r.Delay())
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+    defer cancel()
+    // 时钟不前进, 耗尽配额后等待必然超时
+    for sw.Allow() {
+    }
+    if err := sw.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+        t.Fatalf("got %v", err)
+    }
+}
@@ -0,0 +1,113 @@
+package concurrent
+
+import (
+    "context"
+    "sync/atomic"
+    "time"
+)
+
+// TokenBucket 无锁令牌桶限流器,以固定速率补充令牌,允许最多burst个请求的突发
+//
+// 使用GCRA算法实现,只维护一个"理论到达时间",每次获取许可仅需一次CAS
+type TokenBucket struct {
+    // 理论到达时间(纳秒),小于当前时间表示桶已满
+    tat int64
+    _   [cpuCacheKillerPaddingLength]byte
+    // 每个令牌的间隔(纳秒)
+    interval int64
+    // 桶容量
+    burst int64
+
+    // Now 返回当前时间(纳秒),为nil时使用 time.Now,用于测试时注入时钟
+    Now func() int64
+}
+
+// NewTokenBucket 创建令牌桶,初始为满桶
+// rate: 每秒补充的令牌数
+// burst: 桶容量,即允许的最大突发请求数
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+    if burst < 1 {
+        panic("burst必须大于0")
+    }
+    return &TokenBucket{
+        interval: rateInterval(rate),
+        burst:    int64(burst),
+    }
+}
+
+func (b *TokenBucket) now() int64 {
+    if b.Now == nil {
+        return time.Now().UnixNano()
+    }
+    return b.Now()
+}
+
+func (b *TokenBucket) Allow() bool {
+    return b.AllowN(1)
+}
+
+func (b *TokenBucket) AllowN(n int) bool {
+    if n <= 0 {
+        return true
+    }
+    if int64(n) > b.burst {
+        return false
+    }
+    cost := int64(n) * b.interval
+    limit := b.burst * b.interval
+    for {
+        now := b.now()
+        tat := atomic.LoadInt64(&b.tat)
+        base := tat
+        if base < now {
+            base = now
+        }
+        newTat := base + cost
+        if newTat-now > limit {
+            return false
+        }
+        if atomic.CompareAndSwapInt64(&b.tat, tat, newTat) {
+            return true
+        }
+    }
+}
+
+// Reserve 预约1个令牌,令牌不足时预约未来补充的令牌
+func (b *TokenBucket) Reserve() *Reservation {
+    limit := b.burst * b.interval
+    for {
+        now := b.now()
+        tat := atomic.LoadInt64(&b.tat)
+        base := tat
+        if base < now {
+            base = now
+        }
+        newTat := base + b.interval
+        if atomic.CompareAndSwapInt64(&b.tat, tat, newTat) {
+            wait := newTat - now - limit
+            if wait < 0 {
+                wait = 0
+            }
+            at := now + wait
+            return &Reservation{
+                ok:  true,
+                at:  at,
+                now: b.now,
+                cancel: func() {
+                    // 许可尚未到期时归还
+                    if b.now() < at {
+                        atomic.AddInt64(&b.tat, -b.interval)
+                    }
+                },
+            }
+        }
+    }
+}
+
+// Wait 阻塞直到获取1个令牌
+func (b *TokenBucket) Wait(ctx context.Context) error {
+    if b.Allow() {
+        return nil
+    }
+    return waitReservation(ctx, b.Reserve())
+}
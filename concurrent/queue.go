@@ -1,9 +1,14 @@
 package concurrent
 
 import (
+    "context"
+    "errors"
     "time"
 )
 
+// ErrQueueClosed 队列已关闭(且已排空)时返回此错误
+var ErrQueueClosed = errors.New("queue is closed")
+
 // Queue 队列接口,MPMC安全(算法参考Dmitry Vyukov)
 //
 // 三种实现:
@@ -42,6 +47,32 @@ type TryDequeuer[T any] interface {
     TryDequeue() (T, bool)
 }
 
+// BatchQueue 可选接口:批量入队出队,一次原子操作占用一段连续slot,分段队列和环形队列实现了该接口
+type BatchQueue[T any] interface {
+    // EnqueueBatch 批量入队,环形队列满时阻塞直到全部入队
+    EnqueueBatch(vs []T)
+    // DequeueBatch 批量出队到dst,最多取出min(max,len(dst))个,返回实际数量
+    DequeueBatch(dst []T, max int) int
+}
+
+// ClosableQueue 可关闭的阻塞队列,支持 context 取消的阻塞出队
+//
+// 关闭后不再接受新元素, Close 保留残留元素供消费者排空, CloseNow 直接取出残留元素,
+// 队列关闭且排空后所有等待中的消费者被唤醒并返回 ErrQueueClosed
+type ClosableQueue[T any] interface {
+    BlockQueue[T]
+    // Offer 入队,队列已关闭时返回 ErrQueueClosed; Enqueue 在队列关闭后会丢弃元素
+    Offer(v T) error
+    // DequeueCtx 阻塞出队,ctx取消时返回ctx.Err(),队列关闭且排空时返回 ErrQueueClosed
+    DequeueCtx(ctx context.Context) (T, error)
+    // Close 关闭队列,残留元素仍可出队,排空后等待者返回 ErrQueueClosed
+    Close()
+    // CloseNow 关闭队列并取出全部残留元素,等待者立即返回 ErrQueueClosed
+    CloseNow() []T
+    // Closed 返回队列是否已关闭
+    Closed() bool
+}
+
 type opt[T any] struct {
     Type func() Queue[T]
     opt  []func(Queue[T]) Queue[T]
@@ -0,0 +1,130 @@
+package concurrent
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func batchQueues() []struct {
+	name string
+	q    func() Queue[int]
+} {
+	return []struct {
+		name string
+		q    func() Queue[int]
+	}{
+		{"seg", func() Queue[int] { return newSegQueue[int]() }},
+		{"ring", func() Queue[int] { return newRingQueue[int](256) }},
+	}
+}
+
+func Test_QueueBatch_Order(t *testing.T) {
+	for _, c := range batchQueues() {
+		t.Run(c.name, func(t *testing.T) {
+			q := c.q()
+			bq := q.(BatchQueue[int])
+			// 跨越多个segment / 环形队列多圈
+			n := segSize*2 + 7
+			src := make([]int, 100)
+			dst := make([]int, 64)
+			next, want := 0, 0
+			for want < n {
+				k := 0
+				for ; k < len(src) && next < n && q.Size() < 200; k++ {
+					src[k] = next
+					next++
+				}
+				bq.EnqueueBatch(src[:k])
+				got := bq.DequeueBatch(dst, 50)
+				if got > 50 {
+					t.Fatalf("got=%d > max", got)
+				}
+				for i := 0; i < got; i++ {
+					if dst[i] != want {
+						t.Fatalf("dst[%d]=%d want=%d", i, dst[i], want)
+					}
+					want++
+				}
+			}
+			if q.Size() != 0 {
+				t.Fatalf("size=%d", q.Size())
+			}
+			if got := bq.DequeueBatch(dst, len(dst)); got != 0 {
+				t.Fatalf("空队列 DequeueBatch=%d", got)
+			}
+			// 与单个出入队混用
+			bq.EnqueueBatch([]int{1, 2, 3})
+			q.Enqueue(4)
+			if v, ok := q.Dequeue(); !ok || v != 1 {
+				t.Fatalf("v=%d ok=%v", v, ok)
+			}
+			if got := bq.DequeueBatch(dst, 10); got != 3 || dst[0] != 2 || dst[2] != 4 {
+				t.Fatalf("got=%d dst=%v", got, dst[:got])
+			}
+		})
+	}
+}
+
+func Test_QueueBatch_Concurrent(t *testing.T) {
+	t.Parallel()
+	for _, c := range batchQueues() {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			q := c.q()
+			bq := q.(BatchQueue[int])
+			const producers, consumers, rounds, batch = 4, 4, 500, 16
+			target := int64(producers * rounds * batch)
+			var sum, consumed int64
+			var pwg, cwg sync.WaitGroup
+			pwg.Add(producers)
+			for i := 0; i < producers; i++ {
+				go func() {
+					defer pwg.Done()
+					vs := make([]int, batch)
+					for r := 0; r < rounds; r++ {
+						for j := range vs {
+							vs[j] = j + 1
+						}
+						bq.EnqueueBatch(vs)
+					}
+				}()
+			}
+			cwg.Add(consumers)
+			for i := 0; i < consumers; i++ {
+				go func(i int) {
+					defer cwg.Done()
+					dst := make([]int, 32)
+					for atomic.LoadInt64(&consumed) < target {
+						var n int
+						if i%2 == 0 {
+							n = bq.DequeueBatch(dst, len(dst))
+						} else if v, ok := q.Dequeue(); ok {
+							dst[0], n = v, 1
+						}
+						if n == 0 {
+							runtime.Gosched()
+							continue
+						}
+						s := int64(0)
+						for _, v := range dst[:n] {
+							s += int64(v)
+						}
+						atomic.AddInt64(&sum, s)
+						atomic.AddInt64(&consumed, int64(n))
+					}
+				}(i)
+			}
+			pwg.Wait()
+			cwg.Wait()
+			if consumed != target {
+				t.Fatalf("consumed=%d want=%d", consumed, target)
+			}
+			if want := int64(producers * rounds * batch * (batch + 1) / 2); sum != want {
+				t.Fatalf("sum=%d want=%d", sum, want)
+			}
+		})
+	}
+}
@@ -139,3 +139,44 @@ func BenchmarkQueueBlockNotify(b *testing.B) {
 	b.StopTimer()
 	wg.Wait()
 }
+
+// BenchmarkQueueBatch 批量与逐个出入队吞吐量对比, 每次操作移动batch个元素, ns/op按元素折算
+func BenchmarkQueueBatch(b *testing.B) {
+	const batch = 64
+	for _, tc := range []qBuilder{
+		{"seg", func() Queue[int] { return newSegQueue[int]() }},
+		{"ring", func() Queue[int] { return newRingQueue[int](4096) }},
+	} {
+		b.Run(tc.name+"/single", func(b *testing.B) {
+			q := tc.newQ()
+			start := time.Now()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					for i := 0; i < batch; i++ {
+						q.Enqueue(i)
+					}
+					for i := 0; i < batch; i++ {
+						q.Dequeue()
+					}
+				}
+			})
+			b.ReportMetric(float64(time.Since(start).Nanoseconds())/float64(b.N*batch), "ns/item")
+		})
+		b.Run(tc.name+"/batch", func(b *testing.B) {
+			q := tc.newQ()
+			bq := q.(BatchQueue[int])
+			start := time.Now()
+			b.RunParallel(func(pb *testing.PB) {
+				vs := make([]int, batch)
+				dst := make([]int, batch)
+				for pb.Next() {
+					bq.EnqueueBatch(vs)
+					for n := 0; n < batch; {
+						n += bq.DequeueBatch(dst, batch-n)
+					}
+				}
+			})
+			b.ReportMetric(float64(time.Since(start).Nanoseconds())/float64(b.N*batch), "ns/item")
+		})
+	}
+}
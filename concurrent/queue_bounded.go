@@ -0,0 +1,278 @@
+package concurrent
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BoundedQueue 有界阻塞队列,队列满时生产者阻塞或立即失败,提供生产者背压
+//
+// 示例:
+//
+//	q := NewBoundedQueue[int](1024)
+//	if !q.TryEnqueue(1) {
+//	    // 队列已满
+//	}
+//	ok := q.EnqueueBlock(2, time.Second) // 最多等待1秒
+//	v, ok := q.DequeueBlock()
+//	q.Close() // 唤醒阻塞中的生产者, 消费者排空后返回 ErrQueueClosed
+type BoundedQueue[T any] interface {
+	ClosableQueue[T]
+	TryDequeuer[T]
+	// TryEnqueue 非阻塞入队,队列满或已关闭立即返回false
+	TryEnqueue(v T) bool
+	// EnqueueBlock 阻塞入队直到有空位,支持超时参数,超时或队列关闭返回false
+	EnqueueBlock(v T, timeout ...time.Duration) bool
+	// ProducerWaiterCount 返回当前在 EnqueueBlock 中阻塞等待的生产者数量
+	ProducerWaiterCount() int32
+	// Cap 返回队列容量
+	Cap() int
+}
+
+// WithTypeBounded 使用有界阻塞队列,Enqueue在队列满时阻塞
+// cap会被向上取整到最近的2的幂
+func WithTypeBounded[T any](cap int) Opt[T] {
+	return func(opt *opt[T]) {
+		opt.Type = func() Queue[T] {
+			return NewBoundedQueue[T](cap)
+		}
+	}
+}
+
+// NewBoundedQueue 创建有界阻塞队列,底层为环形队列,cap会被向上取整到最近的2的幂
+func NewBoundedQueue[T any](cap int) BoundedQueue[T] {
+	q := &boundedQueue[T]{
+		ring: newRingQueue[T](cap).(*ringQueue[T]),
+	}
+	q.notEmpty = sync.NewCond(&q.mu)
+	q.notFull = sync.NewCond(&q.mu)
+	return q
+}
+
+type boundedQueue[T any] struct {
+	ring      *ringQueue[T]
+	_         [cpuCacheKillerPaddingLength]byte
+	consumer  int32
+	producer  int32
+	state     int32
+	enqueuing int32
+	mu        sync.Mutex
+	notEmpty  *sync.Cond
+	notFull   *sync.Cond
+}
+
+func (q *boundedQueue[T]) Cap() int {
+	return len(q.ring.slots)
+}
+
+func (q *boundedQueue[T]) Size() int {
+	return q.ring.Size()
+}
+
+func (q *boundedQueue[T]) WaiterCount() int32 {
+	return atomic.LoadInt32(&q.consumer)
+}
+
+func (q *boundedQueue[T]) ProducerWaiterCount() int32 {
+	return atomic.LoadInt32(&q.producer)
+}
+
+func (q *boundedQueue[T]) Closed() bool {
+	return atomic.LoadInt32(&q.state) != queueOpen
+}
+
+// Enqueue 阻塞直到入队成功, 队列关闭后丢弃元素
+func (q *boundedQueue[T]) Enqueue(v T) {
+	_ = q.Offer(v)
+}
+
+// Offer 阻塞直到入队成功, 队列关闭返回 ErrQueueClosed
+func (q *boundedQueue[T]) Offer(v T) error {
+	return q.enqueueWait(v, time.Time{})
+}
+
+func (q *boundedQueue[T]) TryEnqueue(v T) bool {
+	if !q.tryEnqueue(v) {
+		return false
+	}
+	q.wake(&q.consumer, q.notEmpty)
+	return true
+}
+
+// tryEnqueue 先登记再检查状态, Close 会等待登记归零, 保证成功入队的元素对关闭后的消费者可见
+func (q *boundedQueue[T]) tryEnqueue(v T) bool {
+	atomic.AddInt32(&q.enqueuing, 1)
+	ok := atomic.LoadInt32(&q.state) == queueOpen && q.ring.TryEnqueue(v)
+	atomic.AddInt32(&q.enqueuing, -1)
+	return ok
+}
+
+func (q *boundedQueue[T]) EnqueueBlock(v T, timeout ...time.Duration) bool {
+	var deadline time.Time
+	if len(timeout) > 0 {
+		deadline = time.Now().Add(timeout[0])
+	}
+	return q.enqueueWait(v, deadline) == nil
+}
+
+func (q *boundedQueue[T]) enqueueWait(v T, deadline time.Time) error {
+	// 快速路径: 无锁spin
+	for i := 0; i < 4; i++ {
+		if q.TryEnqueue(v) {
+			return nil
+		}
+		if q.Closed() {
+			return ErrQueueClosed
+		}
+		runtime.Gosched()
+	}
+	err := q.wait(&q.producer, q.notFull, nil, deadline, func() bool {
+		return q.tryEnqueue(v)
+	}, q.Closed)
+	if err == nil {
+		q.wake(&q.consumer, q.notEmpty)
+	}
+	return err
+}
+
+func (q *boundedQueue[T]) Dequeue() (T, bool) {
+	v, ok := q.ring.Dequeue()
+	if ok {
+		q.wake(&q.producer, q.notFull)
+	}
+	return v, ok
+}
+
+func (q *boundedQueue[T]) TryDequeue() (T, bool) {
+	v, ok := q.ring.TryDequeue()
+	if ok {
+		q.wake(&q.producer, q.notFull)
+	}
+	return v, ok
+}
+
+func (q *boundedQueue[T]) DequeueBlock(timeout ...time.Duration) (T, bool) {
+	var deadline time.Time
+	if len(timeout) > 0 {
+		deadline = time.Now().Add(timeout[0])
+	}
+	v, err := q.dequeueWait(nil, deadline)
+	return v, err == nil
+}
+
+func (q *boundedQueue[T]) DequeueCtx(ctx context.Context) (T, error) {
+	return q.dequeueWait(ctx, time.Time{})
+}
+
+func (q *boundedQueue[T]) dequeueWait(ctx context.Context, deadline time.Time) (T, error) {
+	for i := 0; i < 4; i++ {
+		if v, ok := q.TryDequeue(); ok {
+			return v, nil
+		}
+		runtime.Gosched()
+	}
+	var v T
+	err := q.wait(&q.consumer, q.notEmpty, ctx, deadline, func() bool {
+		var b bool
+		v, b = q.ring.Dequeue()
+		return b
+	}, func() bool {
+		return atomic.LoadInt32(&q.state) == queueClosed && q.ring.Size() == 0
+	})
+	if err == nil {
+		q.wake(&q.producer, q.notFull)
+	}
+	return v, err
+}
+
+// Close 关闭队列: 唤醒阻塞中的生产者并返回失败, 消费者排空残留元素后返回 ErrQueueClosed
+func (q *boundedQueue[T]) Close() {
+	if !atomic.CompareAndSwapInt32(&q.state, queueOpen, queueClosing) {
+		return
+	}
+	q.mu.Lock()
+	q.notFull.Broadcast()
+	q.mu.Unlock()
+	for atomic.LoadInt32(&q.enqueuing) != 0 {
+		runtime.Gosched()
+	}
+	atomic.StoreInt32(&q.state, queueClosed)
+	q.mu.Lock()
+	q.notEmpty.Broadcast()
+	q.mu.Unlock()
+}
+
+func (q *boundedQueue[T]) CloseNow() []T {
+	q.Close()
+	var r []T
+	for {
+		v, ok := q.ring.Dequeue()
+		if !ok {
+			return r
+		}
+		r = append(r, v)
+	}
+}
+
+// wake 对端存在等待者时唤醒一个
+// 等待者在锁内先增加计数再重试,因此此处读到0时对端的重试必然能看到本次修改
+func (q *boundedQueue[T]) wake(waiter *int32, cond *sync.Cond) {
+	if atomic.LoadInt32(waiter) == 0 {
+		return
+	}
+	q.mu.Lock()
+	cond.Signal()
+	q.mu.Unlock()
+}
+
+// wait 在锁内循环执行try直到成功, closed返回true时返回 ErrQueueClosed
+// ctx为nil表示不可取消, deadline为零值表示不超时
+func (q *boundedQueue[T]) wait(waiter *int32, cond *sync.Cond, ctx context.Context, deadline time.Time, try func() bool, closed func() bool) error {
+	q.mu.Lock()
+	atomic.AddInt32(waiter, 1)
+	stop := wakeOnDone(ctx, &q.mu, cond)
+	var timer *time.Timer
+	defer func() {
+		stop()
+		if timer != nil {
+			timer.Stop()
+		}
+		atomic.AddInt32(waiter, -1)
+		q.mu.Unlock()
+	}()
+	for {
+		if try() {
+			return nil
+		}
+		if closed() {
+			return ErrQueueClosed
+		}
+		if ctx != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !deadline.IsZero() {
+			now := time.Now()
+			if !now.Before(deadline) {
+				return errDequeueTimeout
+			}
+			if timer == nil {
+				timer = time.AfterFunc(deadline.Sub(now), func() {
+					q.mu.Lock()
+					cond.Broadcast()
+					q.mu.Unlock()
+				})
+			}
+		}
+		if atomic.LoadInt32(&q.state) == queueClosed {
+			// 已关闭但仍有竞争中的残留元素, 不再等待对端通知
+			q.mu.Unlock()
+			runtime.Gosched()
+			q.mu.Lock()
+			continue
+		}
+		cond.Wait()
+	}
+}
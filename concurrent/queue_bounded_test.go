@@ -0,0 +1,101 @@
+package concurrent
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_BoundedQueue_TryEnqueue(t *testing.T) {
+	q := NewBoundedQueue[int](3)
+	if q.Cap() != 4 {
+		t.Fatalf("cap=%d want=4", q.Cap())
+	}
+	for i := 0; i < 4; i++ {
+		if !q.TryEnqueue(i) {
+			t.Fatalf("TryEnqueue %d 失败", i)
+		}
+	}
+	if q.TryEnqueue(4) {
+		t.Fatal("队列已满,TryEnqueue应返回false")
+	}
+	if q.EnqueueBlock(4, 10*time.Millisecond) {
+		t.Fatal("队列已满,EnqueueBlock应超时")
+	}
+	for i := 0; i < 4; i++ {
+		v, ok := q.Dequeue()
+		if !ok || v != i {
+			t.Fatalf("i=%d v=%d ok=%v", i, v, ok)
+		}
+	}
+	if _, ok := q.TryDequeue(); ok {
+		t.Fatal("should be empty")
+	}
+}
+
+func Test_BoundedQueue_ProducerBlock(t *testing.T) {
+	t.Parallel()
+	q := NewBoundedQueue[int](2)
+	q.Enqueue(1)
+	q.Enqueue(1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		q.Enqueue(2)
+	}()
+	waitForCondition(t, time.Second, func() bool { return q.ProducerWaiterCount() == 1 }, "生产者应阻塞等待")
+	v, ok := q.DequeueBlock(time.Second)
+	if !ok || v != 1 {
+		t.Fatalf("v=%d ok=%v", v, ok)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("出队后生产者应被唤醒")
+	}
+	q.Dequeue()
+	v, ok = q.DequeueBlock(time.Second)
+	if !ok || v != 2 {
+		t.Fatalf("v=%d ok=%v", v, ok)
+	}
+}
+
+func Test_BoundedQueue_Concurrent(t *testing.T) {
+	t.Parallel()
+	q := BlockQueueWrapper(NewQueue(WithTypeBounded[int](8)))
+	const producers, consumers, n = 4, 4, 5000
+	var sum, consumed int64
+	var pwg, cwg sync.WaitGroup
+	pwg.Add(producers)
+	for i := 0; i < producers; i++ {
+		go func() {
+			defer pwg.Done()
+			for j := 1; j <= n; j++ {
+				q.Enqueue(j)
+			}
+		}()
+	}
+	cwg.Add(consumers)
+	for i := 0; i < consumers; i++ {
+		go func() {
+			defer cwg.Done()
+			for {
+				v, ok := q.DequeueBlock(100 * time.Millisecond)
+				if !ok {
+					return
+				}
+				atomic.AddInt64(&sum, int64(v))
+				atomic.AddInt64(&consumed, 1)
+			}
+		}()
+	}
+	pwg.Wait()
+	cwg.Wait()
+	if consumed != producers*n {
+		t.Fatalf("consumed=%d want=%d", consumed, producers*n)
+	}
+	if want := int64(producers * n * (n + 1) / 2); sum != want {
+		t.Fatalf("sum=%d want=%d", sum, want)
+	}
+}
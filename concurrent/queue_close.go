@@ -0,0 +1,234 @@
+package concurrent
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 队列关闭状态: open -> closing(拒绝新元素,等待进行中的入队完成) -> closed(消费者可感知)
+const (
+	queueOpen int32 = iota
+	queueClosing
+	queueClosed
+)
+
+// errDequeueTimeout 内部使用,DequeueBlock 超时
+var errDequeueTimeout = errors.New("dequeue timeout")
+
+// WithClosable 使用 ClosableQueueWrapper 包装队列,可断言为 ClosableQueue[T]
+func WithClosable[T any]() Opt[T] {
+	return func(opt *opt[T]) {
+		opt.opt = append(opt.opt, func(q Queue[T]) Queue[T] {
+			return ClosableQueueWrapper(q)
+		})
+	}
+}
+
+// ClosableQueueWrapper 包装为可关闭队列,适用于分段、环形、channel和延时队列
+// 已实现 ClosableQueue 的队列(有界队列、到期队列)直接返回
+//
+// 注意: channel队列已满时, Close 会等待阻塞中的生产者完成入队
+func ClosableQueueWrapper[T any](queue Queue[T]) ClosableQueue[T] {
+	if q, ok := queue.(ClosableQueue[T]); ok {
+		return q
+	}
+	q := &closableQueue[T]{Queue: queue}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+type closableQueue[T any] struct {
+	Queue[T]
+	_         [cpuCacheKillerPaddingLength]byte
+	state     int32
+	enqueuing int32
+	waiter    int32
+	mu        sync.Mutex
+	cond      *sync.Cond
+}
+
+func (q *closableQueue[T]) Enqueue(v T) {
+	_ = q.Offer(v)
+}
+
+func (q *closableQueue[T]) Offer(v T) error {
+	// 先登记再检查状态, Close 会等待登记归零, 保证返回nil的元素一定在关闭前入队
+	atomic.AddInt32(&q.enqueuing, 1)
+	if atomic.LoadInt32(&q.state) != queueOpen {
+		atomic.AddInt32(&q.enqueuing, -1)
+		return ErrQueueClosed
+	}
+	q.Queue.Enqueue(v)
+	atomic.AddInt32(&q.enqueuing, -1)
+	if atomic.LoadInt32(&q.waiter) == 0 {
+		return nil
+	}
+	q.mu.Lock()
+	q.cond.Signal()
+	q.mu.Unlock()
+	return nil
+}
+
+func (q *closableQueue[T]) TryDequeue() (T, bool) {
+	if td, ok := q.Queue.(TryDequeuer[T]); ok {
+		return td.TryDequeue()
+	}
+	return q.Queue.Dequeue()
+}
+
+func (q *closableQueue[T]) WaiterCount() int32 {
+	return atomic.LoadInt32(&q.waiter)
+}
+
+func (q *closableQueue[T]) Closed() bool {
+	return atomic.LoadInt32(&q.state) != queueOpen
+}
+
+func (q *closableQueue[T]) Close() {
+	if !atomic.CompareAndSwapInt32(&q.state, queueOpen, queueClosing) {
+		return
+	}
+	for atomic.LoadInt32(&q.enqueuing) != 0 {
+		runtime.Gosched()
+	}
+	atomic.StoreInt32(&q.state, queueClosed)
+	q.mu.Lock()
+	q.cond.Broadcast()
+	q.mu.Unlock()
+}
+
+func (q *closableQueue[T]) CloseNow() []T {
+	q.Close()
+	var r []T
+	for {
+		v, ok := q.Queue.Dequeue()
+		if !ok {
+			return r
+		}
+		r = append(r, v)
+	}
+}
+
+func (q *closableQueue[T]) DequeueBlock(timeout ...time.Duration) (T, bool) {
+	var deadline time.Time
+	if len(timeout) > 0 {
+		deadline = time.Now().Add(timeout[0])
+	}
+	v, err := q.dequeueWait(nil, deadline)
+	return v, err == nil
+}
+
+func (q *closableQueue[T]) DequeueCtx(ctx context.Context) (T, error) {
+	return q.dequeueWait(ctx, time.Time{})
+}
+
+// drained 队列已关闭且排空, 延时队列未到期的元素也计入Size
+func (q *closableQueue[T]) drained() bool {
+	return atomic.LoadInt32(&q.state) == queueClosed && q.Queue.Size() == 0
+}
+
+func (q *closableQueue[T]) dequeueWait(ctx context.Context, deadline time.Time) (T, error) {
+	var zero T
+	td, ok := q.Queue.(TryDequeuer[T])
+	if !ok {
+		// 延时队列等元素随时间可出队, 无法依赖入队通知, 退化为轮询
+		return q.dequeuePoll(ctx, deadline)
+	}
+	// 快速路径: 无锁spin
+	for i := 0; i < 4; i++ {
+		if v, b := td.TryDequeue(); b {
+			return v, nil
+		}
+		runtime.Gosched()
+	}
+	q.mu.Lock()
+	atomic.AddInt32(&q.waiter, 1)
+	stop := wakeOnDone(ctx, &q.mu, q.cond)
+	var timer *time.Timer
+	defer func() {
+		stop()
+		if timer != nil {
+			timer.Stop()
+		}
+		atomic.AddInt32(&q.waiter, -1)
+		q.mu.Unlock()
+	}()
+	for {
+		if v, b := td.TryDequeue(); b {
+			return v, nil
+		}
+		// TryDequeue 可能因CAS竞争失败, 关闭后以Size确认真正排空
+		if q.drained() {
+			return zero, ErrQueueClosed
+		}
+		if ctx != nil && ctx.Err() != nil {
+			return zero, ctx.Err()
+		}
+		if !deadline.IsZero() {
+			now := time.Now()
+			if !now.Before(deadline) {
+				return zero, errDequeueTimeout
+			}
+			if timer == nil {
+				timer = time.AfterFunc(deadline.Sub(now), func() {
+					q.mu.Lock()
+					q.cond.Broadcast()
+					q.mu.Unlock()
+				})
+			}
+		}
+		if atomic.LoadInt32(&q.state) == queueClosed {
+			// 已关闭但仍有竞争中的残留元素, 不再等待入队通知
+			q.mu.Unlock()
+			runtime.Gosched()
+			q.mu.Lock()
+			continue
+		}
+		q.cond.Wait()
+	}
+}
+
+func (q *closableQueue[T]) dequeuePoll(ctx context.Context, deadline time.Time) (T, error) {
+	var zero T
+	for i := 0; ; i++ {
+		if v, b := q.Queue.Dequeue(); b {
+			return v, nil
+		}
+		if q.drained() {
+			return zero, ErrQueueClosed
+		}
+		if ctx != nil && ctx.Err() != nil {
+			return zero, ctx.Err()
+		}
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			return zero, errDequeueTimeout
+		}
+		if i < 64 {
+			runtime.Gosched()
+		} else {
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+// wakeOnDone ctx取消时在锁内广播唤醒cond上的等待者, 调用方结束等待后需调用返回的stop
+func wakeOnDone(ctx context.Context, l sync.Locker, cond *sync.Cond) (stop func()) {
+	if ctx == nil || ctx.Done() == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			l.Lock()
+			cond.Broadcast()
+			l.Unlock()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
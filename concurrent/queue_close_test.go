@@ -0,0 +1,198 @@
+package concurrent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func closableQueues() []struct {
+	name string
+	q    func() ClosableQueue[int]
+} {
+	return []struct {
+		name string
+		q    func() ClosableQueue[int]
+	}{
+		{"seg", func() ClosableQueue[int] { return NewQueue(WithTypeSegment[int](), WithClosable[int]()).(ClosableQueue[int]) }},
+		{"ring", func() ClosableQueue[int] { return ClosableQueueWrapper(NewQueue(WithTypeRing[int](64))) }},
+		{"chan", func() ClosableQueue[int] { return ClosableQueueWrapper(NewQueue(WithTypeChan[int](64))) }},
+		{"delay", func() ClosableQueue[int] { return ClosableQueueWrapper(NewQueue(WithTypeDelay[int](time.Millisecond))) }},
+		{"bounded", func() ClosableQueue[int] { return NewBoundedQueue[int](64) }},
+		{"deadline", func() ClosableQueue[int] { return NewDeadlineQueue[int]() }},
+	}
+}
+
+func Test_ClosableQueue_CloseDrain(t *testing.T) {
+	t.Parallel()
+	for _, c := range closableQueues() {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			q := c.q()
+			for i := 0; i < 3; i++ {
+				if err := q.Offer(i); err != nil {
+					t.Fatal(err)
+				}
+			}
+			q.Close()
+			if !q.Closed() {
+				t.Fatal("Closed() should be true")
+			}
+			if err := q.Offer(3); !errors.Is(err, ErrQueueClosed) {
+				t.Fatalf("关闭后 Offer 应返回 ErrQueueClosed, got %v", err)
+			}
+			for i := 0; i < 3; i++ {
+				v, err := q.DequeueCtx(context.Background())
+				if err != nil || v != i {
+					t.Fatalf("i=%d v=%d err=%v", i, v, err)
+				}
+			}
+			if _, err := q.DequeueCtx(context.Background()); !errors.Is(err, ErrQueueClosed) {
+				t.Fatalf("排空后应返回 ErrQueueClosed, got %v", err)
+			}
+			if _, ok := q.DequeueBlock(); ok {
+				t.Fatal("排空后 DequeueBlock 应返回false")
+			}
+		})
+	}
+}
+
+func Test_ClosableQueue_CloseWakesWaiters(t *testing.T) {
+	t.Parallel()
+	for _, c := range closableQueues() {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			q := c.q()
+			const waiters = 4
+			errs := make(chan error, waiters)
+			for i := 0; i < waiters; i++ {
+				go func() {
+					_, err := q.DequeueCtx(context.Background())
+					errs <- err
+				}()
+			}
+			time.Sleep(20 * time.Millisecond)
+			q.Close()
+			for i := 0; i < waiters; i++ {
+				select {
+				case err := <-errs:
+					if !errors.Is(err, ErrQueueClosed) {
+						t.Fatalf("got %v want ErrQueueClosed", err)
+					}
+				case <-time.After(time.Second):
+					t.Fatal("Close 后等待者未被唤醒")
+				}
+			}
+		})
+	}
+}
+
+func Test_ClosableQueue_DequeueCtxCancel(t *testing.T) {
+	t.Parallel()
+	for _, c := range closableQueues() {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			q := c.q()
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			_, err := q.DequeueCtx(ctx)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("got %v want DeadlineExceeded", err)
+			}
+			// 取消不影响队列本身
+			if err = q.Offer(1); err != nil {
+				t.Fatal(err)
+			}
+			v, err := q.DequeueCtx(context.Background())
+			if err != nil || v != 1 {
+				t.Fatalf("v=%d err=%v", v, err)
+			}
+		})
+	}
+}
+
+func Test_ClosableQueue_CloseNow(t *testing.T) {
+	t.Parallel()
+	for _, c := range closableQueues() {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			q := c.q()
+			for i := 0; i < 5; i++ {
+				q.Enqueue(i)
+			}
+			// 延时队列需等待元素到期后才能取出
+			time.Sleep(10 * time.Millisecond)
+			rest := q.CloseNow()
+			if len(rest) != 5 {
+				t.Fatalf("rest=%v", rest)
+			}
+			if _, err := q.DequeueCtx(context.Background()); !errors.Is(err, ErrQueueClosed) {
+				t.Fatalf("got %v want ErrQueueClosed", err)
+			}
+		})
+	}
+}
+
+func Test_ClosableQueue_ConcurrentClose(t *testing.T) {
+	t.Parallel()
+	for _, c := range closableQueues() {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			q := c.q()
+			const producers, consumers = 4, 4
+			var accepted, consumed int64
+			var mu sync.Mutex
+			var pwg, cwg sync.WaitGroup
+			stop := make(chan struct{})
+			pwg.Add(producers)
+			for i := 0; i < producers; i++ {
+				go func() {
+					defer pwg.Done()
+					for {
+						select {
+						case <-stop:
+							return
+						default:
+						}
+						if q.Offer(1) != nil {
+							return
+						}
+						mu.Lock()
+						accepted++
+						mu.Unlock()
+					}
+				}()
+			}
+			cwg.Add(consumers)
+			for i := 0; i < consumers; i++ {
+				go func() {
+					defer cwg.Done()
+					for {
+						if _, err := q.DequeueCtx(context.Background()); err != nil {
+							return
+						}
+						mu.Lock()
+						consumed++
+						mu.Unlock()
+					}
+				}()
+			}
+			time.Sleep(20 * time.Millisecond)
+			q.Close()
+			close(stop)
+			pwg.Wait()
+			cwg.Wait()
+			// 所有成功 Offer 的元素都必须被消费
+			if accepted != consumed {
+				t.Fatalf("accepted=%d consumed=%d", accepted, consumed)
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package concurrent
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DeadlineQueue 按元素到期时间出队的延时队列,每个元素可携带独立的到期时间
+//
+// 与 WithTypeDelay 的固定延时不同,元素按到期时间(相同时按入队顺序)排序,
+// 短延时的元素不会被排在前面的长延时元素阻塞
+//
+// 示例:
+//
+//	q := NewDeadlineQueue[string]()
+//	h := q.EnqueueAfter("retry", 3*time.Second)
+//	h.Cancel() // 到期前取消
+//	v, ok := q.DequeueBlock(time.Second)
+type DeadlineQueue[T any] interface {
+	ClosableQueue[T]
+	TryDequeuer[T]
+	// EnqueueAt 入队,到达at后才可出队; 队列关闭后元素被丢弃,返回的句柄 Cancel 恒为false
+	EnqueueAt(v T, at time.Time) *DeadlineHandle
+	// EnqueueAfter 入队,d之后才可出队
+	EnqueueAfter(v T, d time.Duration) *DeadlineHandle
+}
+
+// DeadlineHandle 延时元素句柄,用于在到期出队前取消
+type DeadlineHandle struct {
+	at     time.Time
+	cancel func() bool
+}
+
+// Deadline 返回元素的到期时间
+func (h *DeadlineHandle) Deadline() time.Time {
+	return h.at
+}
+
+// Cancel 取消元素,返回true表示元素尚未出队且已从队列移除
+func (h *DeadlineHandle) Cancel() bool {
+	return h.cancel()
+}
+
+// WithTypeDeadline 使用按到期时间排序的延时队列,配合 EnqueueAt/EnqueueAfter 为每个元素指定到期时间
+// 通过 NewQueue 创建时可断言为 DeadlineQueue[T],直接 Enqueue 的元素立即可出队
+func WithTypeDeadline[T any]() Opt[T] {
+	return func(opt *opt[T]) {
+		opt.Type = func() Queue[T] {
+			return NewDeadlineQueue[T]()
+		}
+	}
+}
+
+// NewDeadlineQueue 创建按到期时间出队的延时队列
+func NewDeadlineQueue[T any]() DeadlineQueue[T] {
+	q := &deadlineQueue[T]{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+type deadlineItem[T any] struct {
+	at    time.Time
+	seq   uint64
+	value T
+	// 在堆中的下标,-1表示已出队或已取消
+	index int
+}
+
+// deadlineQueue 最小堆实现,堆顶为最早到期的元素
+type deadlineQueue[T any] struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	heap   []*deadlineItem[T]
+	seq    uint64
+	waiter int32
+	closed bool
+}
+
+func (q *deadlineQueue[T]) Enqueue(v T) {
+	q.EnqueueAt(v, time.Now())
+}
+
+func (q *deadlineQueue[T]) EnqueueAfter(v T, d time.Duration) *DeadlineHandle {
+	return q.EnqueueAt(v, time.Now().Add(d))
+}
+
+func (q *deadlineQueue[T]) EnqueueAt(v T, at time.Time) *DeadlineHandle {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return &DeadlineHandle{at: at, cancel: func() bool { return false }}
+	}
+	item := q.push(v, at)
+	q.mu.Unlock()
+	return &DeadlineHandle{at: at, cancel: func() bool { return q.remove(item) }}
+}
+
+// Offer 立即可出队的元素入队,队列关闭返回 ErrQueueClosed
+func (q *deadlineQueue[T]) Offer(v T) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+	q.push(v, time.Now())
+	return nil
+}
+
+// push 调用方需持有锁
+func (q *deadlineQueue[T]) push(v T, at time.Time) *deadlineItem[T] {
+	q.seq++
+	item := &deadlineItem[T]{at: at, seq: q.seq, value: v, index: len(q.heap)}
+	q.heap = append(q.heap, item)
+	q.up(item.index)
+	// 新元素成为堆顶时,等待者持有的定时器可能晚于新的到期时间,需要唤醒重新计算
+	if item.index == 0 && atomic.LoadInt32(&q.waiter) > 0 {
+		q.cond.Broadcast()
+	}
+	return item
+}
+
+func (q *deadlineQueue[T]) Dequeue() (T, bool) {
+	return q.TryDequeue()
+}
+
+// TryDequeue 堆顶元素已到期则出队,否则立即返回false
+func (q *deadlineQueue[T]) TryDequeue() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.heap) == 0 || q.heap[0].at.After(time.Now()) {
+		var zero T
+		return zero, false
+	}
+	return q.pop(), true
+}
+
+func (q *deadlineQueue[T]) Size() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.heap)
+}
+
+func (q *deadlineQueue[T]) WaiterCount() int32 {
+	return atomic.LoadInt32(&q.waiter)
+}
+
+func (q *deadlineQueue[T]) Closed() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.closed
+}
+
+// Close 关闭队列,未到期的元素仍会在到期后出队,全部出队后等待者返回 ErrQueueClosed
+func (q *deadlineQueue[T]) Close() {
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+}
+
+// CloseNow 关闭队列并按到期顺序取出全部元素,无论是否到期
+func (q *deadlineQueue[T]) CloseNow() []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	r := make([]T, 0, len(q.heap))
+	for len(q.heap) > 0 {
+		r = append(r, q.pop())
+	}
+	q.cond.Broadcast()
+	return r
+}
+
+// DequeueBlock 阻塞直到堆顶元素到期,支持超时参数
+func (q *deadlineQueue[T]) DequeueBlock(timeout ...time.Duration) (T, bool) {
+	var deadline time.Time
+	if len(timeout) > 0 {
+		deadline = time.Now().Add(timeout[0])
+	}
+	v, err := q.dequeueWait(nil, deadline)
+	return v, err == nil
+}
+
+func (q *deadlineQueue[T]) DequeueCtx(ctx context.Context) (T, error) {
+	return q.dequeueWait(ctx, time.Time{})
+}
+
+func (q *deadlineQueue[T]) dequeueWait(ctx context.Context, deadline time.Time) (T, error) {
+	var zero T
+	q.mu.Lock()
+	atomic.AddInt32(&q.waiter, 1)
+	stop := wakeOnDone(ctx, &q.mu, q.cond)
+	var timer *time.Timer
+	defer func() {
+		stop()
+		if timer != nil {
+			timer.Stop()
+		}
+		atomic.AddInt32(&q.waiter, -1)
+		q.mu.Unlock()
+	}()
+	for {
+		now := time.Now()
+		var wait time.Duration = -1
+		if len(q.heap) > 0 {
+			wait = q.heap[0].at.Sub(now)
+			if wait <= 0 {
+				return q.pop(), nil
+			}
+		} else if q.closed {
+			return zero, ErrQueueClosed
+		}
+		if ctx != nil && ctx.Err() != nil {
+			return zero, ctx.Err()
+		}
+		if !deadline.IsZero() {
+			remaining := deadline.Sub(now)
+			if remaining <= 0 {
+				return zero, errDequeueTimeout
+			}
+			if wait < 0 || remaining < wait {
+				wait = remaining
+			}
+		}
+		if wait >= 0 {
+			if timer == nil {
+				timer = time.AfterFunc(wait, q.broadcast)
+			} else {
+				timer.Reset(wait)
+			}
+		}
+		q.cond.Wait()
+	}
+}
+
+func (q *deadlineQueue[T]) broadcast() {
+	q.mu.Lock()
+	q.cond.Broadcast()
+	q.mu.Unlock()
+}
+
+func (q *deadlineQueue[T]) remove(item *deadlineItem[T]) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := item.index
+	if i < 0 {
+		return false
+	}
+	last := len(q.heap) - 1
+	if i != last {
+		q.swap(i, last)
+	}
+	q.heap[last] = nil
+	q.heap = q.heap[:last]
+	item.index = -1
+	if i != last {
+		if !q.down(i) {
+			q.up(i)
+		}
+	}
+	return true
+}
+
+// pop 弹出堆顶,调用方需持有锁且保证堆非空
+func (q *deadlineQueue[T]) pop() T {
+	item := q.heap[0]
+	last := len(q.heap) - 1
+	q.swap(0, last)
+	q.heap[last] = nil
+	q.heap = q.heap[:last]
+	q.down(0)
+	item.index = -1
+	v := item.value
+	var zero T
+	item.value = zero
+	return v
+}
+
+func (q *deadlineQueue[T]) less(i, j int) bool {
+	a, b := q.heap[i], q.heap[j]
+	if a.at.Equal(b.at) {
+		return a.seq < b.seq
+	}
+	return a.at.Before(b.at)
+}
+
+func (q *deadlineQueue[T]) swap(i, j int) {
+	q.heap[i], q.heap[j] = q.heap[j], q.heap[i]
+	q.heap[i].index = i
+	q.heap[j].index = j
+}
+
+func (q *deadlineQueue[T]) up(i int) {
+	for i > 0 {
+		p := (i - 1) / 2
+		if !q.less(i, p) {
+			return
+		}
+		q.swap(i, p)
+		i = p
+	}
+}
+
+// down 下沉,返回是否发生了移动
+func (q *deadlineQueue[T]) down(i int) bool {
+	start := i
+	n := len(q.heap)
+	for {
+		l := 2*i + 1
+		if l >= n {
+			break
+		}
+		m := l
+		if r := l + 1; r < n && q.less(r, l) {
+			m = r
+		}
+		if !q.less(m, i) {
+			break
+		}
+		q.swap(i, m)
+		i = m
+	}
+	return i > start
+}
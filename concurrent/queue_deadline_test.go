@@ -0,0 +1,136 @@
+package concurrent
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_DeadlineQueue_Order(t *testing.T) {
+	t.Parallel()
+	q := NewDeadlineQueue[int]()
+	q.EnqueueAfter(3, 60*time.Millisecond)
+	q.EnqueueAfter(1, 20*time.Millisecond)
+	q.EnqueueAfter(2, 40*time.Millisecond)
+	if _, ok := q.TryDequeue(); ok {
+		t.Fatal("未到期元素不应出队")
+	}
+	for want := 1; want <= 3; want++ {
+		v, ok := q.DequeueBlock(time.Second)
+		if !ok || v != want {
+			t.Fatalf("v=%d ok=%v want=%d", v, ok, want)
+		}
+	}
+	if q.Size() != 0 {
+		t.Fatalf("size=%d want=0", q.Size())
+	}
+}
+
+func Test_DeadlineQueue_SameDeadlineFIFO(t *testing.T) {
+	q := NewDeadlineQueue[int]()
+	at := time.Now()
+	for i := 0; i < 100; i++ {
+		q.EnqueueAt(i, at)
+	}
+	for i := 0; i < 100; i++ {
+		v, ok := q.Dequeue()
+		if !ok || v != i {
+			t.Fatalf("i=%d v=%d ok=%v", i, v, ok)
+		}
+	}
+}
+
+func Test_DeadlineQueue_ShortNotBlockedByLong(t *testing.T) {
+	t.Parallel()
+	q := NewDeadlineQueue[int]()
+	q.EnqueueAfter(1, time.Hour)
+	start := time.Now()
+	// 等待者已在长延时元素上休眠,新的短延时元素应唤醒它
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.EnqueueAfter(2, 20*time.Millisecond)
+	}()
+	v, ok := q.DequeueBlock(time.Second)
+	if !ok || v != 2 {
+		t.Fatalf("v=%d ok=%v", v, ok)
+	}
+	if cost := time.Since(start); cost > 500*time.Millisecond {
+		t.Fatalf("短延时元素被阻塞: %v", cost)
+	}
+}
+
+func Test_DeadlineQueue_Cancel(t *testing.T) {
+	q := NewDeadlineQueue[int]()
+	h1 := q.EnqueueAfter(1, 0)
+	h2 := q.EnqueueAfter(2, 0)
+	q.EnqueueAfter(3, 0)
+	if !h2.Cancel() {
+		t.Fatal("首次取消应成功")
+	}
+	if h2.Cancel() {
+		t.Fatal("重复取消应失败")
+	}
+	v, ok := q.Dequeue()
+	if !ok || v != 1 {
+		t.Fatalf("v=%d ok=%v", v, ok)
+	}
+	if h1.Cancel() {
+		t.Fatal("已出队元素不应取消成功")
+	}
+	v, ok = q.Dequeue()
+	if !ok || v != 3 {
+		t.Fatalf("v=%d ok=%v", v, ok)
+	}
+	if _, ok = q.Dequeue(); ok {
+		t.Fatal("should be empty")
+	}
+}
+
+func Test_DeadlineQueue_Timeout(t *testing.T) {
+	t.Parallel()
+	q := BlockQueueWrapper(NewQueue(WithTypeDeadline[int]()))
+	q.(DeadlineQueue[int]).EnqueueAfter(1, time.Hour)
+	start := time.Now()
+	if _, ok := q.DequeueBlock(30 * time.Millisecond); ok {
+		t.Fatal("应超时")
+	}
+	if cost := time.Since(start); cost < 30*time.Millisecond {
+		t.Fatalf("超时过早: %v", cost)
+	}
+}
+
+func Test_DeadlineQueue_Concurrent(t *testing.T) {
+	t.Parallel()
+	q := NewDeadlineQueue[int]()
+	const producers, n = 4, 500
+	var wg sync.WaitGroup
+	wg.Add(producers)
+	for i := 0; i < producers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < n; j++ {
+				q.EnqueueAfter(j, time.Duration(j%10)*time.Millisecond)
+			}
+		}()
+	}
+	results := make(chan int, producers*n)
+	var cwg sync.WaitGroup
+	cwg.Add(producers)
+	for i := 0; i < producers; i++ {
+		go func() {
+			defer cwg.Done()
+			for {
+				v, ok := q.DequeueBlock(200 * time.Millisecond)
+				if !ok {
+					return
+				}
+				results <- v
+			}
+		}()
+	}
+	wg.Wait()
+	cwg.Wait()
+	if len(results) != producers*n {
+		t.Fatalf("consumed=%d want=%d", len(results), producers*n)
+	}
+}
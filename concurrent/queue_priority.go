@@ -0,0 +1,109 @@
+package concurrent
+
+import (
+	"sync"
+)
+
+// WithTypePriority 使用优先级队列,less(a,b)为true时a先出队,优先级相同的元素按入队顺序出队
+// 内部为互斥锁保护的二叉堆,可配合 BlockQueueWrapper 阻塞出队
+func WithTypePriority[T any](less func(a, b T) bool) Opt[T] {
+	return func(opt *opt[T]) {
+		opt.Type = func() Queue[T] {
+			return newPriorityQueue[T](less)
+		}
+	}
+}
+
+func newPriorityQueue[T any](less func(a, b T) bool) Queue[T] {
+	if less == nil {
+		panic("less不能为nil")
+	}
+	return &priorityQueue[T]{less: less}
+}
+
+type priorityEntry[T any] struct {
+	value T
+	seq   uint64
+}
+
+type priorityQueue[T any] struct {
+	mu   sync.Mutex
+	less func(a, b T) bool
+	heap []priorityEntry[T]
+	seq  uint64
+}
+
+func (q *priorityQueue[T]) Enqueue(v T) {
+	q.mu.Lock()
+	q.seq++
+	q.heap = append(q.heap, priorityEntry[T]{value: v, seq: q.seq})
+	q.up(len(q.heap) - 1)
+	q.mu.Unlock()
+}
+
+func (q *priorityQueue[T]) Dequeue() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.heap) == 0 {
+		var zero T
+		return zero, false
+	}
+	v := q.heap[0].value
+	last := len(q.heap) - 1
+	q.heap[0] = q.heap[last]
+	q.heap[last] = priorityEntry[T]{}
+	q.heap = q.heap[:last]
+	q.down(0)
+	return v, true
+}
+
+func (q *priorityQueue[T]) TryDequeue() (T, bool) {
+	return q.Dequeue()
+}
+
+func (q *priorityQueue[T]) Size() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.heap)
+}
+
+func (q *priorityQueue[T]) before(i, j int) bool {
+	a, b := q.heap[i], q.heap[j]
+	if q.less(a.value, b.value) {
+		return true
+	}
+	if q.less(b.value, a.value) {
+		return false
+	}
+	return a.seq < b.seq
+}
+
+func (q *priorityQueue[T]) up(i int) {
+	for i > 0 {
+		p := (i - 1) / 2
+		if !q.before(i, p) {
+			return
+		}
+		q.heap[i], q.heap[p] = q.heap[p], q.heap[i]
+		i = p
+	}
+}
+
+func (q *priorityQueue[T]) down(i int) {
+	n := len(q.heap)
+	for {
+		l := 2*i + 1
+		if l >= n {
+			return
+		}
+		m := l
+		if r := l + 1; r < n && q.before(r, l) {
+			m = r
+		}
+		if !q.before(m, i) {
+			return
+		}
+		q.heap[i], q.heap[m] = q.heap[m], q.heap[i]
+		i = m
+	}
+}
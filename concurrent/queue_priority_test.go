@@ -0,0 +1,84 @@
+package concurrent
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_PriorityQueue_Order(t *testing.T) {
+	q := NewQueue(WithTypePriority(func(a, b int) bool { return a < b }))
+	for _, v := range rand.Perm(1000) {
+		q.Enqueue(v)
+	}
+	if q.Size() != 1000 {
+		t.Fatalf("size=%d", q.Size())
+	}
+	for i := 0; i < 1000; i++ {
+		v, ok := q.Dequeue()
+		if !ok || v != i {
+			t.Fatalf("i=%d v=%d ok=%v", i, v, ok)
+		}
+	}
+	if _, ok := q.(TryDequeuer[int]).TryDequeue(); ok {
+		t.Fatal("should be empty")
+	}
+}
+
+func Test_PriorityQueue_StableForEqualPriority(t *testing.T) {
+	type job struct {
+		priority int
+		id       int
+	}
+	q := NewQueue(WithTypePriority(func(a, b job) bool { return a.priority > b.priority }))
+	for i := 0; i < 10; i++ {
+		q.Enqueue(job{priority: i % 2, id: i})
+	}
+	// 高优先级(1)先出, 同优先级按入队顺序
+	want := []int{1, 3, 5, 7, 9, 0, 2, 4, 6, 8}
+	for _, id := range want {
+		v, ok := q.Dequeue()
+		if !ok || v.id != id {
+			t.Fatalf("v=%+v ok=%v want id=%d", v, ok, id)
+		}
+	}
+}
+
+func Test_PriorityQueue_Block(t *testing.T) {
+	t.Parallel()
+	bq := BlockQueueWrapper(NewQueue(WithTypePriority(func(a, b int) bool { return a < b })))
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		bq.Enqueue(1)
+	}()
+	v, ok := bq.DequeueBlock(time.Second)
+	if !ok || v != 1 {
+		t.Fatalf("v=%d ok=%v", v, ok)
+	}
+}
+
+func Test_PriorityQueue_Concurrent(t *testing.T) {
+	t.Parallel()
+	q := NewQueue(WithTypePriority(func(a, b int) bool { return a < b }))
+	const producers, n = 4, 1000
+	var wg sync.WaitGroup
+	wg.Add(producers)
+	for i := 0; i < producers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < n; j++ {
+				q.Enqueue(j)
+			}
+		}()
+	}
+	wg.Wait()
+	last := -1
+	for i := 0; i < producers*n; i++ {
+		v, ok := q.Dequeue()
+		if !ok || v < last {
+			t.Fatalf("i=%d v=%d last=%d ok=%v", i, v, last, ok)
+		}
+		last = v
+	}
+}
@@ -32,9 +32,9 @@ func WithTypeRing[T any](cap int) Opt[T] {
 }
 
 func newRingQueue[T any](cap int) Queue[T] {
-	// 容量必须是2的幂
-	if cap < 1 {
-		cap = 1
+	// 容量必须是2的幂,且至少为2: 容量为1时slot序号t+1与下一个tail相同,满队列无法识别
+	if cap < 2 {
+		cap = 2
 	}
 	cap = nextPow2(cap)
 	slots := make([]ringSlot[T], cap)
@@ -81,6 +81,98 @@ func (q *ringQueue[T]) Enqueue(v T) {
 	}
 }
 
+// TryEnqueue 队列满时立即返回false,CAS竞争失败会重试
+func (q *ringQueue[T]) TryEnqueue(v T) bool {
+	for {
+		t := atomic.LoadUint64(&q.tail)
+		s := &q.slots[t&q.mask]
+		seq := atomic.LoadUint64(&s.seq)
+		diff := int64(seq) - int64(t)
+		if diff == 0 {
+			if atomic.CompareAndSwapUint64(&q.tail, t, t+1) {
+				s.value = v
+				atomic.StoreUint64(&s.seq, t+1)
+				return true
+			}
+		} else if diff < 0 {
+			return false
+		}
+	}
+}
+
+// EnqueueBatch 每轮统计从tail起连续空闲的slot,一次CAS占用,队列满时让出CPU等待
+func (q *ringQueue[T]) EnqueueBatch(vs []T) {
+	for len(vs) > 0 {
+		t := atomic.LoadUint64(&q.tail)
+		n := uint64(0)
+		for n < uint64(len(vs)) && n <= q.mask {
+			p := t + n
+			if atomic.LoadUint64(&q.slots[p&q.mask].seq) != p {
+				break
+			}
+			n++
+		}
+		if n == 0 {
+			if int64(atomic.LoadUint64(&q.slots[t&q.mask].seq))-int64(t) < 0 {
+				// 队列满,让出CPU
+				runtime.Gosched()
+			}
+			continue
+		}
+		if !atomic.CompareAndSwapUint64(&q.tail, t, t+n) {
+			continue
+		}
+		for i := uint64(0); i < n; i++ {
+			s := &q.slots[(t+i)&q.mask]
+			s.value = vs[i]
+			atomic.StoreUint64(&s.seq, t+i+1)
+		}
+		vs = vs[n:]
+	}
+}
+
+// DequeueBatch 统计从head起连续就绪的slot,一次CAS占用后依次取出
+func (q *ringQueue[T]) DequeueBatch(dst []T, max int) int {
+	if max > len(dst) {
+		max = len(dst)
+	}
+	if max <= 0 {
+		return 0
+	}
+	var zero T
+	for spin := 0; spin < 64; spin++ {
+		h := atomic.LoadUint64(&q.head)
+		n := uint64(0)
+		for n < uint64(max) && n <= q.mask {
+			p := h + n
+			if atomic.LoadUint64(&q.slots[p&q.mask].seq) != p+1 {
+				break
+			}
+			n++
+		}
+		if n == 0 {
+			if int64(atomic.LoadUint64(&q.slots[h&q.mask].seq))-int64(h+1) < 0 {
+				return 0
+			}
+			continue
+		}
+		if !atomic.CompareAndSwapUint64(&q.head, h, h+n) {
+			if spin > 0 && spin%8 == 0 {
+				runtime.Gosched()
+			}
+			continue
+		}
+		for i := uint64(0); i < n; i++ {
+			s := &q.slots[(h+i)&q.mask]
+			dst[i] = s.value
+			s.value = zero
+			atomic.StoreUint64(&s.seq, h+i+q.mask+1)
+		}
+		return int(n)
+	}
+	return 0
+}
+
 func (q *ringQueue[T]) Dequeue() (T, bool) {
 	for spin := 0; spin < 64; spin++ {
 		h := atomic.LoadUint64(&q.head)
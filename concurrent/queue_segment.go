@@ -71,6 +71,95 @@ func (q *segQueue[T]) Enqueue(v T) {
 	}
 }
 
+// EnqueueBatch 一次原子操作占用连续的len(vs)个位置,范围可跨越多个segment
+func (q *segQueue[T]) EnqueueBatch(vs []T) {
+	n := uint64(len(vs))
+	if n == 0 {
+		return
+	}
+	pos := atomic.AddUint64(&q.tailPos, n) - n
+	seg := (*segment[T])(atomic.LoadPointer(&q.tailSeg))
+	for i := uint64(0); i < n; i++ {
+		p := pos + i
+		if seg.id != p>>segBits {
+			seg = q.ensureWriteSegment(p >> segBits)
+		}
+		s := &seg.slots[p&segMask]
+		for atomic.LoadUint64(&s.seq) != p {
+			runtime.Gosched()
+		}
+		s.value = vs[i]
+		atomic.StoreUint64(&s.seq, p+1)
+	}
+}
+
+// DequeueBatch 统计从head起连续就绪的slot,一次CAS占用后依次取出
+func (q *segQueue[T]) DequeueBatch(dst []T, max int) int {
+	if max > len(dst) {
+		max = len(dst)
+	}
+	if max <= 0 {
+		return 0
+	}
+	var zero T
+	for spin := 0; spin < 64; spin++ {
+		h := atomic.LoadUint64(&q.headPos)
+		seg := (*segment[T])(atomic.LoadPointer(&q.headSeg))
+		if seg.id != h>>segBits {
+			var ok bool
+			seg, ok = q.findReadSegment(h >> segBits)
+			if !ok {
+				return 0
+			}
+		}
+		n := uint64(0)
+		for cur := seg; n < uint64(max); n++ {
+			p := h + n
+			if cur.id != p>>segBits {
+				next := (*segment[T])(atomic.LoadPointer(&cur.next))
+				if next == nil {
+					break
+				}
+				cur = next
+			}
+			if atomic.LoadUint64(&cur.slots[p&segMask].seq) != p+1 {
+				break
+			}
+		}
+		if n == 0 {
+			if spin > 16 && h >= atomic.LoadUint64(&q.tailPos) {
+				return 0
+			}
+			if spin > 0 && spin%8 == 0 {
+				runtime.Gosched()
+			}
+			continue
+		}
+		if !atomic.CompareAndSwapUint64(&q.headPos, h, h+n) {
+			continue
+		}
+		crossed := false
+		for i, cur := uint64(0), seg; i < n; i++ {
+			p := h + i
+			if cur.id != p>>segBits {
+				cur = (*segment[T])(atomic.LoadPointer(&cur.next))
+			}
+			s := &cur.slots[p&segMask]
+			dst[i] = s.value
+			s.value = zero
+			atomic.StoreUint64(&s.seq, p+segSize)
+			if (p & segMask) == segMask {
+				crossed = true
+			}
+		}
+		if crossed {
+			q.advanceHead()
+		}
+		return int(n)
+	}
+	return 0
+}
+
 func (q *segQueue[T]) Dequeue() (T, bool) {
 	for spin := 0; spin < 64; spin++ {
 		h := atomic.LoadUint64(&q.headPos)
@@ -1,7 +1,10 @@
 package concurrent
 
 import (
+    "context"
+    "errors"
     "sync"
+    "time"
 )
 
 type Locker interface {
@@ -9,6 +12,18 @@ type Locker interface {
     TryLock() bool
 }
 
+// TimedLocker 支持超时与取消的锁
+type TimedLocker interface {
+    Locker
+    // LockTimeout 在d内获取锁,超时返回false
+    LockTimeout(d time.Duration) bool
+    // LockCtx 获取锁,ctx取消时返回ctx.Err()
+    LockCtx(ctx context.Context) error
+}
+
+// errLockTimeout 加锁超时
+var errLockTimeout = errors.New("lock timeout")
+
 type ReentrantLock struct {
     cond      sync.Cond
     recursion int32
@@ -17,19 +32,35 @@ type ReentrantLock struct {
 }
 
 func (r *ReentrantLock) Lock() {
+    _ = r.lock(nil, time.Time{})
+}
+
+// LockTimeout 在d内获取锁,超时返回false
+func (r *ReentrantLock) LockTimeout(d time.Duration) bool {
+    return r.lock(nil, time.Now().Add(d)) == nil
+}
+
+// LockCtx 获取锁,ctx取消时返回ctx.Err()
+func (r *ReentrantLock) LockCtx(ctx context.Context) error {
+    return r.lock(ctx, time.Time{})
+}
+
+func (r *ReentrantLock) lock(ctx context.Context, deadline time.Time) error {
     r.cond.L.Lock()
     defer r.cond.L.Unlock()
     goId := GoID()
     if r.goId == goId {
         r.recursion++
-        return
+        return nil
     }
-    for r.recursion != 0 {
-        r.cond.Wait()
+    if err := lockWait(&r.cond, ctx, deadline, func() bool { return r.recursion == 0 }); err != nil {
+        return err
     }
     r.goId = goId
     r.recursion = 1
+    return nil
 }
+
 func (r *ReentrantLock) TryLock() bool {
     r.cond.L.Lock()
     defer r.cond.L.Unlock()
@@ -60,6 +91,52 @@ func (r *ReentrantLock) Unlock() {
     }
 }
 
+// Owner 返回持有锁的goroutine id,未被持有时返回-1,用于排查死锁
+func (r *ReentrantLock) Owner() int64 {
+    r.cond.L.Lock()
+    defer r.cond.L.Unlock()
+    return r.goId
+}
+
+// HoldCount 返回持有者的重入次数,未被持有时返回0
+func (r *ReentrantLock) HoldCount() int {
+    r.cond.L.Lock()
+    defer r.cond.L.Unlock()
+    return int(r.recursion)
+}
+
 func NewReentrantLock() Locker {
     return &ReentrantLock{cond: *sync.NewCond(&sync.Mutex{}), goId: -1}
 }
+
+// lockWait 调用方需持有cond.L,等待直到ready返回true
+// ctx为nil表示不可取消,deadline为零值表示不超时; 放弃等待时将唤醒转交给其他等待者,避免Signal丢失
+func lockWait(cond *sync.Cond, ctx context.Context, deadline time.Time, ready func() bool) error {
+    if ready() {
+        return nil
+    }
+    stop := wakeOnDone(ctx, cond.L, cond)
+    defer stop()
+    if !deadline.IsZero() {
+        timer := time.AfterFunc(time.Until(deadline), func() {
+            cond.L.Lock()
+            cond.Broadcast()
+            cond.L.Unlock()
+        })
+        defer timer.Stop()
+    }
+    for !ready() {
+        var err error
+        if ctx != nil && ctx.Err() != nil {
+            err = ctx.Err()
+        } else if !deadline.IsZero() && !time.Now().Before(deadline) {
+            err = errLockTimeout
+        }
+        if err != nil {
+            cond.Broadcast()
+            return err
+        }
+        cond.Wait()
+    }
+    return nil
+}
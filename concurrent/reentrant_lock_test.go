@@ -1,6 +1,8 @@
 package concurrent
 
 import (
+    "context"
+    "errors"
     "testing"
     "time"
 )
@@ -67,3 +69,63 @@ func Test_ReentrantLock_TryLock(t *testing.T) {
         t.Fatal("TryLock should return false")
     }
 }
+
+func Test_ReentrantLock_LockTimeout(t *testing.T) {
+    lock := NewReentrantLock().(*ReentrantLock)
+    locked := make(chan struct{})
+    release := make(chan struct{})
+    go func() {
+        lock.Lock()
+        close(locked)
+        <-release
+        lock.Unlock()
+    }()
+    <-locked
+    if lock.LockTimeout(20 * time.Millisecond) {
+        t.Fatal("锁被其他goroutine持有,应超时")
+    }
+    if lock.Owner() == -1 || lock.HoldCount() != 1 {
+        t.Fatalf("owner=%d hold=%d", lock.Owner(), lock.HoldCount())
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+    defer cancel()
+    if err := lock.LockCtx(ctx); !errors.Is(err, context.DeadlineExceeded) {
+        t.Fatalf("err=%v", err)
+    }
+    close(release)
+    if !lock.LockTimeout(time.Second) {
+        t.Fatal("释放后应获取成功")
+    }
+    if err := lock.LockCtx(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    if lock.Owner() != GoID() || lock.HoldCount() != 2 {
+        t.Fatalf("owner=%d hold=%d", lock.Owner(), lock.HoldCount())
+    }
+    lock.Unlock()
+    lock.Unlock()
+    if lock.Owner() != -1 || lock.HoldCount() != 0 {
+        t.Fatalf("owner=%d hold=%d", lock.Owner(), lock.HoldCount())
+    }
+}
+
+func Test_ReentrantLock_TimeoutKeepsWakeup(t *testing.T) {
+    t.Parallel()
+    lock := NewReentrantLock().(*ReentrantLock)
+    lock.Lock()
+    done := make(chan struct{})
+    go func() {
+        lock.Lock()
+        lock.Unlock()
+        close(done)
+    }()
+    // 超时放弃的等待者不能吞掉解锁时的唤醒
+    go lock.LockTimeout(10 * time.Millisecond)
+    time.Sleep(20 * time.Millisecond)
+    lock.Unlock()
+    select {
+    case <-done:
+    case <-time.After(time.Second):
+        t.Fatal("等待者未被唤醒")
+    }
+}
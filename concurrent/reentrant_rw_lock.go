@@ -0,0 +1,204 @@
+package concurrent
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "time"
+)
+
+// ErrLockUpgrade 持有读锁的goroutine尝试获取写锁,该操作必然死锁
+var ErrLockUpgrade = errors.New("read lock cannot be upgraded to write lock")
+
+// ReentrantRWLock 基于goroutine id的可重入读写锁
+//
+//   - 同一goroutine可重复获取读锁或写锁
+//   - 持有写锁时可获取读锁(降级),持有读锁时获取写锁(升级)会死锁,Lock/LockCtx 检测到时分别panic/返回 ErrLockUpgrade
+//   - 写锁优先: 有写者等待时,新的读者(未持有读锁的goroutine)需要等待,避免写者饥饿
+type ReentrantRWLock struct {
+    mu   sync.Mutex
+    cond *sync.Cond
+    // 写锁持有者,-1表示无
+    writer     int64
+    writeCount int32
+    // 等待写锁的goroutine数量
+    writeWaiting int32
+    // 各goroutine持有的读锁重入次数
+    readers map[int64]int32
+}
+
+// NewReentrantRWLock 创建可重入读写锁
+func NewReentrantRWLock() *ReentrantRWLock {
+    l := &ReentrantRWLock{writer: -1, readers: map[int64]int32{}}
+    l.cond = sync.NewCond(&l.mu)
+    return l
+}
+
+func (l *ReentrantRWLock) Lock() {
+    if err := l.lock(nil, time.Time{}); err != nil {
+        panic(err)
+    }
+}
+
+// LockTimeout 在d内获取写锁,超时或持有读锁时返回false
+func (l *ReentrantRWLock) LockTimeout(d time.Duration) bool {
+    return l.lock(nil, time.Now().Add(d)) == nil
+}
+
+// LockCtx 获取写锁,ctx取消时返回ctx.Err(),持有读锁时返回 ErrLockUpgrade
+func (l *ReentrantRWLock) LockCtx(ctx context.Context) error {
+    return l.lock(ctx, time.Time{})
+}
+
+func (l *ReentrantRWLock) lock(ctx context.Context, deadline time.Time) error {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    goId := GoID()
+    if l.writer == goId {
+        l.writeCount++
+        return nil
+    }
+    if l.readers[goId] > 0 {
+        return ErrLockUpgrade
+    }
+    l.writeWaiting++
+    err := lockWait(l.cond, ctx, deadline, l.writable)
+    l.writeWaiting--
+    if err != nil {
+        if l.writeWaiting == 0 {
+            // 被本写者阻塞的读者可以继续
+            l.cond.Broadcast()
+        }
+        return err
+    }
+    l.writer = goId
+    l.writeCount = 1
+    return nil
+}
+
+func (l *ReentrantRWLock) writable() bool {
+    return l.writer == -1 && len(l.readers) == 0
+}
+
+// TryLock 非阻塞获取写锁,持有读锁时恒为false
+func (l *ReentrantRWLock) TryLock() bool {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    goId := GoID()
+    if l.writer == goId {
+        l.writeCount++
+        return true
+    }
+    if !l.writable() {
+        return false
+    }
+    l.writer = goId
+    l.writeCount = 1
+    return true
+}
+
+func (l *ReentrantRWLock) Unlock() {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    if l.writeCount == 0 || l.writer != GoID() {
+        panic("unlock of unlocked lock")
+    }
+    l.writeCount--
+    if l.writeCount == 0 {
+        l.writer = -1
+        l.cond.Broadcast()
+    }
+}
+
+func (l *ReentrantRWLock) RLock() {
+    _ = l.rlock(nil, time.Time{})
+}
+
+// RLockTimeout 在d内获取读锁,超时返回false
+func (l *ReentrantRWLock) RLockTimeout(d time.Duration) bool {
+    return l.rlock(nil, time.Now().Add(d)) == nil
+}
+
+// RLockCtx 获取读锁,ctx取消时返回ctx.Err()
+func (l *ReentrantRWLock) RLockCtx(ctx context.Context) error {
+    return l.rlock(ctx, time.Time{})
+}
+
+func (l *ReentrantRWLock) rlock(ctx context.Context, deadline time.Time) error {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    goId := GoID()
+    if l.writer != goId && l.readers[goId] == 0 {
+        if err := lockWait(l.cond, ctx, deadline, l.readable); err != nil {
+            return err
+        }
+    }
+    l.readers[goId]++
+    return nil
+}
+
+func (l *ReentrantRWLock) readable() bool {
+    return l.writer == -1 && l.writeWaiting == 0
+}
+
+// TryRLock 非阻塞获取读锁
+func (l *ReentrantRWLock) TryRLock() bool {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    goId := GoID()
+    if l.writer != goId && l.readers[goId] == 0 && !l.readable() {
+        return false
+    }
+    l.readers[goId]++
+    return true
+}
+
+func (l *ReentrantRWLock) RUnlock() {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    goId := GoID()
+    n := l.readers[goId]
+    if n == 0 {
+        panic("unlock of unlocked lock")
+    }
+    if n > 1 {
+        l.readers[goId] = n - 1
+        return
+    }
+    delete(l.readers, goId)
+    if len(l.readers) == 0 {
+        l.cond.Broadcast()
+    }
+}
+
+// Owner 返回持有写锁的goroutine id,未被持有时返回-1,用于排查死锁
+func (l *ReentrantRWLock) Owner() int64 {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    return l.writer
+}
+
+// HoldCount 返回写锁持有者的重入次数
+func (l *ReentrantRWLock) HoldCount() int {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    return int(l.writeCount)
+}
+
+// ReadHoldCount 返回当前goroutine持有读锁的重入次数
+func (l *ReentrantRWLock) ReadHoldCount() int {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    return int(l.readers[GoID()])
+}
+
+// Readers 返回持有读锁的goroutine id及其重入次数
+func (l *ReentrantRWLock) Readers() map[int64]int {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    r := make(map[int64]int, len(l.readers))
+    for id, n := range l.readers {
+        r[id] = int(n)
+    }
+    return r
+}
@@ -0,0 +1,166 @@
+package concurrent
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "testing"
+    "time"
+)
+
+var (
+    _ RwLocker    = (*ReentrantRWLock)(nil)
+    _ TimedLocker = (*ReentrantRWLock)(nil)
+    _ TimedLocker = (*ReentrantLock)(nil)
+)
+
+func Test_ReentrantRWLock_Reentrant(t *testing.T) {
+    l := NewReentrantRWLock()
+    l.Lock()
+    l.Lock()
+    // 写锁降级
+    l.RLock()
+    if l.HoldCount() != 2 || l.ReadHoldCount() != 1 || l.Owner() != GoID() {
+        t.Fatalf("hold=%d read=%d owner=%d", l.HoldCount(), l.ReadHoldCount(), l.Owner())
+    }
+    l.Unlock()
+    l.Unlock()
+    l.RLock()
+    if l.ReadHoldCount() != 2 || l.Owner() != -1 {
+        t.Fatalf("read=%d owner=%d", l.ReadHoldCount(), l.Owner())
+    }
+    if r := l.Readers(); len(r) != 1 || r[GoID()] != 2 {
+        t.Fatalf("readers=%v", r)
+    }
+    l.RUnlock()
+    l.RUnlock()
+    if !l.TryLock() {
+        t.Fatal("释放后TryLock应成功")
+    }
+    l.Unlock()
+}
+
+func Test_ReentrantRWLock_UpgradeDetect(t *testing.T) {
+    l := NewReentrantRWLock()
+    l.RLock()
+    if err := l.LockCtx(context.Background()); !errors.Is(err, ErrLockUpgrade) {
+        t.Fatalf("err=%v", err)
+    }
+    if l.TryLock() || l.LockTimeout(time.Millisecond) {
+        t.Fatal("持有读锁时不能获取写锁")
+    }
+    func() {
+        defer func() {
+            if r := recover(); r != ErrLockUpgrade {
+                t.Fatalf("recover=%v", r)
+            }
+        }()
+        l.Lock()
+    }()
+    l.RUnlock()
+}
+
+func Test_ReentrantRWLock_UnlockWithoutLock(t *testing.T) {
+    l := NewReentrantRWLock()
+    for _, f := range []func(){l.Unlock, l.RUnlock} {
+        func() {
+            defer func() {
+                if recover() == nil {
+                    t.Fatal("应panic")
+                }
+            }()
+            f()
+        }()
+    }
+}
+
+func Test_ReentrantRWLock_WriterPreferred(t *testing.T) {
+    t.Parallel()
+    l := NewReentrantRWLock()
+    l.RLock()
+    writerDone := make(chan struct{})
+    go func() {
+        l.Lock()
+        l.Unlock()
+        close(writerDone)
+    }()
+    time.Sleep(20 * time.Millisecond)
+    // 写者等待中,其他goroutine的新读者需要等待
+    readerOk := make(chan bool)
+    go func() {
+        readerOk <- l.RLockTimeout(20 * time.Millisecond)
+    }()
+    if <-readerOk {
+        t.Fatal("写者等待时新读者应等待")
+    }
+    // 已持有读锁的goroutine可重入
+    if !l.TryRLock() {
+        t.Fatal("读锁重入应成功")
+    }
+    l.RUnlock()
+    l.RUnlock()
+    select {
+    case <-writerDone:
+    case <-time.After(time.Second):
+        t.Fatal("写者未获取到锁")
+    }
+}
+
+func Test_ReentrantRWLock_Timeout(t *testing.T) {
+    l := NewReentrantRWLock()
+    held := make(chan struct{})
+    release := make(chan struct{})
+    go func() {
+        l.Lock()
+        close(held)
+        <-release
+        l.Unlock()
+    }()
+    <-held
+    if l.LockTimeout(10*time.Millisecond) || l.RLockTimeout(10*time.Millisecond) {
+        t.Fatal("应超时")
+    }
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    if err := l.RLockCtx(ctx); !errors.Is(err, context.Canceled) {
+        t.Fatalf("err=%v", err)
+    }
+    close(release)
+    if err := l.RLockCtx(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+    l.RUnlock()
+}
+
+func Test_ReentrantRWLock_Concurrent(t *testing.T) {
+    t.Parallel()
+    l := NewReentrantRWLock()
+    var wg sync.WaitGroup
+    counter := 0
+    for i := 0; i < 8; i++ {
+        wg.Add(1)
+        i := i
+        go func() {
+            defer wg.Done()
+            for j := 0; j < 200; j++ {
+                if i%2 == 0 {
+                    l.Lock()
+                    l.Lock()
+                    counter++
+                    l.Unlock()
+                    l.Unlock()
+                } else {
+                    l.RLock()
+                    l.RLock()
+                    _ = counter
+                    l.RUnlock()
+                    l.RUnlock()
+                }
+            }
+        }()
+    }
+    wg.Wait()
+    if counter != 800 {
+        t.Fatalf("counter=%d", counter)
+    }
+}
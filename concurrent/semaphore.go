@@ -0,0 +1,139 @@
+package concurrent
+
+import (
+    "container/list"
+    "context"
+    "sync"
+)
+
+// Semaphore 带权重的计数信号量,等待者按FIFO顺序获取,避免大权重请求被小请求饿死
+//
+// 示例:
+//
+//	sem := NewSemaphore(10)
+//	if err := sem.Acquire(ctx, 3); err != nil {
+//	    return err
+//	}
+//	defer sem.Release(3)
+type Semaphore struct {
+    mu      sync.Mutex
+    size    int64
+    cur     int64
+    waiters list.List
+}
+
+type semaphoreWaiter struct {
+    n     int64
+    ready chan struct{}
+}
+
+// NewSemaphore 创建总权重为n的信号量
+func NewSemaphore(n int64) *Semaphore {
+    if n < 0 {
+        panic("信号量大小不能为负数")
+    }
+    return &Semaphore{size: n}
+}
+
+// Acquire 获取权重n,阻塞直到成功或ctx取消,取消时返回ctx.Err()且不占用任何权重
+// n大于信号量总权重时只能等待ctx取消或 Resize 扩容,期间按FIFO阻塞其后的等待者
+func (s *Semaphore) Acquire(ctx context.Context, n int64) error {
+    s.mu.Lock()
+    if s.size-s.cur >= n && s.waiters.Len() == 0 {
+        s.cur += n
+        s.mu.Unlock()
+        return nil
+    }
+    w := semaphoreWaiter{n: n, ready: make(chan struct{})}
+    elem := s.waiters.PushBack(w)
+    s.mu.Unlock()
+
+    select {
+    case <-w.ready:
+        return nil
+    case <-ctx.Done():
+        s.mu.Lock()
+        select {
+        case <-w.ready:
+            // 取消的同时已获取成功,归还后仍按取消处理
+            s.cur -= n
+        default:
+            s.waiters.Remove(elem)
+        }
+        // 队首等待者离开或归还了权重,后续等待者可能可以获取
+        s.notifyWaiters()
+        s.mu.Unlock()
+        return ctx.Err()
+    }
+}
+
+// TryAcquire 非阻塞获取权重n,有等待者时为保证公平直接返回false
+func (s *Semaphore) TryAcquire(n int64) bool {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.size-s.cur >= n && s.waiters.Len() == 0 {
+        s.cur += n
+        return true
+    }
+    return false
+}
+
+// Release 归还权重n
+func (s *Semaphore) Release(n int64) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.cur -= n
+    if s.cur < 0 {
+        panic("信号量归还的权重超过已获取的权重")
+    }
+    s.notifyWaiters()
+}
+
+// Resize 调整信号量总权重,缩小时已获取的权重不受影响,归还后才生效
+func (s *Semaphore) Resize(n int64) {
+    if n < 0 {
+        panic("信号量大小不能为负数")
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.size = n
+    s.notifyWaiters()
+}
+
+// notifyWaiters 按顺序唤醒可以获取权重的等待者,队首无法满足时停止以保证FIFO,调用方需持有锁
+func (s *Semaphore) notifyWaiters() {
+    for {
+        front := s.waiters.Front()
+        if front == nil {
+            return
+        }
+        w := front.Value.(semaphoreWaiter)
+        if s.size-s.cur < w.n {
+            return
+        }
+        s.cur += w.n
+        s.waiters.Remove(front)
+        close(w.ready)
+    }
+}
+
+// Size 信号量总权重
+func (s *Semaphore) Size() int64 {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.size
+}
+
+// InUse 当前已获取的权重
+func (s *Semaphore) InUse() int64 {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.cur
+}
+
+// Waiters 当前等待的数量
+func (s *Semaphore) Waiters() int {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.waiters.Len()
+}
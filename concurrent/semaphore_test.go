@@ -0,0 +1,109 @@
+package concurrent
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "testing"
+    "time"
+)
+
+func Test_Semaphore_Basic(t *testing.T) {
+    s := NewSemaphore(5)
+    if !s.TryAcquire(3) || s.TryAcquire(3) {
+        t.Fatal("TryAcquire结果错误")
+    }
+    if s.InUse() != 3 || s.Size() != 5 {
+        t.Fatalf("inUse=%d size=%d", s.InUse(), s.Size())
+    }
+    if err := s.Acquire(context.Background(), 2); err != nil {
+        t.Fatal(err)
+    }
+    s.Release(5)
+    if s.InUse() != 0 {
+        t.Fatalf("inUse=%d", s.InUse())
+    }
+    func() {
+        defer func() {
+            if recover() == nil {
+                t.Fatal("多余的Release应panic")
+            }
+        }()
+        s.Release(1)
+    }()
+}
+
+func Test_Semaphore_FIFO(t *testing.T) {
+    t.Parallel()
+    s := NewSemaphore(4)
+    s.TryAcquire(3)
+    order := make(chan int64, 2)
+    var wg sync.WaitGroup
+    acquire := func(n int64) {
+        defer wg.Done()
+        if err := s.Acquire(context.Background(), n); err != nil {
+            t.Error(err)
+            return
+        }
+        order <- n
+        s.Release(n)
+    }
+    // 大请求先排队,后来的小请求即使有空闲也不能插队
+    wg.Add(1)
+    go acquire(4)
+    for s.Waiters() != 1 {
+        time.Sleep(time.Millisecond)
+    }
+    wg.Add(1)
+    go acquire(1)
+    for s.Waiters() != 2 {
+        time.Sleep(time.Millisecond)
+    }
+    if s.TryAcquire(1) {
+        t.Fatal("有等待者时TryAcquire应失败")
+    }
+    s.Release(3)
+    wg.Wait()
+    if a, b := <-order, <-order; a != 4 || b != 1 {
+        t.Fatalf("order=%d,%d", a, b)
+    }
+}
+
+func Test_Semaphore_Cancel(t *testing.T) {
+    t.Parallel()
+    s := NewSemaphore(2)
+    s.TryAcquire(1)
+    ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+    defer cancel()
+    // 队首的大请求取消后,后面的小请求应被唤醒
+    done := make(chan error, 1)
+    go func() { done <- s.Acquire(context.Background(), 1) }()
+    if err := s.Acquire(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+        t.Fatalf("err=%v", err)
+    }
+    select {
+    case err := <-done:
+        if err != nil {
+            t.Fatal(err)
+        }
+    case <-time.After(time.Second):
+        t.Fatal("取消后后续等待者未被唤醒")
+    }
+    if s.InUse() != 2 || s.Waiters() != 0 {
+        t.Fatalf("inUse=%d waiters=%d", s.InUse(), s.Waiters())
+    }
+}
+
+func Test_Semaphore_Resize(t *testing.T) {
+    t.Parallel()
+    s := NewSemaphore(1)
+    done := make(chan error, 1)
+    go func() { done <- s.Acquire(context.Background(), 3) }()
+    for s.Waiters() != 1 {
+        time.Sleep(time.Millisecond)
+    }
+    s.Resize(3)
+    if err := <-done; err != nil || s.InUse() != 3 {
+        t.Fatalf("err=%v inUse=%d", err, s.InUse())
+    }
+}
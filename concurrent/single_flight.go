@@ -0,0 +1,143 @@
+package concurrent
+
+import (
+    "context"
+    "fmt"
+    "runtime/debug"
+    "sync"
+)
+
+// SingleFlight 合并相同key的并发调用,同一时间每个key只执行一次fn,其余调用方共享结果
+// 用于避免缓存失效时大量请求同时击穿到后端; 零值可直接使用
+//
+// 示例:
+//
+//	var sf SingleFlight[string, *User]
+//	user, err, shared := sf.Do(id, func() (*User, error) {
+//	    return db.LoadUser(id)
+//	})
+type SingleFlight[K comparable, V any] struct {
+    mu    sync.Mutex
+    calls map[K]*flightCall[V]
+}
+
+// FlightResult DoChan 返回的结果
+type FlightResult[V any] struct {
+    Val    V
+    Err    error
+    Shared bool
+}
+
+// PanicError 包装执行函数时发生的panic及其堆栈,用于将panic作为错误返回给调用方
+type PanicError struct {
+    Value any
+    Stack []byte
+}
+
+func (p *PanicError) Error() string {
+    return fmt.Sprintf("fn panic: %v\n\n%s", p.Value, p.Stack)
+}
+
+type flightCall[V any] struct {
+    done  chan struct{}
+    val   V
+    err   error
+    dups  int
+    chans []chan<- FlightResult[V]
+}
+
+// Do 执行fn并返回结果,相同key已有调用在执行时等待其完成并共享结果
+// shared表示结果是否被多个调用方共享; fn发生panic时所有共享的调用方均以 *PanicError panic
+func (g *SingleFlight[K, V]) Do(key K, fn func() (V, error)) (v V, err error, shared bool) {
+    c, leader := g.join(key, nil)
+    if leader {
+        g.run(key, c, fn)
+    } else {
+        <-c.done
+    }
+    return c.result()
+}
+
+// DoChan 与 Do 相同,但不阻塞,结果通过channel返回; fn发生panic时结果的Err为 *PanicError
+func (g *SingleFlight[K, V]) DoChan(key K, fn func() (V, error)) <-chan FlightResult[V] {
+    ch := make(chan FlightResult[V], 1)
+    c, leader := g.join(key, ch)
+    if leader {
+        go g.run(key, c, fn)
+    }
+    return ch
+}
+
+// DoCtx 与 Do 相同,但调用方可以在ctx取消时提前离开并返回ctx.Err()
+// 共享的调用不会因此取消,fn在独立的goroutine中执行,结果仍会交付给其他调用方
+func (g *SingleFlight[K, V]) DoCtx(ctx context.Context, key K, fn func() (V, error)) (v V, err error, shared bool) {
+    c, leader := g.join(key, nil)
+    if leader {
+        go g.run(key, c, fn)
+    }
+    select {
+    case <-c.done:
+        return c.result()
+    case <-ctx.Done():
+        g.mu.Lock()
+        shared = c.dups > 0
+        g.mu.Unlock()
+        return v, ctx.Err(), shared
+    }
+}
+
+// Forget 忘记key对应的进行中调用,之后的调用会重新执行fn,已在等待的调用方仍获得原结果
+func (g *SingleFlight[K, V]) Forget(key K) {
+    g.mu.Lock()
+    delete(g.calls, key)
+    g.mu.Unlock()
+}
+
+// join 加入key对应的调用,不存在时创建并返回leader=true
+func (g *SingleFlight[K, V]) join(key K, ch chan<- FlightResult[V]) (c *flightCall[V], leader bool) {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    if g.calls == nil {
+        g.calls = map[K]*flightCall[V]{}
+    }
+    if c = g.calls[key]; c != nil {
+        c.dups++
+    } else {
+        c = &flightCall[V]{done: make(chan struct{})}
+        g.calls[key] = c
+        leader = true
+    }
+    if ch != nil {
+        c.chans = append(c.chans, ch)
+    }
+    return c, leader
+}
+
+func (g *SingleFlight[K, V]) run(key K, c *flightCall[V], fn func() (V, error)) {
+    defer func() {
+        if r := recover(); r != nil {
+            c.err = &PanicError{Value: r, Stack: debug.Stack()}
+        }
+        g.mu.Lock()
+        if g.calls[key] == c {
+            delete(g.calls, key)
+        }
+        shared := c.dups > 0
+        chans := c.chans
+        g.mu.Unlock()
+        close(c.done)
+        for _, ch := range chans {
+            ch <- FlightResult[V]{Val: c.val, Err: c.err, Shared: shared}
+        }
+    }()
+    c.val, c.err = fn()
+}
+
+// result 调用方需保证调用已完成
+func (c *flightCall[V]) result() (V, error, bool) {
+    if p, ok := c.err.(*PanicError); ok {
+        panic(p)
+    }
+    // 调用完成后dups不再变化
+    return c.val, c.err, c.dups > 0
+}
@@ -0,0 +1,134 @@
+package concurrent
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+func Test_SingleFlight_Do(t *testing.T) {
+    var sf SingleFlight[string, int]
+    v, err, shared := sf.Do("a", func() (int, error) { return 1, nil })
+    if v != 1 || err != nil || shared {
+        t.Fatalf("v=%d err=%v shared=%v", v, err, shared)
+    }
+    errBoom := errors.New("boom")
+    if _, err, _ = sf.Do("a", func() (int, error) { return 0, errBoom }); err != errBoom {
+        t.Fatalf("err=%v", err)
+    }
+}
+
+func Test_SingleFlight_Coalesce(t *testing.T) {
+    t.Parallel()
+    var sf SingleFlight[string, int]
+    var calls int32
+    release := make(chan struct{})
+    fn := func() (int, error) {
+        atomic.AddInt32(&calls, 1)
+        <-release
+        return 42, nil
+    }
+    const n = 10
+    var wg sync.WaitGroup
+    var sharedCount int32
+    for i := 0; i < n; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            v, err, shared := sf.Do("k", fn)
+            if v != 42 || err != nil {
+                t.Errorf("v=%d err=%v", v, err)
+            }
+            if shared {
+                atomic.AddInt32(&sharedCount, 1)
+            }
+        }()
+    }
+    time.Sleep(20 * time.Millisecond)
+    close(release)
+    wg.Wait()
+    if c := atomic.LoadInt32(&calls); c != 1 {
+        t.Fatalf("fn执行了%d次", c)
+    }
+    if sharedCount != n {
+        t.Fatalf("shared=%d", sharedCount)
+    }
+}
+
+func Test_SingleFlight_DoChanAndForget(t *testing.T) {
+    var sf SingleFlight[int, string]
+    release := make(chan struct{})
+    ch1 := sf.DoChan(1, func() (string, error) {
+        <-release
+        return "first", nil
+    })
+    sf.Forget(1)
+    // Forget 后重新执行
+    ch2 := sf.DoChan(1, func() (string, error) { return "second", nil })
+    if r := <-ch2; r.Val != "second" || r.Shared {
+        t.Fatalf("r=%+v", r)
+    }
+    close(release)
+    if r := <-ch1; r.Val != "first" {
+        t.Fatalf("r=%+v", r)
+    }
+}
+
+func Test_SingleFlight_DoCtx(t *testing.T) {
+    t.Parallel()
+    var sf SingleFlight[string, int]
+    release := make(chan struct{})
+    var calls int32
+    fn := func() (int, error) {
+        atomic.AddInt32(&calls, 1)
+        <-release
+        return 7, nil
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+    defer cancel()
+    result := make(chan int)
+    go func() {
+        v, _, _ := sf.Do("k", fn)
+        result <- v
+    }()
+    time.Sleep(5 * time.Millisecond)
+    // 调用方超时离开,不影响共享的调用
+    if _, err, _ := sf.DoCtx(ctx, "k", fn); !errors.Is(err, context.DeadlineExceeded) {
+        t.Fatalf("err=%v", err)
+    }
+    close(release)
+    if v := <-result; v != 7 {
+        t.Fatalf("v=%d", v)
+    }
+    if v, err, _ := sf.DoCtx(context.Background(), "k", func() (int, error) { return 8, nil }); v != 8 || err != nil {
+        t.Fatalf("v=%d err=%v", v, err)
+    }
+    if c := atomic.LoadInt32(&calls); c != 1 {
+        t.Fatalf("calls=%d", c)
+    }
+}
+
+func Test_SingleFlight_Panic(t *testing.T) {
+    var sf SingleFlight[string, int]
+    func() {
+        defer func() {
+            r := recover()
+            if p, ok := r.(*PanicError); !ok || p.Value != "oops" {
+                t.Fatalf("recover=%v", r)
+            }
+        }()
+        sf.Do("k", func() (int, error) { panic("oops") })
+    }()
+    r := <-sf.DoChan("k", func() (int, error) { panic("oops") })
+    var p *PanicError
+    if !errors.As(r.Err, &p) {
+        t.Fatalf("err=%v", r.Err)
+    }
+    // panic后key被清理
+    if v, _, _ := sf.Do("k", func() (int, error) { return 1, nil }); v != 1 {
+        t.Fatalf("v=%d", v)
+    }
+}
@@ -1,17 +1,24 @@
 package concurrent
 
 import (
+    "context"
+    "math"
     "sync"
     "sync/atomic"
     "time"
 )
 
-// SlidingWindow 简单的时间滑动窗口实现
+// slidingWindowNever sliceFullTime 的初始值, 表示从未滑动过
+const slidingWindowNever = math.MinInt64
+
+// SlidingWindow 简单的时间滑动窗口实现,实现了 Limiter 接口
 type SlidingWindow struct {
     // 所有窗口，记录当前计数
     counters []int32
     // 每个窗口的限制
     limits []int32
+    // 单个窗口限制的最大值, AllowN 超过它时恒为false
+    maxLimit int32
     // 时间窗口，毫秒
     time int64
     // 每秒允许尝试数量
@@ -34,30 +41,41 @@ type SlidingWindow struct {
 // NewSlidingWindow 创建滑动窗口
 // time: 时间窗口长度(毫秒)
 // allowNumber: 允许的最大请求数
-// windowNumber: 窗口数量
+// windowNumber: 窗口数量, 小于1时按1处理, 超过 time 或 allowNumber 时减少到两者中的较小值, 保证每个子窗口至少1毫秒且限额至少为1
 func NewSlidingWindow(time int64, allowNumber, windowNumber int32) *SlidingWindow {
-    if windowNumber <= 2 {
-        panic("窗口数量必须大于2否则有bug")
+    if time <= 0 {
+        panic("时间窗口长度必须大于0")
     }
 
-    if time <= int64(windowNumber) {
-        panic("时间窗口长度必须大于窗口数量")
+    if allowNumber <= 0 {
+        panic("allowNumber必须大于0")
     }
 
-    if allowNumber < windowNumber {
-        panic("allowNumber必须大于等于windowNumber")
+    if windowNumber < 1 {
+        windowNumber = 1
+    }
+    if int64(windowNumber) > time {
+        windowNumber = int32(time)
+    }
+    if windowNumber > allowNumber {
+        windowNumber = allowNumber
     }
 
     sw := &SlidingWindow{
         time:            time,
         allowNumber:     allowNumber,
         windowNumber:    windowNumber,
-        everyWindowTime: time / int64(windowNumber),
+        // 向上取整, 保证一个时间窗口内最多经过 windowNumber 个分片
+        everyWindowTime: (time + int64(windowNumber) - 1) / int64(windowNumber),
         counters:        make([]int32, windowNumber),
         limits:          make([]int32, windowNumber),
         sliceFullTime:   make([]int64, windowNumber),
     }
 
+    for i := range sw.sliceFullTime {
+        sw.sliceFullTime[i] = slidingWindowNever
+    }
+
     weights := make([]int32, windowNumber)
     for i := range weights {
         weights[i] = 1
@@ -67,6 +85,9 @@ func NewSlidingWindow(time int64, allowNumber, windowNumber int32) *SlidingWindo
 
     for i := int32(0); i < windowNumber; i++ {
         sw.limits[i] = values[i]
+        if values[i] > sw.maxLimit {
+            sw.maxLimit = values[i]
+        }
     }
 
     return sw
@@ -74,13 +95,63 @@ func NewSlidingWindow(time int64, allowNumber, windowNumber int32) *SlidingWindo
 
 // CanDo 获取能否执行
 func (sw *SlidingWindow) CanDo() bool {
-    // 获取当前时间
-    var now int64
+    return sw.canDoN(1)
+}
+
+// Allow 同 CanDo
+func (sw *SlidingWindow) Allow() bool {
+    return sw.canDoN(1)
+}
+
+// AllowN 获取能否执行n次,n次必须在同一个子窗口内完成
+// n超过单个子窗口的限额(各子窗口中的最大值,约为 allowNumber/windowNumber)时恒为false, 且不会触发窗口滑动
+func (sw *SlidingWindow) AllowN(n int) bool {
+    if n <= 0 {
+        return true
+    }
+    if n > int(sw.maxLimit) {
+        return false
+    }
+    return sw.canDoN(int32(n))
+}
+
+// Reserve 滑动窗口无法预约未来的配额,当前可执行时返回立即可用的预约,否则预约失败
+func (sw *SlidingWindow) Reserve() *Reservation {
+    if !sw.canDoN(1) {
+        return &Reservation{}
+    }
+    return &Reservation{ok: true, now: sw.now}
+}
+
+// Wait 阻塞直到可以执行,每个子窗口时间重试一次
+func (sw *SlidingWindow) Wait(ctx context.Context) error {
+    if sw.canDoN(1) {
+        return nil
+    }
+    t := time.NewTicker(time.Duration(sw.everyWindowTime) * time.Millisecond)
+    defer t.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return ctx.Err()
+        case <-t.C:
+            if sw.canDoN(1) {
+                return nil
+            }
+        }
+    }
+}
+
+func (sw *SlidingWindow) now() int64 {
     if sw.Now == nil {
-        now = time.Now().UnixMilli()
-    } else {
-        now = sw.Now()
+        return time.Now().UnixMilli()
     }
+    return sw.Now()
+}
+
+func (sw *SlidingWindow) canDoN(n int32) bool {
+    // 获取当前时间
+    now := sw.now()
 
     for {
         // 获取当前分片
@@ -90,8 +161,8 @@ func (sw *SlidingWindow) CanDo() bool {
         currentCount := atomic.LoadInt32(&sw.counters[index])
         limit := atomic.LoadInt32(&sw.limits[index])
 
-        // 如果当前计数已经等于或超过限制，则直接处理滑动逻辑
-        if currentCount >= limit {
+        // 如果当前计数加上本次数量超过限制，则直接处理滑动逻辑
+        if currentCount+n > limit {
             // 处理窗口滑动逻辑
             if sw.handleWindowSliding(index, now) {
                 continue // 窗口已滑动，重试
@@ -101,14 +172,14 @@ func (sw *SlidingWindow) CanDo() bool {
         }
 
         // 原子增加计数器
-        count := atomic.AddInt32(&sw.counters[index], 1)
+        count := atomic.AddInt32(&sw.counters[index], n)
 
         if count <= limit {
             // 有空间，返回true
             return true
         } else {
             // 没空间，先回退计数器
-            atomic.AddInt32(&sw.counters[index], -1)
+            atomic.AddInt32(&sw.counters[index], -n)
 
             // 处理窗口滑动逻辑
             if sw.handleWindowSliding(index, now) {
@@ -121,20 +192,29 @@ func (sw *SlidingWindow) CanDo() bool {
 }
 
 // handleWindowSliding 处理窗口滑动逻辑
+// sliceFullTime[上个分片] 记录上次滑动(即上个分片满)的时间, 两次滑动至少间隔一个子窗口时间
 func (sw *SlidingWindow) handleWindowSliding(currentIndex int32, now int64) bool {
     prevIndex := (currentIndex + sw.windowNumber - 1) % sw.windowNumber
 
     // 获取上个分片满的时间
     last := atomic.LoadInt64(&sw.sliceFullTime[prevIndex])
+
+    // 从未滑动过: 以当前分片首次满的时间为起点, 避免刚创建时连续滑动放过两个分片的请求
+    if last == slidingWindowNever {
+        sw.mutex.Lock()
+        if currentIndex == atomic.LoadInt32(&sw.sliceIndex) && atomic.LoadInt64(&sw.sliceFullTime[prevIndex]) == slidingWindowNever {
+            atomic.StoreInt64(&sw.sliceFullTime[prevIndex], now)
+        }
+        sw.mutex.Unlock()
+        return false
+    }
     difference := now - last
 
     // 与当前时间对比，过了一个窗口时间，清空已经过期的分片，指针指向下一个
     if difference >= sw.everyWindowTime {
         sw.mutex.Lock()
-        // 简单做个防修改
-        if last == atomic.LoadInt64(&sw.sliceFullTime[prevIndex]) {
-            // 记录当前分片已满
-            atomic.StoreInt64(&sw.sliceFullTime[prevIndex], now)
+        // 简单做个防修改, 其他调用方已滑动时直接重试
+        if currentIndex == atomic.LoadInt32(&sw.sliceIndex) && last == atomic.LoadInt64(&sw.sliceFullTime[prevIndex]) {
             num := difference / sw.everyWindowTime
             if num > int64(sw.windowNumber) {
                 num = int64(sw.windowNumber)
@@ -142,12 +222,13 @@ func (sw *SlidingWindow) handleWindowSliding(currentIndex int32, now int64) bool
 
             // 清空已经过期的分片
             for l := int64(1); l <= num; l++ {
-                idx := (prevIndex + int32(l)) % sw.windowNumber
+                idx := (currentIndex + int32(l)) % sw.windowNumber
                 atomic.StoreInt32(&sw.counters[idx], 0)
             }
 
-            idx := atomic.LoadInt32(&sw.sliceIndex)
-            nextIndex := (idx + int32(num)) % sw.windowNumber
+            nextIndex := (currentIndex + int32(num)) % sw.windowNumber
+            // 记录当前分片已满, 滑动后它(或跳过的最后一个分片)成为上个分片
+            atomic.StoreInt64(&sw.sliceFullTime[(nextIndex+sw.windowNumber-1)%sw.windowNumber], now)
             atomic.StoreInt32(&sw.sliceIndex, nextIndex)
         }
         sw.mutex.Unlock()
@@ -215,35 +215,68 @@ func abs(x int) int {
     return x
 }
 
-// Test_SlidingWindow_WindowNumberPanic 测试窗口数量<=2时应panic
-func Test_SlidingWindow_WindowNumberPanic(t *testing.T) {
+// Test_SlidingWindow_SmallParams 窗口数过小、时间窗口或允许数小于窗口数时按规则调整窗口数, 不panic且任意时间窗口内通过数不超过 allowNumber
+func Test_SlidingWindow_SmallParams(t *testing.T) {
     t.Parallel()
     testCases := []struct {
         name         string
         timeWindow   int64
         allowNumber  int32
         windowNumber int32
+        // 调整后的窗口数
+        windows int32
     }{
-        {"窗口数为1", 1000, 100, 1},
-        {"窗口数为2", 1000, 100, 2},
-        {"窗口数为0", 1000, 100, 0},
-        {"窗口数为-1", 1000, 100, -1},
+        {"窗口数为1", 1000, 10, 1, 1},
+        {"窗口数为2", 1000, 10, 2, 2},
+        {"窗口数为0", 1000, 10, 0, 1},
+        {"窗口数为-1", 1000, 10, -1, 1},
+        {"时间窗口不能整除窗口数", 1000, 10, 3, 3},
+        {"时间窗口小于窗口数", 3, 10, 5, 3},
+        {"时间窗口等于窗口数", 5, 10, 5, 5},
+        {"允许数小于窗口数", 1000, 2, 5, 2},
+        {"允许数为1", 1000, 1, 3, 1},
     }
 
     for _, tc := range testCases {
+        tc := tc
         t.Run(tc.name, func(t *testing.T) {
-            defer func() {
-                if r := recover(); r == nil {
-                    t.Errorf("窗口数 %d 应该触发 panic", tc.windowNumber)
+            var now int64 = 1000000
+            sw := NewSlidingWindow(tc.timeWindow, tc.allowNumber, tc.windowNumber)
+            sw.Now = func() int64 { return now }
+            if sw.windowNumber != tc.windows {
+                t.Fatalf("windowNumber=%d want %d", sw.windowNumber, tc.windows)
+            }
+            // 每毫秒尝试 allowNumber 次, 共20个时间窗口
+            var passed []int64
+            for end := now + 20*tc.timeWindow; now < end; now++ {
+                for i := int32(0); i < tc.allowNumber; i++ {
+                    if sw.Allow() {
+                        passed = append(passed, now)
+                    }
                 }
-            }()
-            _ = NewSlidingWindow(tc.timeWindow, tc.allowNumber, tc.windowNumber)
+            }
+            maxIn := 0
+            for i, j := 0, 0; i < len(passed); i++ {
+                for passed[i]-passed[j] >= tc.timeWindow {
+                    j++
+                }
+                if i-j+1 > maxIn {
+                    maxIn = i - j + 1
+                }
+            }
+            if maxIn > int(tc.allowNumber) {
+                t.Fatalf("时间窗口内通过 %d 次, 超过 %d", maxIn, tc.allowNumber)
+            }
+            // 长期通过率接近 allowNumber/timeWindow
+            if want := 19 * int(tc.allowNumber); len(passed) < want {
+                t.Fatalf("共通过 %d 次, 至少应为 %d", len(passed), want)
+            }
         })
     }
 }
 
-// Test_SlidingWindow_TimeLessThanWindowNumber 测试时间小于窗口数量时应panic
-func Test_SlidingWindow_TimeLessThanWindowNumber(t *testing.T) {
+// Test_SlidingWindow_InvalidParams 时间窗口或允许数不大于0时应panic
+func Test_SlidingWindow_InvalidParams(t *testing.T) {
     t.Parallel()
     testCases := []struct {
         name         string
@@ -252,15 +285,17 @@ func Test_SlidingWindow_TimeLessThanWindowNumber(t *testing.T) {
         windowNumber int32
     }{
         {"时间窗口为0", 0, 10, 5},
-        {"时间窗口小于窗口数", 3, 10, 5},
-        {"时间窗口等于窗口数", 5, 10, 5},
+        {"时间窗口为负数", -1, 10, 5},
+        {"允许数为0", 1000, 0, 5},
+        {"允许数为负数", 1000, -1, 5},
     }
 
     for _, tc := range testCases {
+        tc := tc
         t.Run(tc.name, func(t *testing.T) {
             defer func() {
                 if r := recover(); r == nil {
-                    t.Errorf("时间窗口 %d 小于或等于窗口数 %d 应该触发 panic", tc.timeWindow, tc.windowNumber)
+                    t.Errorf("time=%d allowNumber=%d 应该触发 panic", tc.timeWindow, tc.allowNumber)
                 }
             }()
             _ = NewSlidingWindow(tc.timeWindow, tc.allowNumber, tc.windowNumber)
@@ -268,28 +303,40 @@ func Test_SlidingWindow_TimeLessThanWindowNumber(t *testing.T) {
     }
 }
 
-// Test_SlidingWindow_AllowNumberLessThanWindowNumber 测试允许数小于窗口数时应panic
-func Test_SlidingWindow_AllowNumberLessThanWindowNumber(t *testing.T) {
-    t.Parallel()
-    testCases := []struct {
-        name         string
-        timeWindow   int64
-        allowNumber  int32
-        windowNumber int32
-    }{
-        {"允许数小于窗口数", 1000, 2, 5},
-        {"允许数为0", 1000, 0, 5},
-        {"允许数为负数", 1000, -1, 5},
+func Test_SlidingWindow_AllowNBoundary(t *testing.T) {
+    var now int64 = 1000000
+    // 3个子窗口, 每个子窗口限额10
+    sw := NewSlidingWindow(300, 30, 3)
+    sw.Now = func() int64 { return now }
+    if sw.AllowN(11) || sw.AllowN(30) {
+        t.Fatal("n超过单个子窗口限额应恒为false")
     }
-
-    for _, tc := range testCases {
-        t.Run(tc.name, func(t *testing.T) {
-            defer func() {
-                if r := recover(); r == nil {
-                    t.Errorf("允许数 %d 小于窗口数 %d 应该触发 panic", tc.allowNumber, tc.windowNumber)
-                }
-            }()
-            _ = NewSlidingWindow(tc.timeWindow, tc.allowNumber, tc.windowNumber)
-        })
+    if !sw.AllowN(10) {
+        t.Fatal("n等于子窗口限额时空窗口应通过")
+    }
+    // 时间不前进时总数不超过 allowNumber
+    passed := 10
+    for i := 0; i < 10 && sw.AllowN(10); i++ {
+        passed += 10
+    }
+    if passed > 30 {
+        t.Fatalf("passed=%d", passed)
+    }
+    // 超过子窗口限额的请求直接失败, 不会滑动窗口释放出额外的配额
+    for i := 0; i < 5; i++ {
+        if sw.AllowN(20) {
+            t.Fatal("n超过单个子窗口限额应恒为false")
+        }
+    }
+    if sw.AllowN(1) {
+        t.Fatal("时间未前进, 配额已用尽应拒绝")
+    }
+    // 时间前进后仍以单个子窗口限额为上限
+    now += 300
+    if sw.AllowN(11) {
+        t.Fatal("n超过单个子窗口限额应恒为false")
+    }
+    if !sw.AllowN(10) {
+        t.Fatal("时间前进后n等于子窗口限额应通过")
     }
 }
@@ -74,8 +74,8 @@ func (t *task) Cancel() {
         return
     }
     // 立即回收 taskCount 并从槽位物理移除，避免内存堆积
+    // 不清空 wheel: 任务可能正在执行, finish 会并发读取 wheel, 由 cancelled 与 counted 保证只处理一次
     if w := t.wheel; w != nil {
-        t.wheel = nil
         if atomic.SwapUint32(&t.counted, 0) != 0 {
             atomic.AddInt32(&w.taskCount, -1)
         }
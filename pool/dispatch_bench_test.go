@@ -64,3 +64,35 @@ func BenchmarkGo_Throughput(b *testing.B) {
 	})
 	wg.Wait()
 }
+
+// BenchmarkGoPool_DispatchRoundtrip_WorkStealing 工作窃取模式的单任务往返延迟
+func BenchmarkGoPool_DispatchRoundtrip_WorkStealing(b *testing.B) {
+	p := NewGopool(WithWorkStealing())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	_ = p.Go(func() { wg.Done() })
+	wg.Wait()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			var wg sync.WaitGroup
+			wg.Add(1)
+			_ = p.Go(func() { wg.Done() })
+			wg.Wait()
+		}
+	})
+}
+
+// BenchmarkGoPool_Throughput_WorkStealing 工作窃取模式的纯吞吐
+func BenchmarkGoPool_Throughput_WorkStealing(b *testing.B) {
+	p := NewGopool(WithWorkStealing())
+	var wg sync.WaitGroup
+	wg.Add(b.N)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = p.Go(func() { wg.Done() })
+		}
+	})
+	wg.Wait()
+}
@@ -1,9 +1,14 @@
 // Package pool 提供高性能的对象复用基础设施
 //
-// 五个核心组件:
+// 核心组件:
 //   - GoPool: 弹性协程池, BlockQueue阻塞等待任务, 空闲超时自动退出
-//   - StringPool: 字符串<->ID映射池, 引用计数管理生命周期
+//   - Future: 通过 Submit 提交到 GoPool 的异步任务结果, 支持组合与超时
+//   - OrderedExecutor: 基于 GoPool 的按key串行执行器
+//   - ScheduledPool: 基于 TimerWheel 计时、GoPool 执行的定时与周期任务调度器
+//   - StringPool: 字符串<->ID双向映射池, 引用计数管理生命周期, 支持只读快照与导入导出
 //   - BufferPool: bytes.Buffer 对象池, 容量上限保护
 //   - BytePool: 轻量字节缓冲池, 自定义Bytes类型
+//   - SizedBytePool: 按2的幂分级的字节切片池, 支持泄漏与重复归还检测
 //   - ObjectPool: 泛型对象池, 支持自定义创建和重置函数
+//   - ResourcePool: 有界的泛型资源池, 支持校验、空闲超时与借出等待
 package pool
\ No newline at end of file
@@ -0,0 +1,254 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/mzzsfy/go-util/concurrent"
+)
+
+var (
+	// ErrFutureTimeout Future 在 Timeout 指定的时间内未完成
+	ErrFutureTimeout = errors.New("future timeout")
+	// ErrNoFutures AnyOf 未传入任何 Future
+	ErrNoFutures = errors.New("no futures")
+)
+
+// Future 异步任务的结果
+//
+// 回调(Then/Catch等)在使 Future 完成的协程中同步执行, Future 已完成时在注册回调的协程中立即执行,
+// 耗时的后续处理应再次 Submit 到协程池
+type Future[T any] interface {
+	// Get 阻塞直到完成或 ctx 取消, ctx 取消时返回 ctx.Err(), 不影响任务本身
+	Get(ctx context.Context) (T, error)
+	// Done 完成时关闭的 channel
+	Done() <-chan struct{}
+	// Result 非阻塞获取结果, 未完成时 ok 为 false
+	Result() (v T, err error, ok bool)
+	// Catch 失败时使用 fn 的返回值作为结果, 成功时结果不变
+	Catch(fn func(error) (T, error)) Future[T]
+	// Timeout d 内未完成时以 ErrFutureTimeout 失败, 不影响任务本身
+	Timeout(d time.Duration) Future[T]
+	// OnComplete 注册完成回调
+	OnComplete(fn func(T, error))
+}
+
+// Promise 可手动完成的 Future, 只有第一次 Resolve/Reject 生效
+type Promise[T any] struct {
+	mu        sync.Mutex
+	done      chan struct{}
+	completed bool
+	val       T
+	err       error
+	callbacks []func(T, error)
+}
+
+// NewPromise 创建未完成的 Promise
+func NewPromise[T any]() *Promise[T] {
+	return &Promise[T]{done: make(chan struct{})}
+}
+
+// Resolve 以 v 成功完成, 已完成时返回 false
+func (p *Promise[T]) Resolve(v T) bool {
+	return p.complete(v, nil)
+}
+
+// Reject 以 err 失败完成, 已完成时返回 false
+func (p *Promise[T]) Reject(err error) bool {
+	var zero T
+	return p.complete(zero, err)
+}
+
+func (p *Promise[T]) complete(v T, err error) bool {
+	p.mu.Lock()
+	if p.completed {
+		p.mu.Unlock()
+		return false
+	}
+	p.completed = true
+	p.val, p.err = v, err
+	callbacks := p.callbacks
+	p.callbacks = nil
+	close(p.done)
+	p.mu.Unlock()
+	for _, fn := range callbacks {
+		fn(v, err)
+	}
+	return true
+}
+
+func (p *Promise[T]) Get(ctx context.Context) (T, error) {
+	select {
+	case <-p.done:
+		return p.val, p.err
+	default:
+	}
+	select {
+	case <-p.done:
+		return p.val, p.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+func (p *Promise[T]) Done() <-chan struct{} {
+	return p.done
+}
+
+func (p *Promise[T]) Result() (v T, err error, ok bool) {
+	select {
+	case <-p.done:
+		return p.val, p.err, true
+	default:
+		return v, nil, false
+	}
+}
+
+func (p *Promise[T]) OnComplete(fn func(T, error)) {
+	p.mu.Lock()
+	if !p.completed {
+		p.callbacks = append(p.callbacks, fn)
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+	fn(p.val, p.err)
+}
+
+func (p *Promise[T]) Catch(fn func(error) (T, error)) Future[T] {
+	next := NewPromise[T]()
+	p.OnComplete(func(v T, err error) {
+		if err == nil {
+			next.Resolve(v)
+			return
+		}
+		next.complete(call(func() (T, error) { return fn(err) }))
+	})
+	return next
+}
+
+func (p *Promise[T]) Timeout(d time.Duration) Future[T] {
+	next := NewPromise[T]()
+	timer := time.AfterFunc(d, func() { next.Reject(ErrFutureTimeout) })
+	p.OnComplete(func(v T, err error) {
+		timer.Stop()
+		next.complete(v, err)
+	})
+	return next
+}
+
+// Then f 成功后以 fn 的返回值作为新 Future 的结果, f 失败时直接传递错误
+func Then[T, R any](f Future[T], fn func(T) (R, error)) Future[R] {
+	next := NewPromise[R]()
+	f.OnComplete(func(v T, err error) {
+		if err != nil {
+			next.Reject(err)
+			return
+		}
+		next.complete(call(func() (R, error) { return fn(v) }))
+	})
+	return next
+}
+
+// AllOf 全部成功时按传入顺序返回结果, 任意一个失败时立即以该错误失败
+func AllOf[T any](fs ...Future[T]) Future[[]T] {
+	next := NewPromise[[]T]()
+	if len(fs) == 0 {
+		next.Resolve([]T{})
+		return next
+	}
+	res := make([]T, len(fs))
+	var mu sync.Mutex
+	remain := len(fs)
+	for i, f := range fs {
+		i := i
+		f.OnComplete(func(v T, err error) {
+			if err != nil {
+				next.Reject(err)
+				return
+			}
+			mu.Lock()
+			res[i] = v
+			remain--
+			last := remain == 0
+			mu.Unlock()
+			if last {
+				next.Resolve(res)
+			}
+		})
+	}
+	return next
+}
+
+// AnyOf 返回第一个成功的结果, 全部失败时以最后一个失败的错误失败
+func AnyOf[T any](fs ...Future[T]) Future[T] {
+	next := NewPromise[T]()
+	if len(fs) == 0 {
+		next.Reject(ErrNoFutures)
+		return next
+	}
+	var mu sync.Mutex
+	remain := len(fs)
+	for _, f := range fs {
+		f.OnComplete(func(v T, err error) {
+			if err == nil {
+				next.Resolve(v)
+				return
+			}
+			mu.Lock()
+			remain--
+			last := remain == 0
+			mu.Unlock()
+			if last {
+				next.Reject(err)
+			}
+		})
+	}
+	return next
+}
+
+// call 执行 fn, panic 时返回 *concurrent.PanicError
+func call[T any](fn func() (T, error)) (v T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &concurrent.PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
+
+// Submit 提交有返回值的任务到协程池, p 为 nil 时使用默认协程池
+// 任务 panic 时 Future 以 *concurrent.PanicError 失败, 同时仍会调用协程池的 panicHandler
+// 协程池已关闭或任务被拒绝时返回以对应错误失败的 Future
+func Submit[T any](p *GoPool, fn func() (T, error)) Future[T] {
+	return SubmitCtx(context.Background(), p, fn)
+}
+
+// SubmitCtx 同 Submit, 携带 context 提交任务
+func SubmitCtx[T any](ctx context.Context, p *GoPool, fn func() (T, error)) Future[T] {
+	if p == nil {
+		p = defaultGoPool
+	}
+	f := NewPromise[T]()
+	err := p.ctxGo(ctx, func() {
+		defer func() {
+			if r := recover(); r != nil {
+				f.Reject(&concurrent.PanicError{Value: r, Stack: debug.Stack()})
+				// 继续向上抛出, 交给协程池的 panicHandler 处理
+				panic(r)
+			}
+		}()
+		f.complete(fn())
+	}, func(err error) {
+		// 未执行就被丢弃: 被拒绝策略丢弃, ctx 已取消或关闭时被放弃
+		f.Reject(err)
+	})
+	if err != nil {
+		f.Reject(err)
+	}
+	return f
+}
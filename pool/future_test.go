@@ -0,0 +1,162 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mzzsfy/go-util/concurrent"
+	"github.com/mzzsfy/go-util/pool"
+)
+
+func Test_Future_Submit(t *testing.T) {
+	t.Parallel()
+	p := pool.NewGopool()
+	defer p.Shutdown()
+
+	f := pool.Submit(p, func() (int, error) {
+		time.Sleep(5 * time.Millisecond)
+		return 21, nil
+	})
+	s := pool.Then(f, func(v int) (string, error) {
+		return strconv.Itoa(v * 2), nil
+	})
+	v, err := s.Get(context.Background())
+	if err != nil || v != "42" {
+		t.Fatalf("v=%s err=%v", v, err)
+	}
+	if _, _, ok := f.Result(); !ok {
+		t.Fatal("f应已完成")
+	}
+}
+
+func Test_Future_Panic(t *testing.T) {
+	t.Parallel()
+	var handled int32
+	p := pool.NewGopool(pool.WithPanicHandler(func(any, context.Context) {
+		atomic.AddInt32(&handled, 1)
+	}))
+	defer p.Shutdown()
+
+	f := pool.Submit(p, func() (int, error) {
+		panic("boom")
+	})
+	_, err := f.Get(context.Background())
+	var pe *concurrent.PanicError
+	if !errors.As(err, &pe) || pe.Value != "boom" {
+		t.Fatalf("err=%v", err)
+	}
+	for i := 0; atomic.LoadInt32(&handled) == 0; i++ {
+		if i > 1000 {
+			t.Fatal("panicHandler未被调用")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Then 中的panic同样转为错误
+	_, err = pool.Then(pool.Submit(p, func() (int, error) { return 1, nil }), func(int) (int, error) {
+		panic("then")
+	}).Get(context.Background())
+	if !errors.As(err, &pe) || pe.Value != "then" {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func Test_Future_Catch(t *testing.T) {
+	errFoo := errors.New("foo")
+	f := pool.NewPromise[int]()
+	c := f.Catch(func(err error) (int, error) {
+		if err == errFoo {
+			return -1, nil
+		}
+		return 0, err
+	})
+	// 失败时不执行 Then
+	th := pool.Then[int, int](f, func(v int) (int, error) {
+		t.Error("不应执行")
+		return v, nil
+	})
+	if !f.Reject(errFoo) || f.Resolve(1) {
+		t.Fatal("只有第一次完成生效")
+	}
+	if v, err := c.Get(context.Background()); err != nil || v != -1 {
+		t.Fatalf("v=%d err=%v", v, err)
+	}
+	if _, err := th.Get(context.Background()); err != errFoo {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func Test_Future_Timeout(t *testing.T) {
+	t.Parallel()
+	f := pool.NewPromise[int]()
+	if _, err := f.Timeout(10 * time.Millisecond).Get(context.Background()); err != pool.ErrFutureTimeout {
+		t.Fatalf("err=%v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := f.Get(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("err=%v", err)
+	}
+	f.Resolve(1)
+	if v, err := f.Timeout(time.Second).Get(context.Background()); err != nil || v != 1 {
+		t.Fatalf("v=%d err=%v", v, err)
+	}
+}
+
+func Test_Future_AllOfAnyOf(t *testing.T) {
+	t.Parallel()
+	var fs []pool.Future[int]
+	for i := 0; i < 5; i++ {
+		i := i
+		fs = append(fs, pool.Submit(nil, func() (int, error) {
+			time.Sleep(time.Duration(5-i) * time.Millisecond)
+			return i, nil
+		}))
+	}
+	all, err := pool.AllOf(fs...).Get(context.Background())
+	if err != nil || len(all) != 5 {
+		t.Fatalf("all=%v err=%v", all, err)
+	}
+	for i, v := range all {
+		if v != i {
+			t.Fatalf("all=%v", all)
+		}
+	}
+
+	errFoo := errors.New("foo")
+	failed := pool.NewPromise[int]()
+	failed.Reject(errFoo)
+	pending := pool.NewPromise[int]()
+	if _, err = pool.AllOf[int](pending, failed).Get(context.Background()); err != errFoo {
+		t.Fatalf("err=%v", err)
+	}
+
+	ok := pool.NewPromise[int]()
+	first := pool.AnyOf[int](failed, pending, ok)
+	if _, _, done := first.Result(); done {
+		t.Fatal("尚无成功结果")
+	}
+	ok.Resolve(7)
+	if v, err := first.Get(context.Background()); err != nil || v != 7 {
+		t.Fatalf("v=%d err=%v", v, err)
+	}
+	pending.Reject(errors.New("bar"))
+	if _, err = pool.AnyOf[int](failed, pending).Get(context.Background()); err == nil {
+		t.Fatal("全部失败时应返回错误")
+	}
+	if _, err = pool.AnyOf[int]().Get(context.Background()); err != pool.ErrNoFutures {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func Test_Future_PoolClosed(t *testing.T) {
+	p := pool.NewGopool()
+	p.Shutdown()
+	if _, err := pool.Submit(p, func() (int, error) { return 1, nil }).Get(context.Background()); err != pool.ErrPoolClosed {
+		t.Fatalf("err=%v", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+	"unsafe"
 
 	"github.com/mzzsfy/go-util/concurrent"
 )
@@ -20,9 +21,14 @@ var (
 	ErrPoolClosed = errors.New("pool is shut down")
 
 	defaultGoPool = NewGopool(WithName("defaultGoPool"))
-	taskPool      = NewObjectPool(func() *task { return &task{} }, func(i *task) { i.ctx = nil; i.fn = nil })
-	// shutdownSentinel 用于唤醒阻塞 worker 的哨兵任务, nil 无法入队(lkQueue 丢弃 nil)
-	shutdownSentinel = &task{fn: func() {}, ctx: context.Background()}
+	taskPool      = NewObjectPool(func() *task { return &task{} }, func(i *task) { i.ctx = nil; i.fn = nil; i.discard = nil })
+)
+
+// shutDown 状态
+const (
+	poolRunning int32 = iota
+	poolShutdown
+	poolRestarting
 )
 
 func Go(f func()) error {
@@ -33,17 +39,70 @@ func CtxGo(ctx context.Context, f func()) error {
 	return defaultGoPool.CtxGo(ctx, f)
 }
 
+// CtxGoFunc 使用默认协程池运行接收 ctx 并返回错误的任务
+func CtxGoFunc(ctx context.Context, f func(ctx context.Context) error) error {
+	return defaultGoPool.CtxGoFunc(ctx, f)
+}
+
 // GoPool 弹性协程池
 // 空闲 worker 阻塞等待任务而非自旋, 超时后自动退出
 type GoPool struct {
 	panicHandler func(any, context.Context)
+	errorHandler func(error, context.Context)
+	skipHandler  func(context.Context)
 	name         string
 	works        int32
 	shutDown     int32
 	maxWorks     int32
+	minIdle      int32
 	idleTimeout  time.Duration
-	taskQueue    concurrent.BlockQueue[*task]
-	wg           sync.WaitGroup
+	// taskQueue 指向 *taskQueueRef, 队列关闭后不可重新打开, Restart 时整体替换
+	taskQueue unsafe.Pointer
+	newQueue  func() concurrent.ClosableQueue[*task]
+	wg        sync.WaitGroup
+	// waitMu 保护 waitCh, 见 workersDone
+	waitMu sync.Mutex
+	waitCh chan struct{}
+	// tenants 按租户限制进行中的任务数, 为nil时不限制
+	tenants *concurrent.Bulkhead
+	// workStealing 启用工作窃取调度
+	workStealing bool
+	// rejectPolicy 有界队列已满时的拒绝策略
+	rejectPolicy RejectPolicy
+	stats        poolStats
+}
+
+// taskQueueRef 一代任务队列, Restart 时整体替换
+type taskQueueRef struct {
+	concurrent.ClosableQueue[*task]
+	// ws 工作窃取调度器, 未启用时为nil, 启用时 ClosableQueue 作为全局溢出队列
+	ws *stealScheduler
+}
+
+func (p *GoPool) newTaskQueueRef() *taskQueueRef {
+	ref := &taskQueueRef{ClosableQueue: p.newQueue()}
+	if p.workStealing {
+		ref.ws = newStealScheduler(int(atomic.LoadInt32(&p.maxWorks)))
+	}
+	return ref
+}
+
+// ref 返回当前任务队列
+func (p *GoPool) ref() *taskQueueRef {
+	return (*taskQueueRef)(atomic.LoadPointer(&p.taskQueue))
+}
+
+// queue 返回当前任务队列
+func (p *GoPool) queue() concurrent.ClosableQueue[*task] {
+	return p.ref().ClosableQueue
+}
+
+// close 关闭任务队列, 此后提交失败
+func (r *taskQueueRef) close() {
+	r.ClosableQueue.Close()
+	if r.ws != nil {
+		r.ws.close()
+	}
 }
 
 func (p *GoPool) Name() string {
@@ -57,70 +116,132 @@ func (p *GoPool) WorkerCount() uint64 {
 
 // TaskCount 获取队列任务数量
 func (p *GoPool) TaskCount() uint64 {
-	return uint64(p.taskQueue.Size())
+	ref := p.ref()
+	n := uint64(ref.Size())
+	if ref.ws != nil {
+		n += uint64(atomic.LoadInt64(&ref.ws.pending))
+	}
+	return n
 }
 
 // drainQueue 清空队列中残留任务并执行, 返回已处理任务数
-// 用于 Shutdown 后处理 dispatch 竞态入队的任务, 保证已接受的任务不被丢失
-func (p *GoPool) drainQueue() int {
+// worker 已全部空闲退出时, 关闭前已入队的任务由调用方执行, 保证已接受的任务不被丢失
+func (p *GoPool) drainQueue(ref *taskQueueRef) int {
 	count := 0
-	for {
-		t, ok := p.taskQueue.Dequeue()
-		if !ok || t == nil {
-			break
+	if ref.ws != nil {
+		for _, r := range ref.ws.loadRings() {
+			for t := r.pop(&ref.ws.pending); t != nil; t = r.pop(&ref.ws.pending) {
+				p.executeTask(t)
+				count++
+			}
 		}
-		if t != shutdownSentinel {
-			// 执行而非仅回收, 确保竞态入队的任务不会丢失
-			p.executeTask(t)
-			count++
+	}
+	q := ref.ClosableQueue
+	for {
+		t, ok := q.Dequeue()
+		if !ok {
+			return count
 		}
+		p.executeTask(t)
+		count++
 	}
-	return count
 }
 
 // Shutdown 优雅关闭协程池: 停止接受新任务, 等待已有 worker 执行完队列中剩余任务
 func (p *GoPool) Shutdown() bool {
-	if !atomic.CompareAndSwapInt32(&p.shutDown, 0, 1) {
+	if !atomic.CompareAndSwapInt32(&p.shutDown, poolRunning, poolShutdown) {
 		return false
 	}
-	// 唤醒所有等待中的 worker, 让它们检查 shutdown 标记并参与排空队列
-	works := atomic.LoadInt32(&p.works)
-	for i := int32(0); i < works; i++ {
-		p.taskQueue.Enqueue(shutdownSentinel)
-	}
-	// 等待所有 worker 退出
+	ref := p.ref()
+	// 关闭队列: 此后提交失败, 等待中的 worker 排空残留任务后收到关闭通知退出
+	ref.close()
 	p.wg.Wait()
-	// 循环排空残留任务: dispatch 竞态可能导致任务在所有 worker 退出后入队
-	// 已接受(CtxGo 返回 nil)的任务必须被执行, 循环直到队列稳定为空
-	for p.drainQueue() > 0 {
-	}
+	p.drainQueue(ref)
 	return true
 }
 
+// ShutdownCtx 关闭协程池, 与 Shutdown 相同但最多等待到 ctx 取消
+// ctx 取消时放弃队列中尚未开始执行的任务并返回放弃的任务数与 ctx.Err(), 正在执行的任务不受影响
+// 协程池未在运行时返回 ErrPoolClosed
+func (p *GoPool) ShutdownCtx(ctx context.Context) (abandoned int, err error) {
+	if !atomic.CompareAndSwapInt32(&p.shutDown, poolRunning, poolShutdown) {
+		return 0, ErrPoolClosed
+	}
+	ref := p.ref()
+	ref.close()
+	select {
+	case <-p.workersDone():
+		p.drainQueue(ref)
+		return 0, nil
+	case <-ctx.Done():
+		return p.abandonQueue(ref, ErrPoolClosed), ctx.Err()
+	}
+}
+
+// workersDone 返回所有 worker 退出后关闭的 channel
+// 超时返回后等待协程仍在 wg.Wait 中, 复用同一个等待协程, 保证之后的 wg.Add 发生在其 Wait 返回之后
+func (p *GoPool) workersDone() <-chan struct{} {
+	p.waitMu.Lock()
+	defer p.waitMu.Unlock()
+	if p.waitCh != nil {
+		select {
+		case <-p.waitCh:
+		default:
+			return p.waitCh
+		}
+	}
+	ch := make(chan struct{})
+	p.waitCh = ch
+	go func() {
+		p.wg.Wait()
+		close(ch)
+	}()
+	return ch
+}
+
+// abandonQueue 丢弃已关闭队列中的残留任务, 返回丢弃的任务数
+func (p *GoPool) abandonQueue(ref *taskQueueRef, reason error) int {
+	count := 0
+	if ref.ws != nil {
+		for _, r := range ref.ws.loadRings() {
+			for t := r.pop(&ref.ws.pending); t != nil; t = r.pop(&ref.ws.pending) {
+				p.discardTask(t, reason)
+				count++
+			}
+		}
+	}
+	for {
+		t, ok := ref.Dequeue()
+		if !ok {
+			return count
+		}
+		p.discardTask(t, reason)
+		count++
+	}
+}
+
 // Restart 重启协程池, 等待所有旧 worker 退出后再返回
 // 注意: 超时返回 false 时, 后台 goroutine 仍会等待 wg.Wait() 完成
 // 这是 sync.WaitGroup 的设计限制, 无法中断等待
 // 调用方应确保 Shutdown 真正完成后再调用 Restart, 或接受超时后 goroutine 继续运行
 func (p *GoPool) Restart() bool {
-	if atomic.LoadInt32(&p.shutDown) != 1 {
+	if !atomic.CompareAndSwapInt32(&p.shutDown, poolShutdown, poolRestarting) {
 		return false
 	}
 	// 等待所有 worker 退出(依赖 Shutdown 的 wg.Wait 保证)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
-	done := make(chan struct{})
-	go func() {
-		p.wg.Wait()
-		close(done)
-	}()
 	select {
-	case <-done:
-		// 清理队列残留
-		p.drainQueue()
-		return atomic.CompareAndSwapInt32(&p.shutDown, 1, 0)
+	case <-p.workersDone():
+		// 清理旧队列残留, 已关闭的队列无法重新打开, 替换为新队列
+		p.drainQueue(p.ref())
+		atomic.StorePointer(&p.taskQueue, unsafe.Pointer(p.newTaskQueueRef()))
+		atomic.StoreInt32(&p.shutDown, poolRunning)
+		return true
 	case <-ctx.Done():
 		// 超时: goroutine 仍会等待 wg.Wait() 完成, 无法中断
 		// 设计限制: sync.WaitGroup.Wait 不支持 context 取消
+		atomic.StoreInt32(&p.shutDown, poolShutdown)
 		return false
 	}
 }
@@ -130,82 +251,271 @@ func (p *GoPool) Go(f func()) error {
 }
 
 func (p *GoPool) CtxGo(ctx context.Context, f func()) error {
-	if atomic.LoadInt32(&p.shutDown) == 1 {
+	return p.ctxGo(ctx, f, nil)
+}
+
+// CtxGoFunc 提交接收 ctx 并返回错误的任务, 返回的非nil错误交给 WithErrorHandler 设置的处理函数
+func (p *GoPool) CtxGoFunc(ctx context.Context, f func(ctx context.Context) error) error {
+	return p.CtxGo(ctx, func() {
+		if err := f(ctx); err != nil && p.errorHandler != nil {
+			p.errorHandler(err, ctx)
+		}
+	})
+}
+
+// ctxGo 提交任务, discard 在已接受的任务未执行就被丢弃时调用, 参数为丢弃原因
+func (p *GoPool) ctxGo(ctx context.Context, f func(), discard func(error)) error {
+	if atomic.LoadInt32(&p.shutDown) != poolRunning {
 		return ErrPoolClosed
 	}
+	if p.tenants != nil {
+		if tenant, ok := TenantFromContext(ctx); ok {
+			// 租户进行中的任务达到上限时阻塞提交方, 任务结束后归还许可
+			release, err := p.tenants.Acquire(ctx, tenant)
+			if err != nil {
+				return err
+			}
+			fn, d := f, discard
+			f = func() {
+				defer release()
+				fn()
+			}
+			discard = func(err error) {
+				release()
+				if d != nil {
+					d(err)
+				}
+			}
+			err = p.submit(ctx, f, discard)
+			if consumed, ok := err.(taskConsumedError); ok {
+				// 任务已被接受, 许可由任务结束时归还
+				return consumed.err
+			}
+			if err != nil {
+				release()
+			}
+			return err
+		}
+	}
+	err := p.submit(ctx, f, discard)
+	if consumed, ok := err.(taskConsumedError); ok {
+		return consumed.err
+	}
+	return err
+}
+
+// submit 提交任务, 拒绝策略已处理任务但仍返回错误时返回 taskConsumedError
+func (p *GoPool) submit(ctx context.Context, f func(), discard func(error)) error {
 	t := taskPool.Get()
 	t.fn = f
 	t.ctx = ctx
-	p.dispatch(t)
+	t.discard = discard
+	if p.stats.timing {
+		t.submitted = time.Now()
+	}
+	p.stats.submitted.IncrementSimple()
+	if err := p.dispatch(t); err != nil {
+		if _, ok := err.(taskConsumedError); ok {
+			// 拒绝策略已执行或入队了任务, 任务不能再回收, 也不计入被拒绝任务数
+			return err
+		}
+		taskPool.Put(t)
+		if errors.Is(err, concurrent.ErrQueueClosed) || err == ErrPoolClosed {
+			// 检查 shutDown 后队列才被关闭, 任务未被接受
+			p.stats.submitted.DecrementSimple()
+			return ErrPoolClosed
+		}
+		p.stats.rejected.IncrementSimple()
+		return err
+	}
 	return nil
 }
 
-// dispatch 分发任务: 入队并按需创建新 worker
+// dispatch 分发任务: 入队并按需创建新 worker, 队列已关闭时返回 concurrent.ErrQueueClosed
 // 任务入队后优先由 BlockQueue 唤醒空闲 worker, 同时尝试补充 worker 数量到上限内
-func (p *GoPool) dispatch(t *task) {
-	p.taskQueue.Enqueue(t)
-	// 入队后二次检查: 若此时已 shutdown, 补发 sentinel 确保 worker 能消费残存任务
-	if atomic.LoadInt32(&p.shutDown) == 1 {
-		p.taskQueue.Enqueue(shutdownSentinel)
-		return
+func (p *GoPool) dispatch(t *task) error {
+	ref := p.ref()
+	if ref.ws != nil {
+		return p.dispatchStealing(ref, t)
 	}
+	if err := p.offer(ref, t); err != nil {
+		return err
+	}
+	q := ref.ClosableQueue
 	// 有 worker 在 BlockQueue 上阻塞等待时, Enqueue 已通过 Signal 唤醒它, 无需创建新 worker
 	// waiter 在 mu 锁下维护, waiter > 0 严格意味着 worker 在 cond.Wait 中, 不存在"即将退出"的竞态窗口
-	if p.taskQueue.WaiterCount() > 0 {
+	if q.WaiterCount() > 0 {
+		return nil
+	}
+	p.tryAddWorker(ref)
+	return nil
+}
+
+// offer 任务入队, 有界队列已满时交给拒绝策略处理, 已关闭时返回 ErrPoolClosed
+func (p *GoPool) offer(ref *taskQueueRef, t *task) error {
+	if bq, ok := ref.ClosableQueue.(concurrent.BoundedQueue[*task]); ok {
+		if !bq.TryEnqueue(t) {
+			// 队列已关闭而非已满: 不再创建 worker(Shutdown 可能已在 wg.Wait 中), 也不交给拒绝策略
+			if bq.Closed() {
+				return ErrPoolClosed
+			}
+			// 有界队列已满: 先确保有 worker 消费, 再执行拒绝策略, 避免 worker 全部空闲退出后生产者永久阻塞
+			p.tryAddWorker(ref)
+			policy := p.rejectPolicy
+			if policy == nil {
+				policy = RejectBlock(0)
+			}
+			r := &Rejection{Ctx: t.ctx, p: p, q: bq, t: t}
+			if err := policy(r); err != nil {
+				if r.consumed {
+					return taskConsumedError{err: err}
+				}
+				return err
+			}
+			return nil
+		}
+		return nil
+	}
+	return ref.Offer(t)
+}
+
+// tryAddWorker worker 数量未达上限时创建一个消费 ref 的新 worker
+func (p *GoPool) tryAddWorker(ref *taskQueueRef) {
+	if !p.reserveWorker() {
 		return
 	}
-	// maxWorks 构造后不可变, 直接读避免 atomic 开销
-	maxW := p.maxWorks
+	p.wg.Add(1)
+	if ref.ws != nil {
+		go p.goRunStealing(ref)
+	} else {
+		go p.goRun(ref.ClosableQueue)
+	}
+}
+
+// reserveWorker worker 数量未达上限时占用一个名额
+func (p *GoPool) reserveWorker() bool {
 	for {
 		w := atomic.LoadInt32(&p.works)
-		if w >= maxW {
-			return
+		if w >= atomic.LoadInt32(&p.maxWorks) {
+			return false
 		}
 		if atomic.CompareAndSwapInt32(&p.works, w, w+1) {
-			p.wg.Add(1)
-			go p.goRun(nil)
-			return
+			p.stats.updatePeak(w + 1)
+			return true
+		}
+	}
+}
+
+// retire 空闲超时的 worker 尝试退出, worker 数量不超过最小空闲数时返回 false
+func (p *GoPool) retire() bool {
+	for {
+		w := atomic.LoadInt32(&p.works)
+		if w <= atomic.LoadInt32(&p.minIdle) {
+			return false
+		}
+		if atomic.CompareAndSwapInt32(&p.works, w, w-1) {
+			return true
+		}
+	}
+}
+
+// shrink worker 数量超过 SetMaxWorks 调小后的上限时, 执行完任务的 worker 退出
+func (p *GoPool) shrink() bool {
+	for {
+		w := atomic.LoadInt32(&p.works)
+		if w <= atomic.LoadInt32(&p.maxWorks) {
+			return false
+		}
+		if atomic.CompareAndSwapInt32(&p.works, w, w-1) {
+			return true
 		}
 	}
 }
 
 // goRun worker 主循环, 空闲时阻塞在 BlockQueue 上
-func (p *GoPool) goRun(t *task) {
+// worker 名额在退出前归还
+func (p *GoPool) goRun(q concurrent.ClosableQueue[*task]) {
 	defer p.wg.Done()
-	defer atomic.AddInt32(&p.works, -1)
 	for {
-		// 先执行手头任务
-		if t != nil {
-			p.executeTask(t)
-			t = nil
+		t, ok := q.DequeueBlock(p.idleTimeout)
+		if !ok {
+			if q.Closed() {
+				// 队列关闭且排空
+				atomic.AddInt32(&p.works, -1)
+				return
+			}
+			// 空闲超时, 保留最小空闲 worker
+			if !p.retire() {
+				continue
+			}
+			// 先归还名额再检查队列, 提交方看到 worker 已满未创建新 worker 时由本 worker 继续处理
+			if q.Size() > 0 && p.reserveWorker() {
+				continue
+			}
+			return
 		}
-		// 阻塞等待下一个任务
-		t, _ = p.taskQueue.DequeueBlock(p.idleTimeout)
-		// 空闲超时或收到关闭哨兵后退出
-		if t == nil || t == shutdownSentinel {
+		p.executeTask(t)
+		if p.shrink() {
 			return
 		}
 	}
 }
 
-// executeTask 执行单个任务, 处理 panic
+// executeTask 执行单个任务, 处理 panic; ctx 已取消的任务不执行
 func (p *GoPool) executeTask(t *task) {
+	if t.ctx != nil && t.ctx.Err() != nil {
+		p.skipTask(t)
+		return
+	}
+	goid := concurrent.GoID()
+	var start time.Time
+	if p.stats.timing {
+		start = time.Now()
+		p.stats.waitTime.RecordWithGoid(goid, int64(start.Sub(t.submitted)))
+	}
 	defer func() {
 		if a := recover(); a != nil {
+			p.stats.panicked.Increment(goid)
 			if p.panicHandler != nil {
 				p.panicHandler(a, t.ctx)
 			}
 		}
-		if t != shutdownSentinel {
-			taskPool.Put(t)
+		if p.stats.timing {
+			p.stats.execTime.RecordWithGoid(goid, int64(time.Since(start)))
 		}
+		p.stats.completed.Increment(goid)
+		taskPool.Put(t)
 	}()
 	t.fn()
 }
 
+// discardTask 丢弃已接受但未执行的任务, 计入被拒绝任务数
+func (p *GoPool) discardTask(t *task, reason error) {
+	p.stats.rejected.IncrementSimple()
+	if t.discard != nil {
+		t.discard(reason)
+	}
+	taskPool.Put(t)
+}
+
+// skipTask 跳过排队期间 ctx 已取消的任务
+func (p *GoPool) skipTask(t *task) {
+	p.stats.skipped.IncrementSimple()
+	if t.discard != nil {
+		t.discard(t.ctx.Err())
+	}
+	if p.skipHandler != nil {
+		p.skipHandler(t.ctx)
+	}
+	taskPool.Put(t)
+}
+
 type task struct {
 	fn  func()
 	ctx context.Context
+	// discard 任务未执行就被丢弃时调用, 用于归还租户许可或通知 Future
+	discard   func(error)
+	submitted time.Time
 }
 
 // NewGopool 创建一个协程池
@@ -213,13 +523,16 @@ func NewGopool(options ...Option) *GoPool {
 	gopool := &GoPool{
 		maxWorks:    defaultMaxWorks,
 		idleTimeout: defaultIdleTimeout,
-		taskQueue: concurrent.BlockQueueWrapper(
-			concurrent.NewQueue(concurrent.WithTypeSegment[*task]()),
-		),
+		newQueue: func() concurrent.ClosableQueue[*task] {
+			return concurrent.ClosableQueueWrapper(
+				concurrent.NewQueue(concurrent.WithTypeSegment[*task]()),
+			)
+		},
 	}
 	for _, option := range options {
 		option(gopool)
 	}
+	gopool.taskQueue = unsafe.Pointer(gopool.newTaskQueueRef())
 	return gopool
 }
 
@@ -231,6 +544,20 @@ func WithPanicHandler(handler func(any, context.Context)) Option {
 	}
 }
 
+// WithErrorHandler 设置 CtxGoFunc 任务返回错误时的处理函数
+func WithErrorHandler(handler func(error, context.Context)) Option {
+	return func(gopool *GoPool) {
+		gopool.errorHandler = handler
+	}
+}
+
+// WithSkipHandler 设置任务因排队期间 ctx 已取消而被跳过时的回调
+func WithSkipHandler(handler func(ctx context.Context)) Option {
+	return func(gopool *GoPool) {
+		gopool.skipHandler = handler
+	}
+}
+
 func WithName(name string) Option {
 	return func(gopool *GoPool) {
 		gopool.name = name
@@ -246,7 +573,51 @@ func WithIdleTimeout(d time.Duration) Option {
 	}
 }
 
-// WithMaxWorks 设置最大 worker 数量, 默认 1024
+// WithQueueCapacity 使用有界任务队列, 队列满时按 WithRejectPolicy 设置的策略处理,
+// 默认为 RejectBlock(0), Go/CtxGo 阻塞直到有空位(生产者背压)
+// 默认为无界分段队列, capacity 会被向上取整到最近的2的幂
+func WithQueueCapacity(capacity int) Option {
+	return func(gopool *GoPool) {
+		if capacity > 0 {
+			gopool.newQueue = func() concurrent.ClosableQueue[*task] {
+				return concurrent.NewBoundedQueue[*task](capacity)
+			}
+		}
+	}
+}
+
+// WithTenantLimit 按租户限制进行中(已提交未完成)的任务数, 达到上限时 CtxGo 阻塞直到有任务完成或 ctx 取消
+// 租户通过 WithTenant 写入 ctx, 未携带租户的任务不受限制
+func WithTenantLimit(limit int) Option {
+	return func(gopool *GoPool) {
+		if limit > 0 {
+			gopool.tenants = concurrent.NewBulkhead(int64(limit))
+		}
+	}
+}
+
+type tenantKey struct{}
+
+// WithTenant 返回携带租户标识的 ctx, 配合 WithTenantLimit 使用
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext 获取 ctx 中的租户标识
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok
+}
+
+// TenantInFlight 返回租户进行中的任务数, 未设置 WithTenantLimit 时恒为0
+func (p *GoPool) TenantInFlight(tenant string) int64 {
+	if p.tenants == nil {
+		return 0
+	}
+	return p.tenants.Semaphore(tenant).InUse()
+}
+
+// WithMaxWorks 设置最大 worker 数量, 默认 1024, 运行时可通过 SetMaxWorks 调整
 func WithMaxWorks(n int) Option {
 	return func(gopool *GoPool) {
 		if n > 0 {
@@ -254,3 +625,46 @@ func WithMaxWorks(n int) Option {
 		}
 	}
 }
+
+// WithMinIdleWorks 设置最小空闲 worker 数量, worker 数量不超过该值时空闲超时也不退出, 默认0
+// worker 仍按需创建, 不会预先启动
+func WithMinIdleWorks(n int) Option {
+	return func(gopool *GoPool) {
+		if n > 0 {
+			gopool.minIdle = int32(n)
+		}
+	}
+}
+
+// WithTaskTiming 统计任务排队耗时与执行耗时的分布, 通过 Stats 获取
+// 每个任务需要额外读取三次时钟, 默认关闭
+func WithTaskTiming() Option {
+	return func(gopool *GoPool) {
+		gopool.stats.enableTiming()
+	}
+}
+
+// WithRejectPolicy 设置有界队列(WithQueueCapacity)已满时的拒绝策略, 默认 RejectBlock(0)
+func WithRejectPolicy(policy RejectPolicy) Option {
+	return func(gopool *GoPool) {
+		gopool.rejectPolicy = policy
+	}
+}
+
+// MaxWorks 获取最大 worker 数量
+func (p *GoPool) MaxWorks() int {
+	return int(atomic.LoadInt32(&p.maxWorks))
+}
+
+// SetMaxWorks 运行时调整最大 worker 数量, n<=0 时忽略
+// 调大时立即为排队中的任务创建 worker; 调小时多余的 worker 执行完当前任务后退出
+func (p *GoPool) SetMaxWorks(n int) {
+	if n <= 0 {
+		return
+	}
+	atomic.StoreInt32(&p.maxWorks, int32(n))
+	ref := p.ref()
+	for pending := p.TaskCount(); pending > 0 && atomic.LoadInt32(&p.works) < int32(n); pending-- {
+		p.tryAddWorker(ref)
+	}
+}
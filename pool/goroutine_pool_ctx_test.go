@@ -0,0 +1,121 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mzzsfy/go-util/pool"
+)
+
+func Test_GoPool_SkipCancelled(t *testing.T) {
+	t.Parallel()
+	var skipped int32
+	p := pool.NewGopool(pool.WithMaxWorks(1), pool.WithSkipHandler(func(ctx context.Context) {
+		if ctx.Err() == nil {
+			t.Error("跳过的任务ctx应已取消")
+		}
+		atomic.AddInt32(&skipped, 1)
+	}))
+	block := make(chan struct{})
+	started := make(chan struct{})
+	_ = p.Go(func() {
+		close(started)
+		<-block
+	})
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var ran int32
+	if err := p.CtxGo(ctx, func() { atomic.AddInt32(&ran, 1) }); err != nil {
+		t.Fatal(err)
+	}
+	f := pool.SubmitCtx(ctx, p, func() (int, error) { return 1, nil })
+	cancel()
+	close(block)
+	if _, err := f.Get(context.Background()); err != context.Canceled {
+		t.Fatalf("err=%v", err)
+	}
+	p.Shutdown()
+	if ran != 0 || skipped != 2 {
+		t.Fatalf("ran=%d skipped=%d", ran, skipped)
+	}
+	if s := p.Stats(); s.Skipped != 2 || s.Completed != 1 {
+		t.Fatalf("stats=%+v", s)
+	}
+}
+
+func Test_GoPool_CtxGoFunc(t *testing.T) {
+	t.Parallel()
+	type ctxKey struct{}
+	errFoo := errors.New("foo")
+	got := make(chan any, 1)
+	p := pool.NewGopool(pool.WithErrorHandler(func(err error, ctx context.Context) {
+		if err != errFoo {
+			t.Errorf("err=%v", err)
+		}
+		got <- ctx.Value(ctxKey{})
+	}))
+	defer p.Shutdown()
+	ctx := context.WithValue(context.Background(), ctxKey{}, "v")
+	if err := p.CtxGoFunc(ctx, func(ctx context.Context) error {
+		if ctx.Value(ctxKey{}) != "v" {
+			t.Error("任务未收到ctx")
+		}
+		return errFoo
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-got; v != "v" {
+		t.Fatalf("v=%v", v)
+	}
+}
+
+func Test_GoPool_ShutdownCtx(t *testing.T) {
+	t.Parallel()
+	for _, ws := range []bool{false, true} {
+		opts := []pool.Option{pool.WithMaxWorks(1)}
+		if ws {
+			opts = append(opts, pool.WithWorkStealing())
+		}
+		p := pool.NewGopool(opts...)
+		block := make(chan struct{})
+		started := make(chan struct{})
+		_ = p.Go(func() {
+			close(started)
+			<-block
+		})
+		<-started
+		var ran int32
+		for i := 0; i < 5; i++ {
+			_ = p.Go(func() { atomic.AddInt32(&ran, 1) })
+		}
+		f := pool.Submit(p, func() (int, error) { return 1, nil })
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		abandoned, err := p.ShutdownCtx(ctx)
+		cancel()
+		if abandoned != 6 || err != context.DeadlineExceeded {
+			t.Fatalf("ws=%v abandoned=%d err=%v", ws, abandoned, err)
+		}
+		if _, err = f.Get(context.Background()); err != pool.ErrPoolClosed {
+			t.Fatalf("ws=%v err=%v", ws, err)
+		}
+		close(block)
+		if _, err = p.ShutdownCtx(context.Background()); err != pool.ErrPoolClosed {
+			t.Fatalf("ws=%v err=%v", ws, err)
+		}
+		if !p.Restart() {
+			t.Fatalf("ws=%v Restart失败", ws)
+		}
+		_ = p.Go(func() { atomic.AddInt32(&ran, 1) })
+		if abandoned, err = p.ShutdownCtx(context.Background()); abandoned != 0 || err != nil {
+			t.Fatalf("ws=%v abandoned=%d err=%v", ws, abandoned, err)
+		}
+		if ran != 1 {
+			t.Fatalf("ws=%v ran=%d", ws, ran)
+		}
+	}
+}
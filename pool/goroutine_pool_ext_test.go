@@ -188,3 +188,109 @@ func TestGoPool_WithMaxWorks_ZeroOrNegative(t *testing.T) {
 		})
 	}
 }
+
+// TestGoPool_WithQueueCapacity_BackPressure 验证有界队列满时提交任务阻塞, 且所有任务最终执行
+func TestGoPool_WithQueueCapacity_BackPressure(t *testing.T) {
+	t.Parallel()
+
+	p := pool.NewGopool(pool.WithMaxWorks(1), pool.WithQueueCapacity(2), pool.WithIdleTimeout(50*time.Millisecond))
+
+	block := make(chan struct{})
+	var executed int32
+	// 第一个任务占住唯一的 worker, 后续两个任务填满队列
+	for i := 0; i < 3; i++ {
+		if err := p.Go(func() {
+			<-block
+			atomic.AddInt32(&executed, 1)
+		}); err != nil {
+			t.Fatalf("提交任务失败: %v", err)
+		}
+	}
+
+	submitted := make(chan struct{})
+	go func() {
+		_ = p.Go(func() { atomic.AddInt32(&executed, 1) })
+		close(submitted)
+	}()
+	select {
+	case <-submitted:
+		t.Fatal("队列已满时提交应阻塞")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(block)
+	select {
+	case <-submitted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("队列腾出空位后提交应返回")
+	}
+	p.Shutdown()
+	if got := atomic.LoadInt32(&executed); got != 4 {
+		t.Fatalf("期望执行 4 个任务, 实际 %d", got)
+	}
+}
+
+// TestGoPool_TenantLimit 验证 WithTenantLimit 限制同一租户的进行中任务数
+func TestGoPool_TenantLimit(t *testing.T) {
+	t.Parallel()
+
+	p := pool.NewGopool(pool.WithTenantLimit(2), pool.WithMaxWorks(8))
+	defer p.Shutdown()
+
+	var running, maxRunning int32
+	var wg sync.WaitGroup
+	ctx := pool.WithTenant(context.Background(), "a")
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		err := p.CtxGo(ctx, func() {
+			defer wg.Done()
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 其他租户不受租户a的限制
+	done := make(chan struct{})
+	if err := p.CtxGo(pool.WithTenant(context.Background(), "b"), func() { close(done) }); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	wg.Wait()
+	if maxRunning > 2 {
+		t.Fatalf("maxRunning=%d", maxRunning)
+	}
+	if n := p.TenantInFlight("a"); n != 0 {
+		t.Fatalf("TenantInFlight=%d", n)
+	}
+}
+
+// TestGoPool_TenantLimit_Cancel 验证租户达到上限时 CtxGo 随 ctx 取消返回错误
+func TestGoPool_TenantLimit_Cancel(t *testing.T) {
+	t.Parallel()
+
+	p := pool.NewGopool(pool.WithTenantLimit(1))
+	defer p.Shutdown()
+
+	block := make(chan struct{})
+	ctx := pool.WithTenant(context.Background(), "a")
+	if err := p.CtxGo(ctx, func() { <-block }); err != nil {
+		t.Fatal(err)
+	}
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := p.CtxGo(timeout, func() {}); err == nil {
+		t.Fatal("租户已满时应返回错误")
+	}
+	close(block)
+}
@@ -0,0 +1,97 @@
+package pool
+
+import (
+	"sync/atomic"
+
+	"github.com/mzzsfy/go-util/concurrent"
+)
+
+// GoPoolStats 协程池统计快照, 各字段分别读取, 相互之间不保证一致
+type GoPoolStats struct {
+	// Submitted 提交的任务数, 包含被拒绝的任务
+	Submitted int64
+	// Completed 执行完成的任务数, 包含 panic 的任务
+	Completed int64
+	// Rejected 被拒绝策略拒绝或丢弃, 以及 ShutdownCtx 超时放弃的任务数
+	Rejected int64
+	// Skipped 排队期间 ctx 已取消而未执行的任务数
+	Skipped int64
+	// Panicked 执行时 panic 的任务数
+	Panicked int64
+	// Queued 排队中的任务数
+	Queued int
+	// Workers 当前 worker 数量
+	Workers int
+	// IdleWorkers 空闲等待任务的 worker 数量
+	IdleWorkers int
+	// PeakWorkers worker 数量峰值
+	PeakWorkers int
+	// MaxWorks 最大 worker 数量
+	MaxWorks int
+	// WaitTime 任务从提交到开始执行的耗时分布, 单位纳秒, 需要 WithTaskTiming, 未启用时为空
+	WaitTime concurrent.HistogramSnapshot
+	// ExecTime 任务执行耗时分布, 单位纳秒, 需要 WithTaskTiming, 未启用时为空
+	ExecTime concurrent.HistogramSnapshot
+}
+
+type poolStats struct {
+	submitted concurrent.Int64Adder
+	completed concurrent.Int64Adder
+	rejected  concurrent.Int64Adder
+	panicked  concurrent.Int64Adder
+	skipped   concurrent.Int64Adder
+	peak      int32
+	timing    bool
+	// waitTime execTime 仅在 WithTaskTiming 时创建, 避免未启用时为每个协程池分配按CPU分片的直方图
+	waitTime *concurrent.Histogram
+	execTime *concurrent.Histogram
+}
+
+// enableTiming 启用任务耗时统计
+func (s *poolStats) enableTiming() {
+	// 1µs ~ 8s
+	bounds := concurrent.ExponentialBounds(1000, 2, 24)
+	s.timing = true
+	s.waitTime = concurrent.NewHistogram(bounds...)
+	s.execTime = concurrent.NewHistogram(bounds...)
+}
+
+// histogramSnapshot 返回直方图快照, 未启用耗时统计时返回空快照
+func histogramSnapshot(h *concurrent.Histogram) concurrent.HistogramSnapshot {
+	if h == nil {
+		return concurrent.HistogramSnapshot{}
+	}
+	return h.Snapshot()
+}
+
+func (s *poolStats) updatePeak(works int32) {
+	for {
+		peak := atomic.LoadInt32(&s.peak)
+		if works <= peak || atomic.CompareAndSwapInt32(&s.peak, peak, works) {
+			return
+		}
+	}
+}
+
+// Stats 获取协程池统计快照
+func (p *GoPool) Stats() GoPoolStats {
+	ref := p.ref()
+	idle := int(ref.WaiterCount())
+	if ref.ws != nil {
+		idle = int(atomic.LoadInt32(&ref.ws.idle))
+	}
+	return GoPoolStats{
+		Submitted:   p.stats.submitted.Sum(),
+		Completed:   p.stats.completed.Sum(),
+		Rejected:    p.stats.rejected.Sum(),
+		Panicked:    p.stats.panicked.Sum(),
+		Skipped:     p.stats.skipped.Sum(),
+		Queued:      int(p.TaskCount()),
+		Workers:     int(atomic.LoadInt32(&p.works)),
+		IdleWorkers: idle,
+		PeakWorkers: int(atomic.LoadInt32(&p.stats.peak)),
+		MaxWorks:    p.MaxWorks(),
+		WaitTime:    histogramSnapshot(p.stats.waitTime),
+		ExecTime:    histogramSnapshot(p.stats.execTime),
+	}
+}
@@ -0,0 +1,135 @@
+package pool_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mzzsfy/go-util/pool"
+)
+
+func Test_GoPool_Stats(t *testing.T) {
+	t.Parallel()
+	p := pool.NewGopool(pool.WithTaskTiming(), pool.WithPanicHandler(nil))
+	const n = 100
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		i := i
+		_ = p.Go(func() {
+			defer wg.Done()
+			time.Sleep(time.Millisecond)
+			if i == 0 {
+				panic("boom")
+			}
+		})
+	}
+	wg.Wait()
+	p.Shutdown()
+	s := p.Stats()
+	if s.Submitted != n || s.Completed != n || s.Panicked != 1 || s.Rejected != 0 || s.Queued != 0 {
+		t.Fatalf("stats=%+v", s)
+	}
+	if s.PeakWorkers < 1 || s.PeakWorkers > s.MaxWorks {
+		t.Fatalf("PeakWorkers=%d", s.PeakWorkers)
+	}
+	if s.ExecTime.Count != n || s.WaitTime.Count != n || s.ExecTime.Min < int64(time.Millisecond) {
+		t.Fatalf("exec=%+v wait=%+v", s.ExecTime, s.WaitTime)
+	}
+}
+
+func Test_GoPool_StatsWithoutTiming(t *testing.T) {
+	t.Parallel()
+	p := pool.NewGopool()
+	done := make(chan struct{})
+	_ = p.Go(func() { close(done) })
+	<-done
+	p.Shutdown()
+	s := p.Stats()
+	if s.Completed != 1 || s.ExecTime.Count != 0 || s.WaitTime.Counts != nil || s.ExecTime.Percentile(0.99) != 0 {
+		t.Fatalf("stats=%+v", s)
+	}
+}
+
+func Test_GoPool_SetMaxWorks(t *testing.T) {
+	t.Parallel()
+	for _, ws := range []bool{false, true} {
+		opts := []pool.Option{pool.WithMaxWorks(1), pool.WithIdleTimeout(time.Hour)}
+		if ws {
+			opts = append(opts, pool.WithWorkStealing())
+		}
+		p := pool.NewGopool(opts...)
+		block := make(chan struct{})
+		var started sync.WaitGroup
+		started.Add(4)
+		for i := 0; i < 4; i++ {
+			_ = p.Go(func() {
+				started.Done()
+				<-block
+			})
+		}
+		time.Sleep(5 * time.Millisecond)
+		if p.WorkerCount() != 1 {
+			t.Fatalf("ws=%v WorkerCount=%d", ws, p.WorkerCount())
+		}
+		// 调大后为排队任务创建 worker
+		p.SetMaxWorks(4)
+		started.Wait()
+		if p.WorkerCount() != 4 || p.MaxWorks() != 4 {
+			t.Fatalf("ws=%v WorkerCount=%d", ws, p.WorkerCount())
+		}
+		// 调小后多余的 worker 执行完任务退出
+		p.SetMaxWorks(2)
+		close(block)
+		waitWorkers(t, p, 2)
+		if s := p.Stats(); s.PeakWorkers != 4 {
+			t.Fatalf("ws=%v PeakWorkers=%d", ws, s.PeakWorkers)
+		}
+		p.Shutdown()
+	}
+}
+
+func Test_GoPool_MinIdleWorks(t *testing.T) {
+	t.Parallel()
+	for _, ws := range []bool{false, true} {
+		opts := []pool.Option{pool.WithMinIdleWorks(2), pool.WithIdleTimeout(5 * time.Millisecond)}
+		if ws {
+			opts = append(opts, pool.WithWorkStealing())
+		}
+		p := pool.NewGopool(opts...)
+		block := make(chan struct{})
+		var started sync.WaitGroup
+		started.Add(4)
+		for i := 0; i < 4; i++ {
+			_ = p.Go(func() {
+				started.Done()
+				<-block
+			})
+		}
+		started.Wait()
+		close(block)
+		waitWorkers(t, p, 2)
+		time.Sleep(20 * time.Millisecond)
+		if p.WorkerCount() != 2 {
+			t.Fatalf("ws=%v WorkerCount=%d", ws, p.WorkerCount())
+		}
+		// 保留的 worker 仍能执行任务
+		done := make(chan struct{})
+		_ = p.Go(func() { close(done) })
+		<-done
+		p.Shutdown()
+		if p.WorkerCount() != 0 {
+			t.Fatalf("ws=%v 关闭后 WorkerCount=%d", ws, p.WorkerCount())
+		}
+	}
+}
+
+func waitWorkers(t *testing.T, p *pool.GoPool, n uint64) {
+	t.Helper()
+	for i := 0; p.WorkerCount() != n; i++ {
+		if i > 1000 {
+			t.Fatalf("WorkerCount=%d, want %d", p.WorkerCount(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,273 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mzzsfy/go-util/concurrent"
+	"github.com/mzzsfy/go-util/unsafe"
+)
+
+const (
+	// orderedShards lane 映射的分片数
+	orderedShards = 32
+	// orderedBatch lane 连续执行多少个任务后重新提交到协程池, 避免热点 key 长期占用同一个 worker
+	orderedBatch = 64
+)
+
+// ErrExecutorClosed 向已关闭的 OrderedExecutor 提交任务时返回此错误
+var ErrExecutorClosed = errors.New("executor is closed")
+
+// OrderedExecutor 按key串行执行任务: 相同key的任务严格按提交顺序执行, 不同key的任务在协程池上并行执行
+//
+// 每个有任务的key对应一个轻量的串行lane, lane中的任务由一个协程池任务依次执行,
+// 任务执行完后lane被回收, 空闲key不占用内存
+//
+// 注意: 任务中向同一个key提交任务时, 若该key排队已满会因等待自身而死锁
+// 协程池丢弃lane的执行任务时(如 RejectDiscardOldest 或 ShutdownCtx 放弃队列), lane中尚未执行的任务一并被丢弃
+//
+// 示例:
+//
+//	e := NewOrderedExecutor[string](pool, 1024)
+//	_ = e.Go(accountId, func() { handle(event) })
+//	_ = e.Flush(ctx, accountId) // 等待该账户已提交的事件处理完成
+//	e.Close()
+type OrderedExecutor[K comparable] struct {
+	p       *GoPool
+	laneCap int
+	hasher  unsafe.Hasher[K]
+	shards  [orderedShards]orderedShard[K]
+	closed  int32
+	// lanes 活跃lane数量, Close 等待其归零
+	lanes sync.WaitGroup
+}
+
+type orderedShard[K comparable] struct {
+	mu    sync.Mutex
+	lanes map[K]*orderedLane[K]
+	_     [unsafe.CachePaddingLength]byte
+}
+
+type orderedTask struct {
+	ctx context.Context
+	fn  func()
+}
+
+// orderedLane 单个key的任务队列, 在map中时一定有任务待执行或正在执行
+type orderedLane[K comparable] struct {
+	key   K
+	shard *orderedShard[K]
+	tasks []orderedTask
+	head  int
+	// space 队列已满时等待空位的提交方, 有任务出队或执行器关闭时关闭
+	space chan struct{}
+}
+
+func (l *orderedLane[K]) size() int {
+	return len(l.tasks) - l.head
+}
+
+// NewOrderedExecutor 创建按key串行的执行器, p 为 nil 时使用默认协程池
+// laneCap 为每个key排队任务数的上限, 达到上限时 Submit 阻塞(背压), <=0 表示不限制
+func NewOrderedExecutor[K comparable](p *GoPool, laneCap int) *OrderedExecutor[K] {
+	if p == nil {
+		p = defaultGoPool
+	}
+	e := &OrderedExecutor[K]{
+		p:       p,
+		laneCap: laneCap,
+		hasher:  unsafe.NewHasher[K](),
+	}
+	for i := range e.shards {
+		e.shards[i].lanes = map[K]*orderedLane[K]{}
+	}
+	return e
+}
+
+func (e *OrderedExecutor[K]) shard(key K) *orderedShard[K] {
+	return &e.shards[e.hasher.Hash(key)&(orderedShards-1)]
+}
+
+// Go 提交任务, 同 Submit(context.Background(), key, f)
+func (e *OrderedExecutor[K]) Go(key K, f func()) error {
+	return e.Submit(context.Background(), key, f)
+}
+
+// Submit 提交任务, 相同key的任务按提交顺序执行
+// key排队任务数达到上限时阻塞直到有空位或 ctx 取消; 执行前 ctx 已取消的任务被跳过
+// 执行器已关闭时返回 ErrExecutorClosed, 协程池已关闭且该key没有其他排队任务时返回 ErrPoolClosed
+func (e *OrderedExecutor[K]) Submit(ctx context.Context, key K, f func()) error {
+	return e.submit(ctx, key, orderedTask{ctx: ctx, fn: f}, true)
+}
+
+// Flush 等待key在调用前已提交的任务全部执行完成, ctx 取消时返回 ctx.Err()
+func (e *OrderedExecutor[K]) Flush(ctx context.Context, key K) error {
+	done := make(chan struct{})
+	// 标记任务不受容量限制, 执行到它时之前的任务均已完成
+	err := e.submit(ctx, key, orderedTask{fn: func() { close(done) }}, false)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *OrderedExecutor[K]) submit(ctx context.Context, key K, t orderedTask, limit bool) error {
+	s := e.shard(key)
+	s.mu.Lock()
+	for {
+		// 在分片锁内检查, 保证 Close 之后不再创建新的 lane
+		if atomic.LoadInt32(&e.closed) == 1 {
+			s.mu.Unlock()
+			return ErrExecutorClosed
+		}
+		l := s.lanes[key]
+		if l == nil {
+			l = &orderedLane[K]{key: key, shard: s}
+			l.tasks = append(l.tasks, t)
+			s.lanes[key] = l
+			e.lanes.Add(1)
+			s.mu.Unlock()
+			if err := e.schedule(l); err != nil {
+				s.mu.Lock()
+				if l.size() == 1 {
+					delete(s.lanes, key)
+					s.mu.Unlock()
+					e.lanes.Done()
+					return err
+				}
+				s.mu.Unlock()
+				// 期间已有其他任务进入该lane并返回成功, 在当前协程执行
+				e.run(l)
+			}
+			return nil
+		}
+		if !limit || e.laneCap <= 0 || l.size() < e.laneCap {
+			l.tasks = append(l.tasks, t)
+			s.mu.Unlock()
+			return nil
+		}
+		if l.space == nil {
+			l.space = make(chan struct{})
+		}
+		space := l.space
+		s.mu.Unlock()
+		select {
+		case <-space:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		s.mu.Lock()
+	}
+}
+
+// schedule 向协程池提交lane的执行任务
+// 执行任务被协程池丢弃(如 RejectDiscardOldest 或 ShutdownCtx 放弃队列)时lane不会再被执行, 由 drop 回收
+func (e *OrderedExecutor[K]) schedule(l *orderedLane[K]) error {
+	return e.p.ctxGo(context.Background(), func() { e.run(l) }, func(error) { e.drop(l) })
+}
+
+// drop 丢弃lane中尚未执行的任务并回收lane, 等待空位的提交方被唤醒后重新创建lane
+func (e *OrderedExecutor[K]) drop(l *orderedLane[K]) {
+	s := l.shard
+	s.mu.Lock()
+	delete(s.lanes, l.key)
+	l.tasks, l.head = nil, 0
+	if l.space != nil {
+		close(l.space)
+		l.space = nil
+	}
+	s.mu.Unlock()
+	e.lanes.Done()
+}
+
+// run 依次执行lane中的任务, 执行完后回收lane
+func (e *OrderedExecutor[K]) run(l *orderedLane[K]) {
+	s := l.shard
+	for n := 0; ; n++ {
+		if n == orderedBatch {
+			n = 0
+			// 让出 worker, 协程池已关闭时继续在当前 worker 执行
+			// 有界队列满时提交可能阻塞 worker, 此时不让出
+			if _, bounded := e.p.queue().(concurrent.BoundedQueue[*task]); !bounded && e.schedule(l) == nil {
+				return
+			}
+		}
+		s.mu.Lock()
+		if l.size() == 0 {
+			delete(s.lanes, l.key)
+			s.mu.Unlock()
+			e.lanes.Done()
+			return
+		}
+		t := l.tasks[l.head]
+		l.tasks[l.head] = orderedTask{}
+		l.head++
+		if l.head == len(l.tasks) {
+			l.tasks, l.head = l.tasks[:0], 0
+		} else if l.head >= 64 && l.head*2 >= len(l.tasks) {
+			// 已出队部分过半时压缩, 避免持续提交时切片无限增长
+			l.tasks, l.head = append(l.tasks[:0], l.tasks[l.head:]...), 0
+		}
+		if l.space != nil {
+			close(l.space)
+			l.space = nil
+		}
+		s.mu.Unlock()
+		e.execute(t)
+	}
+}
+
+// execute 执行单个任务, panic 交给协程池的 panicHandler 处理, 不影响lane中后续任务
+func (e *OrderedExecutor[K]) execute(t orderedTask) {
+	if t.ctx != nil && t.ctx.Err() != nil {
+		if e.p.skipHandler != nil {
+			e.p.skipHandler(t.ctx)
+		}
+		return
+	}
+	defer func() {
+		if a := recover(); a != nil && e.p.panicHandler != nil {
+			e.p.panicHandler(a, t.ctx)
+		}
+	}()
+	t.fn()
+}
+
+// Lanes 返回当前活跃(有任务排队或执行中)的key数量
+func (e *OrderedExecutor[K]) Lanes() int {
+	n := 0
+	for i := range e.shards {
+		s := &e.shards[i]
+		s.mu.Lock()
+		n += len(s.lanes)
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// Close 停止接受新任务并等待已提交的任务全部执行完成
+// 阻塞等待空位的 Submit 被唤醒后返回 ErrExecutorClosed
+func (e *OrderedExecutor[K]) Close() {
+	atomic.StoreInt32(&e.closed, 1)
+	// 依次获取分片锁, 之前已通过检查的 Submit 对 lanes 的 Add 均发生在 Wait 之前
+	// 同时唤醒等待空位的 Submit, 它们重新加锁后看到 closed 返回 ErrExecutorClosed
+	for i := range e.shards {
+		s := &e.shards[i]
+		s.mu.Lock()
+		for _, l := range s.lanes {
+			if l.space != nil {
+				close(l.space)
+				l.space = nil
+			}
+		}
+		s.mu.Unlock()
+	}
+	e.lanes.Wait()
+}
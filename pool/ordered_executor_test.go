@@ -0,0 +1,214 @@
+package pool_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mzzsfy/go-util/pool"
+)
+
+func Test_OrderedExecutor_Order(t *testing.T) {
+	t.Parallel()
+	p := pool.NewGopool(pool.WithMaxWorks(8))
+	defer p.Shutdown()
+	e := pool.NewOrderedExecutor[int](p, 0)
+
+	const keys, n = 20, 300
+	results := make([][]int, keys)
+	var wg sync.WaitGroup
+	for k := 0; k < keys; k++ {
+		k := k
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				i := i
+				if err := e.Go(k, func() {
+					// 同一key的任务串行执行, 无需加锁
+					results[k] = append(results[k], i)
+				}); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	e.Close()
+	for k, r := range results {
+		if len(r) != n {
+			t.Fatalf("key=%d len=%d", k, len(r))
+		}
+		for i, v := range r {
+			if v != i {
+				t.Fatalf("key=%d 顺序错误 %d != %d", k, v, i)
+			}
+		}
+	}
+	if e.Lanes() != 0 {
+		t.Fatalf("lane未回收: %d", e.Lanes())
+	}
+	if err := e.Go(0, func() {}); err != pool.ErrExecutorClosed {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func Test_OrderedExecutor_BackPressure(t *testing.T) {
+	t.Parallel()
+	e := pool.NewOrderedExecutor[string](nil, 2)
+	block := make(chan struct{})
+	started := make(chan struct{})
+	_ = e.Go("a", func() {
+		close(started)
+		<-block
+	})
+	<-started
+	// 第一个任务已出队执行, 队列还能容纳2个
+	for i := 0; i < 2; i++ {
+		if err := e.Go("a", func() {}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := e.Submit(ctx, "a", func() {}); err != context.DeadlineExceeded {
+		t.Fatalf("err=%v", err)
+	}
+	// 其他key不受影响
+	done := make(chan struct{})
+	if err := e.Go("b", func() { close(done) }); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	// 有空位后阻塞的提交方继续
+	errCh := make(chan error, 1)
+	go func() { errCh <- e.Submit(context.Background(), "a", func() {}) }()
+	time.Sleep(5 * time.Millisecond)
+	close(block)
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+	e.Close()
+}
+
+func Test_OrderedExecutor_Flush(t *testing.T) {
+	t.Parallel()
+	var panics int32
+	p := pool.NewGopool(pool.WithPanicHandler(func(any, context.Context) {
+		atomic.AddInt32(&panics, 1)
+	}))
+	defer p.Shutdown()
+	e := pool.NewOrderedExecutor[string](p, 4)
+	var done int32
+	for i := 0; i < 10; i++ {
+		i := i
+		// 容量限制只影响 Submit, Flush 不会因队列已满阻塞
+		_ = e.Go("a", func() {
+			time.Sleep(time.Millisecond)
+			if i == 3 {
+				panic("boom")
+			}
+			atomic.AddInt32(&done, 1)
+		})
+	}
+	if err := e.Flush(context.Background(), "a"); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&done) != 9 || atomic.LoadInt32(&panics) != 1 {
+		t.Fatalf("done=%d panics=%d", done, panics)
+	}
+	// 没有任务的key直接返回
+	if err := e.Flush(context.Background(), "b"); err != nil {
+		t.Fatal(err)
+	}
+
+	block := make(chan struct{})
+	_ = e.Go("a", func() { <-block })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := e.Flush(ctx, "a"); err != context.DeadlineExceeded {
+		t.Fatalf("err=%v", err)
+	}
+	close(block)
+	e.Close()
+}
+
+func Test_OrderedExecutor_CloseWakesSubmit(t *testing.T) {
+	t.Parallel()
+	e := pool.NewOrderedExecutor[string](nil, 1)
+	block := make(chan struct{})
+	started := make(chan struct{})
+	_ = e.Go("a", func() {
+		close(started)
+		<-block
+	})
+	<-started
+	_ = e.Go("a", func() {})
+	errCh := make(chan error, 1)
+	go func() { errCh <- e.Submit(context.Background(), "a", func() {}) }()
+	time.Sleep(5 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		e.Close()
+		close(closed)
+	}()
+	// 执行中的任务仍未结束时, 阻塞的 Submit 已被唤醒
+	select {
+	case err := <-errCh:
+		if err != pool.ErrExecutorClosed {
+			t.Fatalf("err=%v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close 未唤醒阻塞的 Submit")
+	}
+	close(block)
+	<-closed
+}
+
+func Test_OrderedExecutor_RunnerDiscarded(t *testing.T) {
+	t.Parallel()
+	p := pool.NewGopool(pool.WithMaxWorks(1), pool.WithQueueCapacity(2), pool.WithRejectPolicy(pool.RejectDiscardOldest))
+	defer p.Shutdown()
+	block := make(chan struct{})
+	var unblock sync.Once
+	defer unblock.Do(func() { close(block) })
+	started := make(chan struct{})
+	_ = p.Go(func() {
+		close(started)
+		<-block
+	})
+	<-started
+	e := pool.NewOrderedExecutor[string](p, 0)
+	var ran int32
+	// lane a 的执行任务先入队, 随后被挤出有界队列
+	if err := e.Go("a", func() { atomic.AddInt32(&ran, 1) }); err != nil {
+		t.Fatal(err)
+	}
+	_ = p.Go(func() {})
+	_ = p.Go(func() {})
+	if n := e.Lanes(); n != 0 {
+		t.Fatalf("执行任务被丢弃后lane应被回收: %d", n)
+	}
+	unblock.Do(func() { close(block) })
+	// 丢弃后同一个key仍可继续执行
+	if err := e.Flush(context.Background(), "a"); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		e.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close 未返回")
+	}
+	if atomic.LoadInt32(&ran) != 0 {
+		t.Fatalf("ran=%d", ran)
+	}
+}
@@ -0,0 +1,127 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mzzsfy/go-util/concurrent"
+)
+
+// ErrTaskRejected 有界队列已满, 任务被拒绝策略拒绝
+var ErrTaskRejected = errors.New("task rejected")
+
+// RejectPolicy 有界队列已满时的拒绝策略
+// 返回nil表示任务已被处理(入队或已执行), 返回的错误由 Go/CtxGo 返回并计入被拒绝任务数
+// 已通过 Run 或 Offer 处理了任务仍返回错误时, 错误照常返回, 但任务已被接受, 不计入被拒绝任务数
+//
+// 内置策略: RejectAbort, RejectCallerRuns, RejectDiscardOldest, RejectBlock
+type RejectPolicy func(r *Rejection) error
+
+// Rejection 因队列已满而无法入队的任务
+type Rejection struct {
+	// Ctx 提交任务时的 context
+	Ctx context.Context
+	p   *GoPool
+	q   concurrent.BoundedQueue[*task]
+	t   *task
+	// consumed 任务已执行或已入队, 之后归协程池所有, 提交方不能再回收
+	consumed bool
+}
+
+// taskConsumedError 拒绝策略处理了任务后仍返回的错误
+type taskConsumedError struct {
+	err error
+}
+
+func (e taskConsumedError) Error() string {
+	return e.err.Error()
+}
+
+func (e taskConsumedError) Unwrap() error {
+	return e.err
+}
+
+// Pool 返回任务所属的协程池
+func (r *Rejection) Pool() *GoPool {
+	return r.p
+}
+
+// Run 在当前协程执行任务, 计入已完成任务数, panic 交给协程池的 panicHandler 处理
+// 任务已执行或已入队时不再执行
+func (r *Rejection) Run() {
+	if r.consumed {
+		return
+	}
+	r.consumed = true
+	r.p.executeTask(r.t)
+}
+
+// Offer 再次尝试入队
+// timeout 为0时不等待, 大于0时最多等待 timeout, 小于0时一直等待
+// 仍然已满时返回 ErrTaskRejected, 协程池已关闭时返回 ErrPoolClosed, 任务已执行或已入队时直接返回nil
+func (r *Rejection) Offer(timeout time.Duration) error {
+	if r.consumed {
+		return nil
+	}
+	var ok bool
+	switch {
+	case timeout == 0:
+		ok = r.q.TryEnqueue(r.t)
+	case timeout > 0:
+		ok = r.q.EnqueueBlock(r.t, timeout)
+	default:
+		ok = r.q.EnqueueBlock(r.t)
+	}
+	if ok {
+		r.consumed = true
+		return nil
+	}
+	if r.q.Closed() {
+		return ErrPoolClosed
+	}
+	return ErrTaskRejected
+}
+
+// DiscardOldest 丢弃队列中最早的任务, 计入被拒绝任务数, 队列为空时返回 false
+// 启用 WithWorkStealing 时只丢弃全局溢出队列中的任务
+func (r *Rejection) DiscardOldest() bool {
+	old, ok := r.q.TryDequeue()
+	if !ok {
+		return false
+	}
+	r.p.discardTask(old, ErrTaskRejected)
+	return true
+}
+
+// RejectAbort 直接拒绝, Go/CtxGo 返回 ErrTaskRejected
+func RejectAbort(*Rejection) error {
+	return ErrTaskRejected
+}
+
+// RejectCallerRuns 在提交任务的协程中直接执行任务, 同时减缓提交速度
+func RejectCallerRuns(r *Rejection) error {
+	r.Run()
+	return nil
+}
+
+// RejectDiscardOldest 丢弃队列中最早的任务后重新入队
+func RejectDiscardOldest(r *Rejection) error {
+	for {
+		err := r.Offer(0)
+		if err != ErrTaskRejected {
+			return err
+		}
+		r.DiscardOldest()
+	}
+}
+
+// RejectBlock 阻塞等待队列空位, timeout 内仍无空位时返回 ErrTaskRejected, timeout<=0 时一直等待
+func RejectBlock(timeout time.Duration) RejectPolicy {
+	if timeout <= 0 {
+		timeout = -1
+	}
+	return func(r *Rejection) error {
+		return r.Offer(timeout)
+	}
+}
@@ -0,0 +1,236 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mzzsfy/go-util/pool"
+)
+
+// newFullPool 创建单 worker、队列容量为2的协程池, 返回时 worker 被阻塞且队列已满
+func newFullPool(t *testing.T, policy pool.RejectPolicy) (*pool.GoPool, chan struct{}) {
+	t.Helper()
+	p := pool.NewGopool(pool.WithMaxWorks(1), pool.WithQueueCapacity(2), pool.WithRejectPolicy(policy))
+	block := make(chan struct{})
+	started := make(chan struct{})
+	if err := p.Go(func() {
+		close(started)
+		<-block
+	}); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	for i := 0; i < 2; i++ {
+		if err := p.Go(func() {}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return p, block
+}
+
+func Test_RejectAbort(t *testing.T) {
+	t.Parallel()
+	p, block := newFullPool(t, pool.RejectAbort)
+	if err := p.Go(func() {}); err != pool.ErrTaskRejected {
+		t.Fatalf("err=%v", err)
+	}
+	_, err := pool.Submit(p, func() (int, error) { return 1, nil }).Get(context.Background())
+	if err != pool.ErrTaskRejected {
+		t.Fatalf("err=%v", err)
+	}
+	close(block)
+	p.Shutdown()
+	s := p.Stats()
+	if s.Rejected != 2 || s.Submitted != 5 || s.Completed != 3 {
+		t.Fatalf("stats=%+v", s)
+	}
+}
+
+func Test_RejectCallerRuns(t *testing.T) {
+	t.Parallel()
+	p, block := newFullPool(t, pool.RejectCallerRuns)
+	var ran bool
+	if err := p.Go(func() { ran = true }); err != nil || !ran {
+		t.Fatalf("err=%v ran=%v", err, ran)
+	}
+	close(block)
+	p.Shutdown()
+}
+
+func Test_RejectDiscardOldest(t *testing.T) {
+	t.Parallel()
+	p := pool.NewGopool(pool.WithMaxWorks(1), pool.WithQueueCapacity(2), pool.WithRejectPolicy(pool.RejectDiscardOldest))
+	block := make(chan struct{})
+	started := make(chan struct{})
+	_ = p.Go(func() {
+		close(started)
+		<-block
+	})
+	<-started
+	var fs []pool.Future[int]
+	for i := 0; i < 4; i++ {
+		i := i
+		fs = append(fs, pool.Submit(p, func() (int, error) { return i, nil }))
+	}
+	close(block)
+	// 最早的两个任务被丢弃
+	for i, f := range fs {
+		v, err := f.Get(context.Background())
+		if i < 2 {
+			if err != pool.ErrTaskRejected {
+				t.Fatalf("i=%d err=%v", i, err)
+			}
+		} else if err != nil || v != i {
+			t.Fatalf("i=%d v=%d err=%v", i, v, err)
+		}
+	}
+	p.Shutdown()
+	if s := p.Stats(); s.Rejected != 2 {
+		t.Fatalf("stats=%+v", s)
+	}
+}
+
+func Test_RejectBlock(t *testing.T) {
+	t.Parallel()
+	p, block := newFullPool(t, pool.RejectBlock(10*time.Millisecond))
+	start := time.Now()
+	if err := p.Go(func() {}); err != pool.ErrTaskRejected || time.Since(start) < 10*time.Millisecond {
+		t.Fatalf("err=%v", err)
+	}
+
+	// 空位出现后入队成功
+	var ran int32
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(block)
+	}()
+	p2, block2 := newFullPool(t, pool.RejectBlock(0))
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(block2)
+	}()
+	if err := p2.Go(func() { atomic.StoreInt32(&ran, 1) }); err != nil {
+		t.Fatal(err)
+	}
+	p2.Shutdown()
+	if atomic.LoadInt32(&ran) != 1 {
+		t.Fatal("任务未执行")
+	}
+
+	// 关闭时唤醒阻塞的提交方
+	p3, block3 := newFullPool(t, pool.RejectBlock(0))
+	errCh := make(chan error, 1)
+	go func() { errCh <- p3.Go(func() {}) }()
+	time.Sleep(5 * time.Millisecond)
+	go p3.Shutdown()
+	if err := <-errCh; err != nil && !errors.Is(err, pool.ErrPoolClosed) {
+		t.Fatalf("err=%v", err)
+	}
+	close(block3)
+	p.Shutdown()
+}
+
+// 关闭后才入队的任务返回 ErrPoolClosed, 不交给拒绝策略在调用方执行
+func Test_RejectCallerRuns_AfterShutdown(t *testing.T) {
+	t.Parallel()
+	p := pool.NewGopool(pool.WithMaxWorks(1), pool.WithQueueCapacity(2),
+		pool.WithRejectPolicy(pool.RejectCallerRuns), pool.WithTenantLimit(1))
+	ctx := pool.WithTenant(context.Background(), "t")
+	block := make(chan struct{})
+	started := make(chan struct{})
+	if err := p.CtxGo(ctx, func() {
+		close(started)
+		<-block
+	}); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	// 租户许可已用完, 提交阻塞在许可上, 通过 shutDown 检查后才入队
+	var ran int32
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.CtxGo(ctx, func() { atomic.StoreInt32(&ran, 1) })
+	}()
+	time.Sleep(5 * time.Millisecond)
+	done := make(chan struct{})
+	go func() {
+		p.Shutdown()
+		close(done)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	close(block)
+	if err := <-errCh; err != pool.ErrPoolClosed {
+		t.Fatalf("err=%v", err)
+	}
+	<-done
+	if atomic.LoadInt32(&ran) != 0 {
+		t.Fatal("关闭后提交的任务不应执行")
+	}
+}
+
+// 拒绝策略执行或入队了任务后仍返回错误, 任务不能被回收后复用, 否则会被两个提交方共用
+func Test_RejectPolicy_ConsumedThenError(t *testing.T) {
+	t.Parallel()
+	errPolicy := errors.New("policy")
+	for name, consume := range map[string]func(r *pool.Rejection){
+		"run":   func(r *pool.Rejection) { r.Run() },
+		"offer": func(r *pool.Rejection) { _ = r.Offer(-1) },
+	} {
+		name, consume := name, consume
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var ranConsumed int32
+			policy := func(r *pool.Rejection) error {
+				consume(r)
+				// 重复调用不会再次执行或入队
+				r.Run()
+				if err := r.Offer(0); err != nil {
+					t.Error(err)
+				}
+				return errPolicy
+			}
+			p := pool.NewGopool(pool.WithMaxWorks(1), pool.WithQueueCapacity(2),
+				pool.WithRejectPolicy(policy), pool.WithTenantLimit(2))
+			ctx := pool.WithTenant(context.Background(), "t")
+			block := make(chan struct{})
+			started := make(chan struct{})
+			_ = p.Go(func() {
+				close(started)
+				<-block
+			})
+			<-started
+			_ = p.Go(func() {})
+			_ = p.Go(func() {})
+			if name == "offer" {
+				// 队列腾出空位后 Offer(-1) 入队
+				go func() {
+					time.Sleep(5 * time.Millisecond)
+					close(block)
+				}()
+			}
+			if err := p.CtxGo(ctx, func() { atomic.AddInt32(&ranConsumed, 1) }); err != errPolicy {
+				t.Fatalf("err=%v", err)
+			}
+			if name == "run" {
+				close(block)
+			}
+			// 之后提交的任务各自执行自己的函数
+			var a, b int32
+			_ = p.Go(func() { atomic.AddInt32(&a, 1) })
+			_ = p.Go(func() { atomic.AddInt32(&b, 1) })
+			p.Shutdown()
+			if atomic.LoadInt32(&ranConsumed) != 1 || a != 1 || b != 1 {
+				t.Fatalf("consumed=%d a=%d b=%d", ranConsumed, a, b)
+			}
+			if s := p.Stats(); s.Rejected != 0 {
+				t.Fatalf("stats=%+v", s)
+			}
+			if n := p.TenantInFlight("t"); n != 0 {
+				t.Fatalf("租户许可未归还: %d", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,329 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBorrowTimeout 在 MaxWait 内未能借出对象
+var ErrBorrowTimeout = errors.New("borrow timeout")
+
+// ResourcePoolConfig ResourcePool 配置
+type ResourcePoolConfig[T any] struct {
+	// New 创建对象, 必填, ctx 为 Borrow 传入的 ctx
+	New func(ctx context.Context) (T, error)
+	// Destroy 销毁对象(关闭连接等), 可为nil
+	Destroy func(T)
+	// ValidateOnBorrow 借出空闲对象前校验, 返回 false 时销毁该对象并继续获取, 可为nil
+	ValidateOnBorrow func(T) bool
+	// ValidateOnReturn 归还时校验, 返回 false 时直接销毁, 可为nil
+	ValidateOnReturn func(T) bool
+	// MaxTotal 对象总数(借出+空闲)上限, 达到上限时 Borrow 阻塞等待, <=0 表示不限制
+	MaxTotal int
+	// MaxIdle 空闲对象数上限, 超出时归还的对象被销毁, <=0 时与 MaxTotal 相同
+	MaxIdle int
+	// IdleTimeout 空闲超过该时间的对象被后台销毁, <=0 表示不按时间销毁
+	IdleTimeout time.Duration
+	// MaxWait Borrow 最长等待时间, 超时返回 ErrBorrowTimeout, <=0 表示只受 ctx 限制
+	MaxWait time.Duration
+}
+
+// ResourcePoolStats ResourcePool 统计快照
+type ResourcePoolStats struct {
+	// Borrowed 借出未归还的对象数
+	Borrowed int
+	// Idle 空闲对象数
+	Idle int
+	// Waiting 等待借出的调用数
+	Waiting int
+	// Created 累计创建的对象数
+	Created int64
+	// Destroyed 累计销毁的对象数
+	Destroyed int64
+}
+
+// ResourcePool 有界的泛型资源池, 适用于连接、解析器、脚本虚拟机等创建代价高且需要限制数量的对象
+//
+// 与 ObjectPool 不同, 空闲对象不会被 GC 回收, 只会因超出 MaxIdle、空闲超时、校验失败、Invalidate 或 Close 被销毁
+//
+// 示例:
+//
+//	p := NewResourcePool(ResourcePoolConfig[net.Conn]{
+//		New:         func(ctx context.Context) (net.Conn, error) { return dialer.DialContext(ctx, "tcp", addr) },
+//		Destroy:     func(c net.Conn) { c.Close() },
+//		MaxTotal:    16,
+//		IdleTimeout: time.Minute,
+//	})
+//	c, err := p.Borrow(ctx)
+//	if err != nil { ... }
+//	if _, err = c.Write(req); err != nil {
+//		p.Invalidate(c) // 连接已损坏
+//	} else {
+//		p.Return(c)
+//	}
+type ResourcePool[T any] struct {
+	cfg ResourcePoolConfig[T]
+
+	mu sync.Mutex
+	// idle 按归还时间排序, 借出时取最近归还的对象, 较早的对象更容易空闲超时
+	idle     []idleResource[T]
+	total    int
+	borrowed int
+	// waiters 等待借出的调用, 对象归还或名额释放时唤醒第一个
+	waiters   []chan struct{}
+	created   int64
+	destroyed int64
+	closed    bool
+	stop      chan struct{}
+}
+
+type idleResource[T any] struct {
+	v     T
+	since time.Time
+}
+
+// NewResourcePool 创建资源池, cfg.New 为nil时 panic
+func NewResourcePool[T any](cfg ResourcePoolConfig[T]) *ResourcePool[T] {
+	if cfg.New == nil {
+		panic("pool: ResourcePoolConfig.New is nil")
+	}
+	if cfg.MaxIdle <= 0 {
+		cfg.MaxIdle = cfg.MaxTotal
+	}
+	p := &ResourcePool[T]{cfg: cfg, stop: make(chan struct{})}
+	if cfg.IdleTimeout > 0 {
+		go p.evictLoop()
+	}
+	return p
+}
+
+// Borrow 借出对象, 有空闲对象时直接返回, 未达到 MaxTotal 时创建新对象, 否则阻塞等待归还
+// ctx 取消时返回 ctx.Err(), 超过 MaxWait 时返回 ErrBorrowTimeout, 已关闭时返回 ErrPoolClosed
+func (p *ResourcePool[T]) Borrow(ctx context.Context) (T, error) {
+	var zero T
+	var timeout <-chan time.Time
+	if p.cfg.MaxWait > 0 {
+		timer := time.NewTimer(p.cfg.MaxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return zero, ErrPoolClosed
+		}
+		if n := len(p.idle); n > 0 {
+			r := p.idle[n-1]
+			p.idle[n-1] = idleResource[T]{}
+			p.idle = p.idle[:n-1]
+			p.borrowed++
+			p.mu.Unlock()
+			if p.cfg.ValidateOnBorrow != nil && !p.cfg.ValidateOnBorrow(r.v) {
+				p.Invalidate(r.v)
+				continue
+			}
+			return r.v, nil
+		}
+		if p.cfg.MaxTotal <= 0 || p.total < p.cfg.MaxTotal {
+			p.total++
+			p.borrowed++
+			p.mu.Unlock()
+			return p.create(ctx)
+		}
+		ch := make(chan struct{}, 1)
+		p.waiters = append(p.waiters, ch)
+		p.mu.Unlock()
+		select {
+		case <-ch:
+			continue
+		case <-ctx.Done():
+			p.cancelWait(ch)
+			return zero, ctx.Err()
+		case <-timeout:
+			p.cancelWait(ch)
+			return zero, ErrBorrowTimeout
+		}
+	}
+}
+
+// create 在已占用名额后创建对象, 创建失败或 panic 时释放名额, 创建期间池被关闭时销毁新对象并返回 ErrPoolClosed
+func (p *ResourcePool[T]) create(ctx context.Context) (v T, err error) {
+	ok := false
+	defer func() {
+		if ok {
+			return
+		}
+		p.mu.Lock()
+		p.total--
+		p.borrowed--
+		p.notifyOne()
+		p.mu.Unlock()
+	}()
+	v, err = p.cfg.New(ctx)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	p.mu.Lock()
+	p.created++
+	if p.closed {
+		p.destroyed++
+		p.mu.Unlock()
+		p.destroy(v)
+		var zero T
+		return zero, ErrPoolClosed
+	}
+	p.mu.Unlock()
+	ok = true
+	return v, nil
+}
+
+// Return 归还借出的对象, 校验失败、超出 MaxIdle 或已关闭时销毁
+// 只能归还由本池借出的对象, 且每个对象只能归还或 Invalidate 一次
+func (p *ResourcePool[T]) Return(v T) {
+	if p.cfg.ValidateOnReturn != nil && !p.cfg.ValidateOnReturn(v) {
+		p.Invalidate(v)
+		return
+	}
+	p.mu.Lock()
+	if p.closed || len(p.idle) >= p.cfg.MaxIdle && p.cfg.MaxIdle > 0 {
+		p.mu.Unlock()
+		p.Invalidate(v)
+		return
+	}
+	p.borrowed--
+	p.idle = append(p.idle, idleResource[T]{v: v, since: time.Now()})
+	p.notifyOne()
+	p.mu.Unlock()
+}
+
+// Invalidate 销毁借出的已损坏对象, 释放名额
+func (p *ResourcePool[T]) Invalidate(v T) {
+	p.mu.Lock()
+	p.borrowed--
+	p.total--
+	p.destroyed++
+	p.notifyOne()
+	p.mu.Unlock()
+	p.destroy(v)
+}
+
+// Close 关闭资源池, 销毁空闲对象并唤醒等待中的 Borrow(返回 ErrPoolClosed), 之后归还的对象直接销毁
+func (p *ResourcePool[T]) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.stop)
+	idle := p.idle
+	p.idle = nil
+	p.total -= len(idle)
+	p.destroyed += int64(len(idle))
+	for _, ch := range p.waiters {
+		ch <- struct{}{}
+	}
+	p.waiters = nil
+	p.mu.Unlock()
+	for _, r := range idle {
+		p.destroy(r.v)
+	}
+}
+
+// Stats 返回统计快照
+func (p *ResourcePool[T]) Stats() ResourcePoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return ResourcePoolStats{
+		Borrowed:  p.borrowed,
+		Idle:      len(p.idle),
+		Waiting:   len(p.waiters),
+		Created:   p.created,
+		Destroyed: p.destroyed,
+	}
+}
+
+// notifyOne 唤醒第一个等待者重新尝试借出, 调用方需持有锁
+func (p *ResourcePool[T]) notifyOne() {
+	if len(p.waiters) == 0 {
+		return
+	}
+	ch := p.waiters[0]
+	p.waiters[0] = nil
+	p.waiters = p.waiters[1:]
+	ch <- struct{}{}
+}
+
+// cancelWait 放弃等待, 已被唤醒时把唤醒转交给下一个等待者
+func (p *ResourcePool[T]) cancelWait(ch chan struct{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, w := range p.waiters {
+		if w == ch {
+			p.waiters = append(p.waiters[:i], p.waiters[i+1:]...)
+			return
+		}
+	}
+	select {
+	case <-ch:
+		p.notifyOne()
+	default:
+	}
+}
+
+func (p *ResourcePool[T]) destroy(v T) {
+	if p.cfg.Destroy != nil {
+		p.cfg.Destroy(v)
+	}
+}
+
+// evictLoop 定期销毁空闲超时的对象
+func (p *ResourcePool[T]) evictLoop() {
+	interval := p.cfg.IdleTimeout / 2
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case now := <-ticker.C:
+			p.evict(now)
+		}
+	}
+}
+
+func (p *ResourcePool[T]) evict(now time.Time) {
+	deadline := now.Add(-p.cfg.IdleTimeout)
+	p.mu.Lock()
+	n := 0
+	for n < len(p.idle) && !p.idle[n].since.After(deadline) {
+		n++
+	}
+	if n == 0 {
+		p.mu.Unlock()
+		return
+	}
+	expired := make([]T, n)
+	for i := 0; i < n; i++ {
+		expired[i] = p.idle[i].v
+	}
+	rest := copy(p.idle, p.idle[n:])
+	for i := rest; i < len(p.idle); i++ {
+		p.idle[i] = idleResource[T]{}
+	}
+	p.idle = p.idle[:rest]
+	p.total -= n
+	p.destroyed += int64(n)
+	p.notifyOne()
+	p.mu.Unlock()
+	for _, v := range expired {
+		p.destroy(v)
+	}
+}
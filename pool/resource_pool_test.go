@@ -0,0 +1,233 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mzzsfy/go-util/pool"
+)
+
+type testConn struct {
+	id     int32
+	broken bool
+}
+
+func newConnPool(cfg pool.ResourcePoolConfig[*testConn]) (*pool.ResourcePool[*testConn], *int32) {
+	var destroyed int32
+	var id int32
+	cfg.New = func(context.Context) (*testConn, error) {
+		return &testConn{id: atomic.AddInt32(&id, 1)}, nil
+	}
+	cfg.Destroy = func(*testConn) { atomic.AddInt32(&destroyed, 1) }
+	return pool.NewResourcePool(cfg), &destroyed
+}
+
+func Test_ResourcePool_BorrowReturn(t *testing.T) {
+	t.Parallel()
+	p, destroyed := newConnPool(pool.ResourcePoolConfig[*testConn]{MaxTotal: 2, MaxWait: 20 * time.Millisecond})
+	ctx := context.Background()
+
+	c1, _ := p.Borrow(ctx)
+	c2, _ := p.Borrow(ctx)
+	if c1.id == c2.id {
+		t.Fatal("应创建两个不同对象")
+	}
+	// 达到上限后等待超时
+	if _, err := p.Borrow(ctx); err != pool.ErrBorrowTimeout {
+		t.Fatalf("err=%v", err)
+	}
+	p.Return(c1)
+	c3, err := p.Borrow(ctx)
+	if err != nil || c3 != c1 {
+		t.Fatalf("应复用空闲对象, err=%v", err)
+	}
+
+	// 等待中的 Borrow 在归还后被唤醒
+	got := make(chan *testConn)
+	go func() {
+		c, _ := p.Borrow(ctx)
+		got <- c
+	}()
+	time.Sleep(5 * time.Millisecond)
+	if s := p.Stats(); s.Waiting != 1 || s.Borrowed != 2 {
+		t.Fatalf("stats=%+v", s)
+	}
+	p.Invalidate(c2)
+	if c := <-got; c == nil || c == c2 {
+		t.Fatal("名额释放后应创建新对象")
+	}
+	if atomic.LoadInt32(destroyed) != 1 {
+		t.Fatal("Invalidate 应销毁对象")
+	}
+
+	ctx2, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	if _, err = p.Borrow(ctx2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err=%v", err)
+	}
+	if s := p.Stats(); s.Created != 3 || s.Destroyed != 1 || s.Borrowed != 2 || s.Waiting != 0 {
+		t.Fatalf("stats=%+v", s)
+	}
+}
+
+func Test_ResourcePool_Validate(t *testing.T) {
+	t.Parallel()
+	p, destroyed := newConnPool(pool.ResourcePoolConfig[*testConn]{
+		MaxIdle:          1,
+		ValidateOnBorrow: func(c *testConn) bool { return !c.broken },
+		ValidateOnReturn: func(c *testConn) bool { return c.id != 99 },
+	})
+	ctx := context.Background()
+	c1, _ := p.Borrow(ctx)
+	c2, _ := p.Borrow(ctx)
+	p.Return(c1)
+	// 超出 MaxIdle 时销毁
+	p.Return(c2)
+	if atomic.LoadInt32(destroyed) != 1 || p.Stats().Idle != 1 {
+		t.Fatalf("destroyed=%d stats=%+v", *destroyed, p.Stats())
+	}
+	// 空闲期间损坏, 借出时校验失败被销毁并创建新对象
+	c1.broken = true
+	c, _ := p.Borrow(ctx)
+	if c == c1 || atomic.LoadInt32(destroyed) != 2 {
+		t.Fatal("校验失败的对象不应借出")
+	}
+	c.id = 99
+	p.Return(c)
+	if atomic.LoadInt32(destroyed) != 3 || p.Stats().Idle != 0 {
+		t.Fatal("归还校验失败应销毁")
+	}
+}
+
+func Test_ResourcePool_IdleTimeoutAndClose(t *testing.T) {
+	t.Parallel()
+	p, destroyed := newConnPool(pool.ResourcePoolConfig[*testConn]{MaxTotal: 1, IdleTimeout: 20 * time.Millisecond})
+	ctx := context.Background()
+	c, _ := p.Borrow(ctx)
+	p.Return(c)
+	for i := 0; atomic.LoadInt32(destroyed) == 0; i++ {
+		if i > 1000 {
+			t.Fatal("空闲超时对象未被销毁")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if s := p.Stats(); s.Idle != 0 || s.Destroyed != 1 {
+		t.Fatalf("stats=%+v", s)
+	}
+
+	c, _ = p.Borrow(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, err := p.Borrow(ctx); err != pool.ErrPoolClosed {
+			t.Errorf("err=%v", err)
+		}
+	}()
+	time.Sleep(5 * time.Millisecond)
+	p.Close()
+	wg.Wait()
+	// 关闭后归还的对象直接销毁
+	p.Return(c)
+	if s := p.Stats(); s.Borrowed != 0 || s.Idle != 0 || atomic.LoadInt32(destroyed) != 2 {
+		t.Fatalf("stats=%+v destroyed=%d", s, *destroyed)
+	}
+}
+
+func Test_ResourcePool_Concurrent(t *testing.T) {
+	t.Parallel()
+	var inUse, peak int32
+	p, _ := newConnPool(pool.ResourcePoolConfig[*testConn]{MaxTotal: 4, MaxIdle: 2})
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c, err := p.Borrow(context.Background())
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				n := atomic.AddInt32(&inUse, 1)
+				if n > atomic.LoadInt32(&peak) {
+					atomic.StoreInt32(&peak, n)
+				}
+				atomic.AddInt32(&inUse, -1)
+				if j%10 == 0 {
+					p.Invalidate(c)
+				} else {
+					p.Return(c)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	s := p.Stats()
+	if atomic.LoadInt32(&peak) > 4 || s.Borrowed != 0 || s.Idle > 2 || s.Created-s.Destroyed != int64(s.Idle) {
+		t.Fatalf("peak=%d stats=%+v", peak, s)
+	}
+	p.Close()
+}
+
+func Test_ResourcePool_CreateCloseAndPanic(t *testing.T) {
+	t.Parallel()
+	var destroyed int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	p := pool.NewResourcePool(pool.ResourcePoolConfig[*testConn]{
+		New: func(context.Context) (*testConn, error) {
+			close(started)
+			<-release
+			return &testConn{}, nil
+		},
+		Destroy:  func(*testConn) { atomic.AddInt32(&destroyed, 1) },
+		MaxTotal: 1,
+	})
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := p.Borrow(context.Background())
+		errCh <- err
+	}()
+	<-started
+	// 创建期间关闭, 新对象应被销毁且不借出
+	p.Close()
+	close(release)
+	if err := <-errCh; err != pool.ErrPoolClosed {
+		t.Fatalf("err=%v", err)
+	}
+	if s := p.Stats(); s.Borrowed != 0 || s.Created != 1 || s.Destroyed != 1 || atomic.LoadInt32(&destroyed) != 1 {
+		t.Fatalf("stats=%+v destroyed=%d", s, destroyed)
+	}
+
+	var calls int32
+	p2 := pool.NewResourcePool(pool.ResourcePoolConfig[*testConn]{
+		New: func(context.Context) (*testConn, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				panic("boom")
+			}
+			return &testConn{}, nil
+		},
+		MaxTotal: 1,
+		MaxWait:  50 * time.Millisecond,
+	})
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("New 的 panic 应传播给调用方")
+			}
+		}()
+		_, _ = p2.Borrow(context.Background())
+	}()
+	// panic 后名额应被释放
+	if _, err := p2.Borrow(context.Background()); err != nil {
+		t.Fatalf("err=%v", err)
+	}
+	if s := p2.Stats(); s.Borrowed != 1 || s.Created != 1 {
+		t.Fatalf("stats=%+v", s)
+	}
+}
@@ -0,0 +1,296 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mzzsfy/go-util/helper"
+)
+
+// ErrInvalidPeriod 周期或延迟小于等于0
+var ErrInvalidPeriod = errors.New("period must be positive")
+
+// OverlapPolicy 周期任务上一次执行尚未结束时, 再次触发的处理方式
+type OverlapPolicy int
+
+const (
+	// OverlapSkip 跳过本次触发(默认)
+	OverlapSkip OverlapPolicy = iota
+	// OverlapQueue 记录本次触发, 上一次执行结束后立即再执行, 同一任务始终串行
+	OverlapQueue
+	// OverlapAllow 允许同一任务并发执行
+	OverlapAllow
+)
+
+// ScheduledPool 定时/周期任务调度器, 由 helper.TimerWheel 计时, 到期后提交到 GoPool 执行
+//
+// 时间轮的 goroutine 只负责提交任务, 协程池使用有界队列时建议搭配 RejectAbort 等不阻塞的拒绝策略,
+// 避免队列满时拖慢其他任务的触发
+//
+// 示例:
+//
+//	sp := NewScheduledPool(nil, helper.WithTickInterval(10*time.Millisecond))
+//	t, _ := sp.ScheduleFixedRate(0, time.Second, OverlapSkip, func() { report() })
+//	t.Pause()
+//	t.Resume()
+//	sp.Shutdown()
+type ScheduledPool struct {
+	p      *GoPool
+	wheel  *helper.TimerWheel
+	closed int32
+}
+
+// NewScheduledPool 创建调度器, p 为 nil 时创建独立的协程池
+// p 由调度器接管, Shutdown 时一并关闭; opts 为时间轮选项, 执行器固定为提交到 p
+func NewScheduledPool(p *GoPool, opts ...helper.Option) *ScheduledPool {
+	if p == nil {
+		p = NewGopool(WithName("scheduled"))
+	}
+	// 到期任务在时间轮 goroutine 中直接执行, 任务本身只提交到协程池
+	opts = append(opts, helper.WithExecutor(func(t helper.Task) { t.Run() }))
+	return &ScheduledPool{
+		p:     p,
+		wheel: helper.NewTimerWheel(opts...),
+	}
+}
+
+// Pool 返回执行任务的协程池
+func (s *ScheduledPool) Pool() *GoPool {
+	return s.p
+}
+
+// ScheduleAt 在 at 时刻执行一次 f, at 已过去时立即执行
+func (s *ScheduledPool) ScheduleAt(at time.Time, f func()) (*ScheduledTask, error) {
+	t, err := s.newTask(OverlapAllow, 0, f)
+	if err != nil {
+		return nil, err
+	}
+	t.setHandle(s.wheel.Schedule(time.Until(at), helper.FuncTask(t.trigger)))
+	return t, nil
+}
+
+// ScheduleFixedRate 固定频率执行 f: 首次在 initialDelay 后触发, 之后每隔 period 触发一次, 触发时间不受执行耗时影响
+// 上一次执行未结束时按 policy 处理; 错过的触发(如时间轮被阻塞)合并为一次
+func (s *ScheduledPool) ScheduleFixedRate(initialDelay, period time.Duration, policy OverlapPolicy, f func()) (*ScheduledTask, error) {
+	if period <= 0 {
+		return nil, ErrInvalidPeriod
+	}
+	t, err := s.newTask(policy, 0, f)
+	if err != nil {
+		return nil, err
+	}
+	first := true
+	// 时间轮按顺序调用 schedule, 首次调用时 base 为当前时间, 之后为上一次的计划时间
+	t.setHandle(s.wheel.ScheduleCustom(func(base time.Time) time.Time {
+		if first {
+			first = false
+			return base.Add(initialDelay)
+		}
+		next := base.Add(period)
+		if now := time.Now(); next.Before(now) {
+			next = next.Add((now.Sub(next)/period + 1) * period)
+		}
+		return next
+	}, helper.FuncTask(t.trigger)))
+	return t, nil
+}
+
+// ScheduleFixedDelay 固定延迟执行 f: 首次在 initialDelay 后触发, 之后每次执行结束 delay 后再次触发, 同一任务不会重叠执行
+func (s *ScheduledPool) ScheduleFixedDelay(initialDelay, delay time.Duration, f func()) (*ScheduledTask, error) {
+	if delay <= 0 {
+		return nil, ErrInvalidPeriod
+	}
+	t, err := s.newTask(OverlapSkip, delay, f)
+	if err != nil {
+		return nil, err
+	}
+	t.setHandle(s.wheel.Schedule(initialDelay, helper.FuncTask(t.trigger)))
+	return t, nil
+}
+
+// ScheduleCron 按 cron 表达式执行 f, 表达式格式见 helper.ParseCron, 上一次执行未结束时按 policy 处理
+func (s *ScheduledPool) ScheduleCron(expr string, policy OverlapPolicy, f func()) (*ScheduledTask, error) {
+	c, err := helper.ParseCron(expr)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.newTask(policy, 0, f)
+	if err != nil {
+		return nil, err
+	}
+	t.setHandle(s.wheel.ScheduleCustom(func(base time.Time) time.Time {
+		return c.NextTime(base)
+	}, helper.FuncTask(t.trigger)))
+	return t, nil
+}
+
+func (s *ScheduledPool) newTask(policy OverlapPolicy, delay time.Duration, f func()) (*ScheduledTask, error) {
+	if atomic.LoadInt32(&s.closed) == 1 {
+		return nil, ErrPoolClosed
+	}
+	return &ScheduledTask{s: s, fn: f, policy: policy, delay: delay}, nil
+}
+
+// Shutdown 优雅关闭: 先停止时间轮不再触发新的执行, 再关闭协程池并等待已提交的执行完成
+// OverlapQueue 排队中尚未提交的执行被丢弃
+func (s *ScheduledPool) Shutdown() bool {
+	if !atomic.CompareAndSwapInt32(&s.closed, 0, 1) {
+		return false
+	}
+	s.wheel.Stop()
+	return s.p.Shutdown()
+}
+
+// ShutdownCtx 同 Shutdown, 最多等待到 ctx 取消, 返回协程池中被放弃的任务数
+func (s *ScheduledPool) ShutdownCtx(ctx context.Context) (int, error) {
+	if !atomic.CompareAndSwapInt32(&s.closed, 0, 1) {
+		return 0, ErrPoolClosed
+	}
+	s.wheel.Stop()
+	return s.p.ShutdownCtx(ctx)
+}
+
+// ScheduledTask 已调度任务的句柄
+type ScheduledTask struct {
+	s      *ScheduledPool
+	fn     func()
+	policy OverlapPolicy
+	// delay 大于0表示固定延迟任务, 每次执行结束后重新调度
+	delay time.Duration
+
+	mu     sync.Mutex
+	handle helper.TaskHandle
+	// running 执行中(含已提交未执行)的次数, pending OverlapQueue 排队的触发次数
+	running   int
+	pending   int
+	paused    int32
+	cancelled int32
+	runs      int64
+}
+
+// setHandle 记录时间轮句柄用于 Cancel, 已取消时直接取消该句柄
+func (t *ScheduledTask) setHandle(h helper.TaskHandle) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.Cancelled() {
+		h.Cancel()
+		return
+	}
+	t.handle = h
+}
+
+// Cancel 取消后续触发, 排队中的执行被丢弃, 不影响正在执行的任务
+func (t *ScheduledTask) Cancel() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	atomic.StoreInt32(&t.cancelled, 1)
+	t.pending = 0
+	if t.handle != nil {
+		t.handle.Cancel()
+	}
+}
+
+// Cancelled 是否已取消
+func (t *ScheduledTask) Cancelled() bool {
+	return atomic.LoadInt32(&t.cancelled) == 1
+}
+
+// Pause 暂停, 暂停期间的触发被跳过, 计划时间照常推进
+func (t *ScheduledTask) Pause() {
+	atomic.StoreInt32(&t.paused, 1)
+}
+
+// Resume 恢复暂停的任务, 从下一次触发开始执行
+func (t *ScheduledTask) Resume() {
+	atomic.StoreInt32(&t.paused, 0)
+}
+
+// Paused 是否已暂停
+func (t *ScheduledTask) Paused() bool {
+	return atomic.LoadInt32(&t.paused) == 1
+}
+
+// Runs 已开始执行的次数
+func (t *ScheduledTask) Runs() int64 {
+	return atomic.LoadInt64(&t.runs)
+}
+
+// trigger 到期触发, 在时间轮 goroutine 中执行
+func (t *ScheduledTask) trigger() {
+	if t.Cancelled() {
+		return
+	}
+	if t.Paused() {
+		if t.delay > 0 {
+			t.scheduleNext()
+		}
+		return
+	}
+	t.mu.Lock()
+	switch {
+	case t.running == 0 || t.policy == OverlapAllow:
+		t.running++
+		t.mu.Unlock()
+		t.submit()
+	case t.policy == OverlapQueue:
+		t.pending++
+		t.mu.Unlock()
+	default:
+		t.mu.Unlock()
+	}
+}
+
+// submit 提交一次执行到协程池, 调用前已计入 running
+func (t *ScheduledTask) submit() {
+	if err := t.s.p.ctxGo(context.Background(), t.run, t.discarded); err != nil {
+		t.mu.Lock()
+		t.running--
+		t.pending = 0
+		t.mu.Unlock()
+		if t.delay > 0 && err != ErrPoolClosed {
+			t.scheduleNext()
+		}
+	}
+}
+
+func (t *ScheduledTask) run() {
+	// panic 时同样结束本次执行, panic 继续交给协程池的 panicHandler 处理
+	defer t.finish()
+	if !t.Cancelled() {
+		atomic.AddInt64(&t.runs, 1)
+		t.fn()
+	}
+}
+
+// discarded 已提交的执行未执行就被协程池丢弃(如 RejectDiscardOldest 或 ShutdownCtx 放弃队列),
+// 与执行结束相同地释放 running、处理排队的触发并重新调度固定延迟任务
+func (t *ScheduledTask) discarded(error) {
+	t.finish()
+}
+
+// finish 一次执行结束: 有排队的触发时继续提交, 固定延迟任务重新调度
+func (t *ScheduledTask) finish() {
+	t.mu.Lock()
+	if t.pending > 0 && !t.Cancelled() && atomic.LoadInt32(&t.s.closed) == 0 {
+		t.pending--
+		t.mu.Unlock()
+		t.submit()
+		return
+	}
+	t.running--
+	t.mu.Unlock()
+	if t.delay > 0 {
+		t.scheduleNext()
+	}
+}
+
+// scheduleNext 固定延迟任务调度下一次触发, 不持有锁调用时间轮, 到期时间已过时 trigger 会在当前协程直接执行
+func (t *ScheduledTask) scheduleNext() {
+	if t.Cancelled() {
+		return
+	}
+	t.setHandle(t.s.wheel.Schedule(t.delay, helper.FuncTask(t.trigger)))
+}
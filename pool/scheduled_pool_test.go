@@ -0,0 +1,246 @@
+package pool_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mzzsfy/go-util/helper"
+	"github.com/mzzsfy/go-util/pool"
+)
+
+func newScheduledPool() *pool.ScheduledPool {
+	return pool.NewScheduledPool(nil, helper.WithTickInterval(5*time.Millisecond))
+}
+
+// waitRuns 等待任务执行次数达到 n
+func waitRuns(t *testing.T, st *pool.ScheduledTask, n int64) {
+	t.Helper()
+	for i := 0; st.Runs() < n; i++ {
+		if i > 3000 {
+			t.Fatalf("runs=%d, want %d", st.Runs(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func Test_ScheduledPool_FixedRate(t *testing.T) {
+	t.Parallel()
+	sp := newScheduledPool()
+	defer sp.Shutdown()
+
+	var n int32
+	st, err := sp.ScheduleFixedRate(0, 10*time.Millisecond, pool.OverlapSkip, func() {
+		atomic.AddInt32(&n, 1)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitRuns(t, st, 3)
+
+	st.Pause()
+	time.Sleep(20 * time.Millisecond)
+	paused := st.Runs()
+	time.Sleep(50 * time.Millisecond)
+	if st.Runs() != paused || !st.Paused() {
+		t.Fatalf("暂停期间不应执行, runs %d -> %d", paused, st.Runs())
+	}
+	st.Resume()
+	waitRuns(t, st, paused+2)
+
+	st.Cancel()
+	time.Sleep(20 * time.Millisecond)
+	cancelled := st.Runs()
+	time.Sleep(50 * time.Millisecond)
+	if st.Runs() != cancelled || !st.Cancelled() {
+		t.Fatalf("取消后不应执行, runs %d -> %d", cancelled, st.Runs())
+	}
+
+	if _, err = sp.ScheduleFixedRate(0, 0, pool.OverlapSkip, func() {}); err != pool.ErrInvalidPeriod {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func Test_ScheduledPool_Overlap(t *testing.T) {
+	t.Parallel()
+	for _, c := range []struct {
+		name     string
+		policy   pool.OverlapPolicy
+		parallel bool
+	}{
+		{"skip", pool.OverlapSkip, false},
+		{"queue", pool.OverlapQueue, false},
+		{"allow", pool.OverlapAllow, true},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			sp := newScheduledPool()
+			defer sp.Shutdown()
+
+			var running, peak int32
+			st, err := sp.ScheduleFixedRate(0, 10*time.Millisecond, c.policy, func() {
+				r := atomic.AddInt32(&running, 1)
+				for {
+					p := atomic.LoadInt32(&peak)
+					if r <= p || atomic.CompareAndSwapInt32(&peak, p, r) {
+						break
+					}
+				}
+				time.Sleep(40 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(200 * time.Millisecond)
+			st.Cancel()
+			if p := atomic.LoadInt32(&peak); (p > 1) != c.parallel {
+				t.Fatalf("peak=%d", p)
+			}
+			if c.policy == pool.OverlapSkip && st.Runs() > 6 {
+				t.Fatalf("runs=%d, 重叠的触发应被跳过", st.Runs())
+			}
+		})
+	}
+}
+
+func Test_ScheduledPool_QueueDrainsPending(t *testing.T) {
+	t.Parallel()
+	sp := newScheduledPool()
+	defer sp.Shutdown()
+
+	release := make(chan struct{})
+	st, err := sp.ScheduleFixedRate(0, 10*time.Millisecond, pool.OverlapQueue, func() {
+		<-release
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitRuns(t, st, 1)
+	// 阻塞期间积压多次触发
+	time.Sleep(60 * time.Millisecond)
+	st.Pause()
+	close(release)
+	waitRuns(t, st, 3)
+}
+
+func Test_ScheduledPool_FixedDelay(t *testing.T) {
+	t.Parallel()
+	sp := newScheduledPool()
+	defer sp.Shutdown()
+
+	var last, minGap int64
+	atomic.StoreInt64(&minGap, int64(time.Hour))
+	var running int32
+	st, err := sp.ScheduleFixedDelay(0, 40*time.Millisecond, func() {
+		if atomic.AddInt32(&running, 1) > 1 {
+			t.Error("固定延迟任务不应重叠")
+		}
+		now := time.Now().UnixNano()
+		if l := atomic.LoadInt64(&last); l != 0 && now-l < atomic.LoadInt64(&minGap) {
+			atomic.StoreInt64(&minGap, now-l)
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.StoreInt64(&last, time.Now().UnixNano())
+		atomic.AddInt32(&running, -1)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitRuns(t, st, 4)
+	st.Cancel()
+	time.Sleep(50 * time.Millisecond)
+	// 下次在上次结束后 delay 才开始, 时间轮追赶 tick 时可能提前触发, 只校验明显大于0
+	if gap := atomic.LoadInt64(&minGap); gap < int64(20*time.Millisecond) {
+		t.Fatalf("minGap=%v", time.Duration(gap))
+	}
+}
+
+func Test_ScheduledPool_AtAndShutdown(t *testing.T) {
+	t.Parallel()
+	sp := newScheduledPool()
+
+	done := make(chan struct{})
+	start := time.Now()
+	st, err := sp.ScheduleAt(start.Add(30*time.Millisecond), func() { close(done) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("ScheduleAt 未执行")
+	}
+	if time.Since(start) < 25*time.Millisecond || st.Runs() != 1 {
+		t.Fatalf("过早执行: %v", time.Since(start))
+	}
+
+	var finished int32
+	_, err = sp.ScheduleAt(time.Now(), func() {
+		time.Sleep(30 * time.Millisecond)
+		atomic.StoreInt32(&finished, 1)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var never int32
+	_, _ = sp.ScheduleAt(time.Now().Add(time.Hour), func() { atomic.StoreInt32(&never, 1) })
+	time.Sleep(5 * time.Millisecond)
+	if !sp.Shutdown() {
+		t.Fatal("首次关闭应返回true")
+	}
+	// 关闭时等待已提交的执行完成
+	if atomic.LoadInt32(&finished) != 1 || atomic.LoadInt32(&never) != 0 {
+		t.Fatal("关闭应等待执行中的任务, 且不再触发新任务")
+	}
+	if _, err = sp.ScheduleAt(time.Now(), func() {}); err != pool.ErrPoolClosed {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func Test_ScheduledPool_Cron(t *testing.T) {
+	t.Parallel()
+	sp := newScheduledPool()
+	defer sp.Shutdown()
+
+	if _, err := sp.ScheduleCron("bad", pool.OverlapSkip, func() {}); err == nil {
+		t.Fatal("非法表达式应返回错误")
+	}
+	st, err := sp.ScheduleCron("* * * * * *", pool.OverlapSkip, func() {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitRuns(t, st, 1)
+}
+
+func Test_ScheduledPool_RunnerDiscarded(t *testing.T) {
+	t.Parallel()
+	p := pool.NewGopool(pool.WithMaxWorks(1), pool.WithQueueCapacity(2), pool.WithRejectPolicy(pool.RejectDiscardOldest))
+	sp := pool.NewScheduledPool(p, helper.WithTickInterval(5*time.Millisecond))
+	defer sp.Shutdown()
+	block := make(chan struct{})
+	started := make(chan struct{})
+	_ = p.Go(func() {
+		close(started)
+		<-block
+	})
+	<-started
+	delay, err := sp.ScheduleFixedDelay(0, 20*time.Millisecond, func() {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rate, err := sp.ScheduleFixedRate(0, 10*time.Millisecond, pool.OverlapSkip, func() {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 持续提交挤出队列中的执行, 被丢弃的执行不能让任务一直处于执行中或中断固定延迟的调度
+	deadline := time.Now().Add(100 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		_ = p.Go(func() {})
+		time.Sleep(time.Millisecond)
+	}
+	close(block)
+	waitRuns(t, delay, 1)
+	waitRuns(t, rate, 1)
+}
@@ -0,0 +1,195 @@
+package pool
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/mzzsfy/go-util/helper"
+)
+
+const (
+	// defaultSizedMinCap SizedBytePool 默认最小分级容量
+	defaultSizedMinCap = 64
+	// defaultSizedMaxCap SizedBytePool 默认最大分级容量, 超过此容量的切片不池化
+	defaultSizedMaxCap = 1 << 20
+)
+
+// SizedBytePool 按2的幂分级的字节切片池
+//
+// Get(n) 从能容纳 n 的最小级别取切片, Put 按 cap 归还到不超过 cap 的最大级别,
+// 不同大小的请求互不影响, 大切片不会挤占小切片的复用
+//
+// 示例:
+//
+//	p := NewSizedBytePool(WithSizedBytesRange(64, 64<<10))
+//	b := p.Get(1500) // len=1500, cap=2048
+//	n, _ := conn.Read(b)
+//	p.Put(b)
+type SizedBytePool struct {
+	minShift int
+	maxShift int
+	// classes[i] 中切片的容量为 1<<(minShift+i), 只存放底层数组首地址, 避免 Put 时分配切片头
+	classes []sync.Pool
+	debug   *bytesTracker
+}
+
+// SizedBytesOpt SizedBytePool 选项
+type SizedBytesOpt func(*SizedBytePool)
+
+// WithSizedBytesRange 设置分级容量范围, 均向上取整为2的幂, minCap 最小为16
+// Get 超过 maxCap 时直接分配, 不会被归还到池中
+func WithSizedBytesRange(minCap, maxCap int) SizedBytesOpt {
+	return func(p *SizedBytePool) {
+		if minCap < minPoolCap {
+			minCap = minPoolCap
+		}
+		if maxCap < minCap {
+			maxCap = minCap
+		}
+		p.minShift = bits.Len(uint(minCap - 1))
+		p.maxShift = bits.Len(uint(maxCap - 1))
+	}
+}
+
+// WithSizedBytesDebug 启用调试模式: 记录每个未归还切片的 Get 调用栈, 通过 Leaks 查询;
+// 检测到重复归还(或归还不是由本池 Get 的切片)时调用 onDoublePut, 该切片不会放回池中, onDoublePut 为 nil 时 panic
+//
+// 调试模式每次 Get/Put 都会获取调用栈并加锁, 只应在测试或排查问题时启用
+func WithSizedBytesDebug(onDoublePut func(BytesTrace)) SizedBytesOpt {
+	return func(p *SizedBytePool) {
+		p.debug = &bytesTracker{onDoublePut: onDoublePut, outstanding: map[uintptr]BytesTrace{}}
+	}
+}
+
+// BytesTrace 调试模式下记录的切片信息
+type BytesTrace struct {
+	// Cap 切片容量
+	Cap int
+	// Time Get 的时间, 重复归还时为 Put 的时间
+	Time time.Time
+	// Stack Get 的调用栈, 重复归还时为 Put 的调用栈
+	Stack helper.Stacks
+}
+
+func (t BytesTrace) String() string {
+	return fmt.Sprintf("cap=%d at %s\n%s", t.Cap, t.Time.Format(time.RFC3339Nano), t.Stack)
+}
+
+// NewSizedBytePool 创建分级字节池, 默认容量范围为 64B~1MB
+func NewSizedBytePool(opts ...SizedBytesOpt) *SizedBytePool {
+	p := &SizedBytePool{}
+	WithSizedBytesRange(defaultSizedMinCap, defaultSizedMaxCap)(p)
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.classes = make([]sync.Pool, p.maxShift-p.minShift+1)
+	return p
+}
+
+// Get 获取长度为 n 的切片, 容量为能容纳 n 的最小级别, 内容未清零
+func (p *SizedBytePool) Get(n int) []byte {
+	if n < 0 {
+		n = 0
+	}
+	shift := bits.Len(uint(n - 1))
+	if n == 0 || shift < p.minShift {
+		shift = p.minShift
+	}
+	var b []byte
+	if shift > p.maxShift {
+		b = make([]byte, n)
+	} else if ptr, ok := p.classes[shift-p.minShift].Get().(*byte); ok {
+		b = unsafe.Slice(ptr, 1<<shift)[:n]
+	} else {
+		b = make([]byte, n, 1<<shift)
+	}
+	if p.debug != nil {
+		p.debug.get(b)
+	}
+	return b
+}
+
+// Put 归还切片, 按 cap 放入不超过 cap 的最大级别, cap 超出容量范围时丢弃
+// 归还后不能再使用该切片
+func (p *SizedBytePool) Put(b []byte) {
+	c := cap(b)
+	if c < 1<<p.minShift || c > 1<<p.maxShift {
+		if p.debug != nil && c > 0 {
+			p.debug.put(b)
+		}
+		return
+	}
+	if p.debug != nil && !p.debug.put(b) {
+		return
+	}
+	shift := bits.Len(uint(c)) - 1
+	p.classes[shift-p.minShift].Put(&b[:1][0])
+}
+
+// Leaks 返回已 Get 超过 minAge 仍未归还的切片, 按 Get 时间排序, 未启用调试模式时返回 nil
+func (p *SizedBytePool) Leaks(minAge time.Duration) []BytesTrace {
+	if p.debug == nil {
+		return nil
+	}
+	return p.debug.leaks(minAge)
+}
+
+// bytesTracker 调试模式下按底层数组首地址记录未归还的切片
+type bytesTracker struct {
+	mu          sync.Mutex
+	outstanding map[uintptr]BytesTrace
+	onDoublePut func(BytesTrace)
+}
+
+func bytesKey(b []byte) uintptr {
+	return uintptr(unsafe.Pointer(&b[:1][0]))
+}
+
+func (t *bytesTracker) get(b []byte) {
+	if cap(b) == 0 {
+		return
+	}
+	// 跳过 get 与 SizedBytePool.Get
+	trace := BytesTrace{Cap: cap(b), Time: time.Now(), Stack: helper.CallerStack(2)}
+	t.mu.Lock()
+	t.outstanding[bytesKey(b)] = trace
+	t.mu.Unlock()
+}
+
+// put 返回 false 表示重复归还
+func (t *bytesTracker) put(b []byte) bool {
+	key := bytesKey(b)
+	t.mu.Lock()
+	_, ok := t.outstanding[key]
+	delete(t.outstanding, key)
+	t.mu.Unlock()
+	if ok {
+		return true
+	}
+	trace := BytesTrace{Cap: cap(b), Time: time.Now(), Stack: helper.CallerStack(2)}
+	if t.onDoublePut == nil {
+		panic("SizedBytePool: double put or foreign slice, " + trace.String())
+	}
+	t.onDoublePut(trace)
+	return false
+}
+
+func (t *bytesTracker) leaks(minAge time.Duration) []BytesTrace {
+	now := time.Now()
+	var res []BytesTrace
+	t.mu.Lock()
+	for _, trace := range t.outstanding {
+		if now.Sub(trace.Time) >= minAge {
+			res = append(res, trace)
+		}
+	}
+	t.mu.Unlock()
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Time.Before(res[j].Time)
+	})
+	return res
+}
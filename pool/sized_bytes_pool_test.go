@@ -0,0 +1,107 @@
+package pool_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mzzsfy/go-util/pool"
+)
+
+func Test_SizedBytePool_Classes(t *testing.T) {
+	p := pool.NewSizedBytePool(pool.WithSizedBytesRange(64, 4096))
+	for _, c := range []struct{ n, cap int }{
+		{0, 64}, {1, 64}, {64, 64}, {65, 128}, {1500, 2048}, {4096, 4096}, {4097, 4097},
+	} {
+		b := p.Get(c.n)
+		if len(b) != c.n || cap(b) != c.cap {
+			t.Fatalf("Get(%d): len=%d cap=%d, want cap %d", c.n, len(b), cap(b), c.cap)
+		}
+		p.Put(b)
+	}
+
+	// 按 cap 归还到不超过 cap 的级别, 取出的切片容量不小于请求的级别
+	p.Put(make([]byte, 0, 300))
+	for i := 0; i < 10; i++ {
+		b := p.Get(200)
+		if cap(b) < 256 || len(b) != 200 {
+			t.Fatalf("len=%d cap=%d", len(b), cap(b))
+		}
+		b[199] = 1
+	}
+}
+
+func Test_SizedBytePool_Reuse(t *testing.T) {
+	p := pool.NewSizedBytePool()
+	b := p.Get(1000)
+	b[0] = 42
+	p.Put(b)
+	// sync.Pool 不保证复用, 只验证取到的切片可用
+	b2 := p.Get(1000)
+	if len(b2) != 1000 || cap(b2) != 1024 {
+		t.Fatalf("len=%d cap=%d", len(b2), cap(b2))
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		p.Put(p.Get(512))
+	})
+	if allocs > 0.5 {
+		t.Fatalf("allocs=%v", allocs)
+	}
+}
+
+func Test_SizedBytePool_Debug(t *testing.T) {
+	var doubled []pool.BytesTrace
+	p := pool.NewSizedBytePool(pool.WithSizedBytesDebug(func(trace pool.BytesTrace) {
+		doubled = append(doubled, trace)
+	}))
+
+	leaked := p.Get(100)
+	b := p.Get(10)
+	p.Put(b)
+	p.Put(b)
+	if len(doubled) != 1 || doubled[0].Cap != 64 {
+		t.Fatalf("doubled=%v", doubled)
+	}
+	if !strings.Contains(doubled[0].Stack.String(), "Test_SizedBytePool_Debug") {
+		t.Fatalf("stack=%s", doubled[0].Stack)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	leaks := p.Leaks(time.Millisecond)
+	if len(leaks) != 1 || leaks[0].Cap != cap(leaked) {
+		t.Fatalf("leaks=%v", leaks)
+	}
+	if !strings.Contains(leaks[0].Stack.String(), "Test_SizedBytePool_Debug") {
+		t.Fatalf("stack=%s", leaks[0].Stack)
+	}
+	if len(p.Leaks(time.Hour)) != 0 {
+		t.Fatal("未达到 minAge 的切片不应报告")
+	}
+	p.Put(leaked)
+	if len(p.Leaks(0)) != 0 {
+		t.Fatal("归还后不应报告")
+	}
+
+	// 未设置回调时 panic
+	strict := pool.NewSizedBytePool(pool.WithSizedBytesDebug(nil))
+	defer func() {
+		if recover() == nil {
+			t.Fatal("重复归还应panic")
+		}
+	}()
+	strict.Put(make([]byte, 128))
+}
+
+func BenchmarkSizedBytePool(b *testing.B) {
+	p := pool.NewSizedBytePool()
+	sizes := []int{100, 1500, 9000, 64 << 10}
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			buf := p.Get(sizes[i&3])
+			buf[0] = 1
+			p.Put(buf)
+			i++
+		}
+	})
+}
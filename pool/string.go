@@ -1,10 +1,29 @@
 package pool
 
 import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
 	"sync"
 	"sync/atomic"
 
 	"github.com/mzzsfy/go-util/storage"
+	"github.com/mzzsfy/go-util/unsafe"
+)
+
+// stringPoolShards 字符串池分片数, 字符串按哈希分片, ID 按低位分片
+const stringPoolShards = 32
+
+// stringPoolMagic Export 数据头
+const stringPoolMagic = "GSP1"
+
+var (
+	// ErrStringPoolNotEmpty 向非空字符串池 Import
+	ErrStringPoolNotEmpty = errors.New("string pool is not empty")
+	// ErrStringPoolData Import 的数据格式错误或ID重复
+	ErrStringPoolData = errors.New("invalid string pool data")
 )
 
 type stringPoolEntry struct {
@@ -12,67 +31,289 @@ type stringPoolEntry struct {
 	using uint32
 }
 
+type stringShard struct {
+	lock sync.RWMutex
+	m    storage.Map[string, *stringPoolEntry]
+	_    [unsafe.CachePaddingLength]byte
+}
+
+type idShard struct {
+	// 按ID解码字符串是读多写少的场景, 使用读写锁使 Lookup 之间互不阻塞
+	lock sync.RWMutex
+	m    map[uint64]string
+	_    [unsafe.CachePaddingLength]byte
+}
+
 // NewStringPool 创建字符串池
 func NewStringPool() *StringPool {
-	return &StringPool{
-		m: storage.NewMap[string, *stringPoolEntry](),
+	p := &StringPool{hasher: unsafe.NewHasher[string]()}
+	for i := range p.shards {
+		p.shards[i].m = storage.NewMap[string, *stringPoolEntry]()
+		p.ids[i].m = map[uint64]string{}
 	}
+	return p
 }
 
 // StringPool 字符串池, 用数字代替字符串, 用于 Map 的 Key 场景
+//
+// 字符串按哈希分片加锁, 不同分片的写入互不阻塞; ID 到字符串的反向索引按 ID 单独分片,
+// 锁顺序固定为先字符串分片后 ID 分片
 type StringPool struct {
-	idGen uint64
-	lock  sync.RWMutex
-	m     storage.Map[string, *stringPoolEntry]
+	idGen  uint64
+	hasher unsafe.Hasher[string]
+	shards [stringPoolShards]stringShard
+	ids    [stringPoolShards]idShard
+}
+
+func (p *StringPool) shard(s string) *stringShard {
+	return &p.shards[p.hasher.Hash(s)&(stringPoolShards-1)]
+}
+
+func (p *StringPool) idShard(id uint64) *idShard {
+	return &p.ids[id&(stringPoolShards-1)]
 }
 
 // Peek 查看字符串对应的ID,不存在则返回0
 func (p *StringPool) Peek(s string) uint64 {
-	p.lock.RLock()
-	v, ok := p.m.Get(s)
-	p.lock.RUnlock()
+	sh := p.shard(s)
+	sh.lock.RLock()
+	v, ok := sh.m.Get(s)
+	sh.lock.RUnlock()
 	if ok {
 		return v.id
 	}
 	return 0
 }
 
+// Lookup 查找ID对应的字符串
+func (p *StringPool) Lookup(id uint64) (string, bool) {
+	is := p.idShard(id)
+	is.lock.RLock()
+	s, ok := is.m[id]
+	is.lock.RUnlock()
+	return s, ok
+}
+
 // Use 获取字符串对应的ID并增加引用计数,不存在则创建
 func (p *StringPool) Use(s string) uint64 {
-	p.lock.RLock()
-	if v, ok := p.m.Get(s); ok {
+	sh := p.shard(s)
+	sh.lock.RLock()
+	if v, ok := sh.m.Get(s); ok {
 		// 在读锁内完成原子递增，避免 RUnlock 后 UnUse 将引用计数减到 0 并删除条目
 		atomic.AddUint32(&v.using, 1)
 		id := v.id
-		p.lock.RUnlock()
+		sh.lock.RUnlock()
 		return id
 	}
-	p.lock.RUnlock()
-	p.lock.Lock()
+	sh.lock.RUnlock()
+	sh.lock.Lock()
 	// 写锁互斥: v.using 无并发修改, 直接自增避免 atomic 开销
-	if v, ok := p.m.Get(s); ok {
+	if v, ok := sh.m.Get(s); ok {
 		v.using++
-		p.lock.Unlock()
+		sh.lock.Unlock()
 		return v.id
 	}
 	id := atomic.AddUint64(&p.idGen, 1)
-	p.m.Put(s, &stringPoolEntry{
+	sh.m.Put(s, &stringPoolEntry{
 		id:    id,
 		using: 1,
 	})
-	p.lock.Unlock()
+	p.putID(id, s)
+	sh.lock.Unlock()
 	return id
 }
 
 // UnUse 释放一次引用, 引用归零时删除条目
 // 线程安全: 写锁保证与 Use 的读锁互斥, using 的自减无需 atomic
 func (p *StringPool) UnUse(s string) {
-	p.lock.Lock()
-	if v, ok := p.m.Get(s); ok {
+	sh := p.shard(s)
+	sh.lock.Lock()
+	if v, ok := sh.m.Get(s); ok {
 		v.using--
 		if v.using == 0 {
-			p.m.Delete(s)
+			sh.m.Delete(s)
+			is := p.idShard(v.id)
+			is.lock.Lock()
+			delete(is.m, v.id)
+			is.lock.Unlock()
+		}
+	}
+	sh.lock.Unlock()
+}
+
+func (p *StringPool) putID(id uint64, s string) {
+	is := p.idShard(id)
+	is.lock.Lock()
+	is.m[id] = s
+	is.lock.Unlock()
+}
+
+// Len 返回字符串数量
+func (p *StringPool) Len() int {
+	n := 0
+	for i := range p.shards {
+		sh := &p.shards[i]
+		sh.lock.RLock()
+		n += sh.m.Count()
+		sh.lock.RUnlock()
+	}
+	return n
+}
+
+// stringPoolRecord 导出/快照中的一条记录
+type stringPoolRecord struct {
+	id    uint64
+	using uint32
+	s     string
+}
+
+// records 按ID顺序返回所有记录, 各分片依次加读锁, 并发修改时不保证全局一致
+func (p *StringPool) records() []stringPoolRecord {
+	var res []stringPoolRecord
+	for i := range p.shards {
+		sh := &p.shards[i]
+		sh.lock.RLock()
+		sh.m.Iter(func(s string, v *stringPoolEntry) bool {
+			res = append(res, stringPoolRecord{id: v.id, using: atomic.LoadUint32(&v.using), s: s})
+			return false
+		})
+		sh.lock.RUnlock()
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].id < res[j].id
+	})
+	return res
+}
+
+// Freeze 返回当前内容的只读快照, 快照的查询无锁, 之后对字符串池的修改不影响快照
+func (p *StringPool) Freeze() *FrozenStringPool {
+	records := p.records()
+	f := &FrozenStringPool{
+		records: records,
+		ids:     make(map[string]uint64, len(records)),
+		strs:    make(map[uint64]string, len(records)),
+	}
+	for _, r := range records {
+		f.ids[r.s] = r.id
+		f.strs[r.id] = r.s
+	}
+	return f
+}
+
+// Export 将所有字符串、ID及引用计数写入 w, 可通过 Import 恢复
+func (p *StringPool) Export(w io.Writer) error {
+	return exportStringPool(w, p.records())
+}
+
+// Import 从 Export 的数据恢复字符串、ID及引用计数, 之后新建的ID从已导入的最大ID继续递增
+// 只能在使用前对空池调用, 否则返回 ErrStringPoolNotEmpty; 数据错误时返回 ErrStringPoolData, 已读取的部分不回滚
+// 非空检查只在开始时进行一次, Import 不能与 Use 等修改操作并发执行
+func (p *StringPool) Import(r io.Reader) error {
+	if p.Len() > 0 {
+		return ErrStringPoolNotEmpty
+	}
+	return importStringPool(r, func(rec stringPoolRecord) error {
+		if rec.id == 0 || rec.using == 0 {
+			return ErrStringPoolData
+		}
+		if _, ok := p.Lookup(rec.id); ok {
+			return ErrStringPoolData
+		}
+		sh := p.shard(rec.s)
+		sh.lock.Lock()
+		defer sh.lock.Unlock()
+		if _, ok := sh.m.Get(rec.s); ok {
+			return ErrStringPoolData
+		}
+		sh.m.Put(rec.s, &stringPoolEntry{id: rec.id, using: rec.using})
+		p.putID(rec.id, rec.s)
+		for {
+			cur := atomic.LoadUint64(&p.idGen)
+			if rec.id <= cur || atomic.CompareAndSwapUint64(&p.idGen, cur, rec.id) {
+				return nil
+			}
+		}
+	})
+}
+
+// FrozenStringPool StringPool 的只读快照, 由 StringPool.Freeze 创建, 并发查询无锁
+type FrozenStringPool struct {
+	records []stringPoolRecord
+	ids     map[string]uint64
+	strs    map[uint64]string
+}
+
+// Peek 查看字符串对应的ID,不存在则返回0
+func (f *FrozenStringPool) Peek(s string) uint64 {
+	return f.ids[s]
+}
+
+// Lookup 查找ID对应的字符串
+func (f *FrozenStringPool) Lookup(id uint64) (string, bool) {
+	s, ok := f.strs[id]
+	return s, ok
+}
+
+// Len 返回字符串数量
+func (f *FrozenStringPool) Len() int {
+	return len(f.records)
+}
+
+// Export 同 StringPool.Export
+func (f *FrozenStringPool) Export(w io.Writer) error {
+	return exportStringPool(w, f.records)
+}
+
+// exportStringPool 数据格式: 魔数, 记录数, 每条记录依次为 ID、引用计数、字符串长度、字符串, 整数均为 uvarint
+func exportStringPool(w io.Writer, records []stringPoolRecord) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(stringPoolMagic)
+	var buf [binary.MaxVarintLen64]byte
+	writeUvarint := func(v uint64) {
+		bw.Write(buf[:binary.PutUvarint(buf[:], v)])
+	}
+	writeUvarint(uint64(len(records)))
+	for _, r := range records {
+		writeUvarint(r.id)
+		writeUvarint(uint64(r.using))
+		writeUvarint(uint64(len(r.s)))
+		bw.WriteString(r.s)
+	}
+	// bufio.Writer 写入出错后后续写入均为空操作, Flush 返回第一个错误
+	return bw.Flush()
+}
+
+func importStringPool(r io.Reader, add func(stringPoolRecord) error) error {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(stringPoolMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != stringPoolMagic {
+		return ErrStringPoolData
+	}
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return ErrStringPoolData
+	}
+	for i := uint64(0); i < n; i++ {
+		var rec stringPoolRecord
+		var using, size uint64
+		if rec.id, err = binary.ReadUvarint(br); err != nil {
+			return ErrStringPoolData
+		}
+		if using, err = binary.ReadUvarint(br); err != nil || using > 1<<32-1 {
+			return ErrStringPoolData
+		}
+		if size, err = binary.ReadUvarint(br); err != nil || size > 1<<31 {
+			return ErrStringPoolData
+		}
+		// 按实际读取的数据增长, 避免损坏的长度字段导致大内存分配
+		var sb []byte
+		if sb, err = io.ReadAll(io.LimitReader(br, int64(size))); err != nil || uint64(len(sb)) != size {
+			return ErrStringPoolData
+		}
+		rec.using, rec.s = uint32(using), string(sb)
+		if err = add(rec); err != nil {
+			return err
 		}
 	}
-	p.lock.Unlock()
+	return nil
 }
@@ -1,6 +1,8 @@
 package pool
 
 import (
+    "bytes"
+    "strconv"
     "sync"
     "sync/atomic"
     "testing"
@@ -190,3 +192,111 @@ func Test_StringPool_UnUseNonExistent(t *testing.T) {
     p.UnUse("nonexistent")
     p.UnUse("nonexistent")
 }
+
+// TestStringPool_Lookup 验证ID到字符串的反向查找, 并发创建不同字符串时双向映射一致
+func Test_StringPool_Lookup(t *testing.T) {
+    p := NewStringPool()
+    if _, ok := p.Lookup(1); ok {
+        t.Fatal("空池 Lookup 应返回 false")
+    }
+
+    var wg sync.WaitGroup
+    ids := make([][]uint64, 8)
+    for g := range ids {
+        g := g
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for i := 0; i < 200; i++ {
+                ids[g] = append(ids[g], p.Use("k"+strconv.Itoa(g)+"-"+strconv.Itoa(i)))
+            }
+        }()
+    }
+    wg.Wait()
+    if p.Len() != 8*200 {
+        t.Fatalf("Len=%d", p.Len())
+    }
+    for g := range ids {
+        for i, id := range ids[g] {
+            s, ok := p.Lookup(id)
+            if want := "k" + strconv.Itoa(g) + "-" + strconv.Itoa(i); !ok || s != want || p.Peek(s) != id {
+                t.Fatalf("Lookup(%d)=%q,%v want %q", id, s, ok, want)
+            }
+        }
+    }
+
+    id := p.Peek("k0-0")
+    p.UnUse("k0-0")
+    if _, ok := p.Lookup(id); ok {
+        t.Fatal("引用归零后 Lookup 应返回 false")
+    }
+}
+
+// TestStringPool_Freeze 快照不受之后修改影响
+func Test_StringPool_Freeze(t *testing.T) {
+    p := NewStringPool()
+    a := p.Use("a")
+    b := p.Use("b")
+    f := p.Freeze()
+    p.UnUse("a")
+    c := p.Use("c")
+
+    if f.Len() != 2 || f.Peek("a") != a || f.Peek("c") != 0 {
+        t.Fatalf("快照内容错误: len=%d a=%d c=%d", f.Len(), f.Peek("a"), f.Peek("c"))
+    }
+    if s, ok := f.Lookup(b); !ok || s != "b" {
+        t.Fatalf("Lookup(%d)=%q,%v", b, s, ok)
+    }
+    if _, ok := f.Lookup(c); ok {
+        t.Fatal("快照之后创建的ID不应存在")
+    }
+}
+
+// TestStringPool_ExportImport 导出后导入到新池, ID与引用计数保持不变, 新ID继续递增
+func Test_StringPool_ExportImport(t *testing.T) {
+    p := NewStringPool()
+    p.Use("a")
+    p.Use("a")
+    b := p.Use("b")
+    p.Use("")
+    p.Use("tmp")
+    p.UnUse("tmp")
+
+    var buf bytes.Buffer
+    if err := p.Export(&buf); err != nil {
+        t.Fatal(err)
+    }
+    var frozen bytes.Buffer
+    if err := p.Freeze().Export(&frozen); err != nil || !bytes.Equal(buf.Bytes(), frozen.Bytes()) {
+        t.Fatalf("快照导出应与原池一致, err=%v", err)
+    }
+
+    q := NewStringPool()
+    if err := q.Import(bytes.NewReader(buf.Bytes())); err != nil {
+        t.Fatal(err)
+    }
+    if q.Len() != 3 || q.Peek("a") != p.Peek("a") || q.Peek("b") != b || q.Peek("") != p.Peek("") {
+        t.Fatalf("导入内容错误: len=%d", q.Len())
+    }
+    // 引用计数保留: "a" 需要两次 UnUse 才删除
+    q.UnUse("a")
+    if q.Peek("a") == 0 {
+        t.Fatal("引用计数应保留")
+    }
+    q.UnUse("a")
+    if q.Peek("a") != 0 {
+        t.Fatal("引用归零后应删除")
+    }
+    if id := q.Use("new"); id <= p.Peek("") {
+        t.Fatalf("新ID应大于已导入的ID, got %d", id)
+    }
+
+    if err := q.Import(bytes.NewReader(buf.Bytes())); err != ErrStringPoolNotEmpty {
+        t.Fatalf("err=%v", err)
+    }
+    for _, data := range [][]byte{nil, []byte("XXXX"), buf.Bytes()[:buf.Len()-1]} {
+        if err := NewStringPool().Import(bytes.NewReader(data)); err != ErrStringPoolData {
+            t.Fatalf("err=%v", err)
+        }
+    }
+}
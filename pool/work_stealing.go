@@ -0,0 +1,375 @@
+package pool
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/mzzsfy/go-util/concurrent"
+	gounsafe "github.com/mzzsfy/go-util/unsafe"
+)
+
+const (
+	// localRingSize 每个本地队列的容量, 满时溢出到全局队列
+	localRingSize = 256
+	// stealBatch 单次最多窃取的任务数
+	stealBatch = 32
+	// globalCheckInterval 每执行多少个任务优先检查一次全局队列, 避免本地队列繁忙时全局队列饥饿
+	globalCheckInterval = 61
+)
+
+// WithWorkStealing 启用工作窃取调度
+//
+// 每个 worker 持有一个本地队列, worker 中提交的子任务进入该 worker 自己的本地队列,
+// 其他 goroutine 提交的任务按 goroutine id 分散到各 worker 的本地队列, 本地队列满或还没有 worker 时进入全局溢出队列;
+// worker 依次从自己的本地队列、全局队列获取任务, 都为空时从其他本地队列窃取一半任务, 降低 worker 较多时对单一队列的竞争
+func WithWorkStealing() Option {
+	return func(gopool *GoPool) {
+		gopool.workStealing = true
+	}
+}
+
+// localRing 本地环形队列, 持有者与窃取者均从队头取任务以保证先提交先执行
+// 入队与出队时同步维护 pending 计数; head/tail 在锁内修改, 使用原子写以便窃取方无锁判断是否为空
+type localRing struct {
+	mu     sync.Mutex
+	buf    [localRingSize]*task
+	head   uint32
+	tail   uint32
+	closed bool
+	// owned 是否被 worker 持有, 由 stealScheduler.mu 保护
+	owned bool
+	// idx 在 stealScheduler.rings 中的下标
+	idx int32
+	_   [gounsafe.CachePaddingLength]byte
+}
+
+type pushResult int
+
+const (
+	pushOk pushResult = iota
+	pushFull
+	pushClosed
+)
+
+func (r *localRing) push(t *task, pending *int64) pushResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return pushClosed
+	}
+	if r.tail-r.head == localRingSize {
+		return pushFull
+	}
+	r.buf[r.tail%localRingSize] = t
+	atomic.StoreUint32(&r.tail, r.tail+1)
+	atomic.AddInt64(pending, 1)
+	return pushOk
+}
+
+// pop 取出队头任务, 队列为空时返回nil
+func (r *localRing) pop(pending *int64) *task {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.head == r.tail {
+		return nil
+	}
+	t := r.buf[r.head%localRingSize]
+	r.buf[r.head%localRingSize] = nil
+	atomic.StoreUint32(&r.head, r.head+1)
+	atomic.AddInt64(pending, -1)
+	return t
+}
+
+// empty 无锁判断队列是否为空, 结果可能已过时
+func (r *localRing) empty() bool {
+	return atomic.LoadUint32(&r.head) == atomic.LoadUint32(&r.tail)
+}
+
+// steal 从队头取出一半(至多 len(dst))任务到 dst, 返回任务数
+func (r *localRing) steal(dst []*task, pending *int64) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := int(r.tail-r.head+1) / 2
+	if n > len(dst) {
+		n = len(dst)
+	}
+	head := r.head
+	for i := 0; i < n; i++ {
+		dst[i] = r.buf[head%localRingSize]
+		r.buf[head%localRingSize] = nil
+		head++
+	}
+	atomic.StoreUint32(&r.head, head)
+	atomic.AddInt64(pending, -int64(n))
+	return n
+}
+
+// stealScheduler 工作窃取调度器
+//
+// 本地队列随 worker 创建, worker 退出时归还给调度器复用而不销毁, 其中残留的任务仍可被其他 worker 窃取,
+// 因此本地队列数量不超过同时存在的 worker 数量的峰值
+type stealScheduler struct {
+	mu sync.Mutex
+	// rings 所有本地队列(*[]*localRing), 写时复制, 窃取与分发时无锁读取
+	rings unsafe.Pointer
+	// owners worker 的 goroutine id 到其本地队列
+	owners sync.Map
+	// pending 所有本地队列中的任务数
+	pending int64
+	// hint 最近由非 worker 提交任务的本地队列下标, 窃取时优先检查
+	hint int32
+	// idle 空闲等待唤醒的 worker 数量
+	idle int32
+	// wake 唤醒空闲 worker 的令牌, 多余的令牌只会造成一次空转
+	wake chan struct{}
+	// quit 关闭时 close, 唤醒所有空闲 worker
+	quit   chan struct{}
+	closed int32
+}
+
+func newStealScheduler(maxWorks int) *stealScheduler {
+	s := &stealScheduler{
+		wake: make(chan struct{}, maxWorks),
+		quit: make(chan struct{}),
+	}
+	rings := []*localRing{}
+	atomic.StorePointer(&s.rings, unsafe.Pointer(&rings))
+	return s
+}
+
+// loadRings 返回当前所有本地队列, 返回的切片不可修改
+func (s *stealScheduler) loadRings() []*localRing {
+	return *(*[]*localRing)(atomic.LoadPointer(&s.rings))
+}
+
+// acquire 为 goroutine id 为 gid 的 worker 分配本地队列, 优先复用已退出 worker 的队列
+func (s *stealScheduler) acquire(gid int64) *localRing {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rings := s.loadRings()
+	var own *localRing
+	for _, r := range rings {
+		if !r.owned {
+			own = r
+			break
+		}
+	}
+	if own == nil {
+		own = &localRing{closed: atomic.LoadInt32(&s.closed) == 1, idx: int32(len(rings))}
+		next := make([]*localRing, len(rings), len(rings)+1)
+		copy(next, rings)
+		next = append(next, own)
+		atomic.StorePointer(&s.rings, unsafe.Pointer(&next))
+	}
+	own.owned = true
+	s.owners.Store(gid, own)
+	return own
+}
+
+// release worker 退出时归还本地队列, 队列中残留的任务由其他 worker 窃取
+func (s *stealScheduler) release(gid int64, own *localRing) {
+	s.owners.Delete(gid)
+	s.mu.Lock()
+	own.owned = false
+	s.mu.Unlock()
+}
+
+// target 返回提交任务应进入的本地队列: worker 中提交时为该 worker 的队列, 否则按 goroutine id 选择, 没有本地队列时返回nil
+func (s *stealScheduler) target() *localRing {
+	gid := concurrent.GoID()
+	if own, ok := s.owners.Load(gid); ok {
+		return own.(*localRing)
+	}
+	rings := s.loadRings()
+	if len(rings) == 0 {
+		return nil
+	}
+	r := rings[uint64(gid)%uint64(len(rings))]
+	// 被唤醒的 worker 不一定是该队列的持有者, 记录位置使窃取不必逐个检查; 先读后写避免多个提交方反复写同一缓存行
+	if atomic.LoadInt32(&s.hint) != r.idx {
+		atomic.StoreInt32(&s.hint, r.idx)
+	}
+	return r
+}
+
+func (s *stealScheduler) close() {
+	s.mu.Lock()
+	atomic.StoreInt32(&s.closed, 1)
+	for _, r := range s.loadRings() {
+		r.mu.Lock()
+		r.closed = true
+		r.mu.Unlock()
+	}
+	s.mu.Unlock()
+	close(s.quit)
+}
+
+func (s *stealScheduler) hasWork(q concurrent.ClosableQueue[*task]) bool {
+	return atomic.LoadInt64(&s.pending) > 0 || q.Size() > 0
+}
+
+// dispatchStealing 任务放入本地队列, 已满或还没有本地队列时放入全局队列, 然后唤醒或创建 worker
+func (p *GoPool) dispatchStealing(ref *taskQueueRef, t *task) error {
+	s := ref.ws
+	res := pushFull
+	if r := s.target(); r != nil {
+		res = r.push(t, &s.pending)
+	}
+	switch res {
+	case pushClosed:
+		return concurrent.ErrQueueClosed
+	case pushFull:
+		if err := p.offer(ref, t); err != nil {
+			return err
+		}
+	}
+	// 与 park 中先增加 idle 再检查任务构成对称的检查顺序, 保证不会丢失唤醒
+	if atomic.LoadInt32(&s.idle) > 0 {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+		return nil
+	}
+	p.tryAddWorker(ref)
+	return nil
+}
+
+// stealWorker 工作窃取模式下的 worker
+type stealWorker struct {
+	s     *stealScheduler
+	q     concurrent.ClosableQueue[*task]
+	own   *localRing
+	batch [stealBatch]*task
+	// 窃取到尚未执行的任务为 batch[next:n]
+	next, n int
+	tick    uint32
+	timer   *time.Timer
+}
+
+// take 获取下一个任务, 无任务时返回nil
+func (w *stealWorker) take() *task {
+	if w.next < w.n {
+		t := w.batch[w.next]
+		w.batch[w.next] = nil
+		w.next++
+		return t
+	}
+	w.tick++
+	if w.tick%globalCheckInterval == 0 {
+		if t, ok := w.q.Dequeue(); ok {
+			return t
+		}
+	}
+	if t := w.own.pop(&w.s.pending); t != nil {
+		return t
+	}
+	if t, ok := w.q.Dequeue(); ok {
+		return t
+	}
+	return w.steal()
+}
+
+// steal 从其他本地队列窃取任务, 第一个直接返回, 其余暂存在 batch 中
+func (w *stealWorker) steal() *task {
+	// 本地队列数量随 worker 数量增长, 没有待执行任务时跳过逐个加锁检查
+	if atomic.LoadInt64(&w.s.pending) <= 0 {
+		return nil
+	}
+	rings := w.s.loadRings()
+	start := int(atomic.LoadInt32(&w.s.hint))
+	for i := range rings {
+		r := rings[(start+i)%len(rings)]
+		if r == w.own || r.empty() {
+			continue
+		}
+		if n := r.steal(w.batch[:], &w.s.pending); n > 0 {
+			t := w.batch[0]
+			w.batch[0] = nil
+			w.next, w.n = 1, n
+			return t
+		}
+	}
+	return nil
+}
+
+// park 无任务时等待唤醒, 返回 false 表示 worker 应退出(空闲超时, 或已关闭且没有剩余任务), 此时已归还 worker 名额
+func (w *stealWorker) park(p *GoPool) bool {
+	s := w.s
+	atomic.AddInt32(&s.idle, 1)
+	closed := atomic.LoadInt32(&s.closed) == 1
+	if s.hasWork(w.q) {
+		atomic.AddInt32(&s.idle, -1)
+		// 任务计数已增加但还不能取出(如全局队列的入队尚未完成)时让出 CPU, 避免空闲 worker 自旋拖慢提交方
+		runtime.Gosched()
+		return true
+	}
+	if closed {
+		atomic.AddInt32(&s.idle, -1)
+		atomic.AddInt32(&p.works, -1)
+		return false
+	}
+	if w.timer == nil {
+		w.timer = time.NewTimer(p.idleTimeout)
+	} else {
+		w.timer.Reset(p.idleTimeout)
+	}
+	select {
+	case <-s.wake:
+	case <-s.quit:
+	case <-w.timer.C:
+		// 先减少 idle 与 worker 数量再检查任务, 提交方看到 idle 为0时会创建新 worker;
+		// 检查到任务但名额已被新 worker 占用时, 由新 worker 处理
+		atomic.AddInt32(&s.idle, -1)
+		if !p.retire() {
+			// 保留最小空闲 worker
+			return true
+		}
+		return s.hasWork(w.q) && p.reserveWorker()
+	}
+	if !w.timer.Stop() {
+		// 非阻塞读取, 兼容 go1.23 之后 Stop 返回时通道中不再保留过期值的语义
+		select {
+		case <-w.timer.C:
+		default:
+		}
+	}
+	atomic.AddInt32(&s.idle, -1)
+	return true
+}
+
+// goRunStealing 工作窃取模式的 worker 主循环
+func (p *GoPool) goRunStealing(ref *taskQueueRef) {
+	// worker 名额在 park 返回 false 时归还
+	defer p.wg.Done()
+	gid := concurrent.GoID()
+	w := &stealWorker{s: ref.ws, q: ref.ClosableQueue, own: ref.ws.acquire(gid)}
+	defer func() {
+		ref.ws.release(gid, w.own)
+		if w.timer != nil {
+			w.timer.Stop()
+		}
+	}()
+	for {
+		if t := w.take(); t != nil {
+			p.executeTask(t)
+			if w.next >= w.n && p.shrink() {
+				// SetMaxWorks 调小后退出, 本地队列中的任务交给其他 worker
+				if w.s.hasWork(w.q) && atomic.LoadInt32(&w.s.idle) > 0 {
+					select {
+					case w.s.wake <- struct{}{}:
+					default:
+					}
+				}
+				return
+			}
+			continue
+		}
+		if !w.park(p) {
+			return
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package pool
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mzzsfy/go-util/concurrent"
+)
+
+func Test_localRing(t *testing.T) {
+	var pending int64
+	r := &localRing{}
+	for i := 0; i < localRingSize; i++ {
+		if r.push(&task{}, &pending) != pushOk {
+			t.Fatal("push失败", i)
+		}
+	}
+	if r.push(&task{}, &pending) != pushFull || pending != localRingSize {
+		t.Fatal("队列应已满", pending)
+	}
+	var dst [stealBatch]*task
+	if n := r.steal(dst[:4], &pending); n != 4 || pending != localRingSize-4 {
+		t.Fatal("窃取数量受dst限制", n, pending)
+	}
+	for r.pop(&pending) != nil {
+	}
+	r.push(&task{}, &pending)
+	r.push(&task{}, &pending)
+	r.push(&task{}, &pending)
+	if n := r.steal(dst[:], &pending); n != 2 || pending != 1 {
+		t.Fatal("应窃取一半(向上取整)", n, pending)
+	}
+	r.closed = true
+	if r.push(&task{}, &pending) != pushClosed {
+		t.Fatal("关闭后push应失败")
+	}
+}
+
+func Test_WorkStealing_Go(t *testing.T) {
+	p := NewGopool(WithWorkStealing(), WithMaxWorks(8), WithIdleTimeout(20*time.Millisecond))
+
+	const producers, n = 8, 2000
+	var executed int64
+	var wg sync.WaitGroup
+	wg.Add(producers * n * 2)
+	for i := 0; i < producers; i++ {
+		go func() {
+			for j := 0; j < n; j++ {
+				err := p.Go(func() {
+					atomic.AddInt64(&executed, 1)
+					// 在 worker 中提交子任务
+					if err := p.Go(func() {
+						atomic.AddInt64(&executed, 1)
+						wg.Done()
+					}); err != nil {
+						t.Error(err)
+						wg.Done()
+					}
+					wg.Done()
+				})
+				if err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if executed != producers*n*2 {
+		t.Fatal("executed", executed)
+	}
+	if c := p.TaskCount(); c != 0 {
+		t.Fatal("TaskCount", c)
+	}
+
+	// 空闲超时后 worker 全部退出, 新任务仍能执行
+	for i := 0; p.WorkerCount() != 0; i++ {
+		if i > 1000 {
+			t.Fatal("worker未空闲退出", p.WorkerCount())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	done := make(chan struct{})
+	if err := p.Go(func() { close(done) }); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	if n := len(p.ref().ws.loadRings()); n == 0 || n > 8 {
+		t.Fatal("本地队列数量应不超过 worker 数量峰值", n)
+	}
+	p.Shutdown()
+}
+
+func Test_WorkStealing_Shutdown_NoTaskDrop(t *testing.T) {
+	t.Parallel()
+	const n = 2000
+	var executed, accepted int32
+	// 单个 worker 且任务较慢, 关闭时本地队列与全局溢出队列中都有残留任务
+	p := NewGopool(WithWorkStealing(), WithMaxWorks(1))
+	for i := 0; i < n; i++ {
+		if p.Go(func() {
+			if atomic.AddInt32(&executed, 1)%200 == 0 {
+				time.Sleep(time.Millisecond)
+			}
+		}) == nil {
+			accepted++
+		}
+	}
+	if !p.Shutdown() {
+		t.Fatal("Shutdown失败")
+	}
+	if executed != accepted || accepted != n {
+		t.Fatal("executed", executed, "accepted", accepted)
+	}
+	if p.Go(func() {}) != ErrPoolClosed {
+		t.Fatal("关闭后提交应失败")
+	}
+	if !p.Restart() {
+		t.Fatal("Restart失败")
+	}
+	done := make(chan struct{})
+	if err := p.Go(func() { close(done) }); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	p.Shutdown()
+}
+
+func Test_WorkStealing_BoundedQueue(t *testing.T) {
+	t.Parallel()
+	var executed int32
+	p := NewGopool(WithWorkStealing(), WithMaxWorks(2), WithQueueCapacity(16))
+	var wg sync.WaitGroup
+	const n = localRingSize * 4
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		if err := p.Go(func() {
+			atomic.AddInt32(&executed, 1)
+			wg.Done()
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	p.Shutdown()
+	if executed != n {
+		t.Fatal("executed", executed)
+	}
+}
+
+func Test_WorkStealing_OwnDequeAndQueued(t *testing.T) {
+	t.Parallel()
+	p := NewGopool(WithWorkStealing(), WithMaxWorks(2))
+	const n = 100
+	var executed int32
+	var wg sync.WaitGroup
+	wg.Add(n)
+	pushed := make(chan *localRing)
+	release := make(chan struct{})
+	if err := p.Go(func() {
+		// worker 中提交的子任务进入该 worker 自己的本地队列
+		own, _ := p.ref().ws.owners.Load(concurrent.GoID())
+		for i := 0; i < n; i++ {
+			if err := p.Go(func() {
+				atomic.AddInt32(&executed, 1)
+				wg.Done()
+			}); err != nil {
+				t.Error(err)
+				wg.Done()
+			}
+		}
+		pushed <- own.(*localRing)
+		<-release
+	}); err != nil {
+		t.Fatal(err)
+	}
+	own := <-pushed
+	if own == nil {
+		t.Fatal("worker 应持有本地队列")
+	}
+	// 持有者阻塞, 子任务只能被另一个 worker 窃取执行
+	wg.Wait()
+	if s := p.Stats(); s.Queued != 0 {
+		t.Fatalf("窃取后 Queued 应为0, stats=%+v", s)
+	}
+	own.mu.Lock()
+	size := own.tail - own.head
+	own.mu.Unlock()
+	if size != 0 || executed != n {
+		t.Fatal("size", size, "executed", executed)
+	}
+	close(release)
+	p.Shutdown()
+	if c := p.TaskCount(); c != 0 {
+		t.Fatal("TaskCount", c)
+	}
+}
+
+func Test_WorkStealing_DrainKeepsPending(t *testing.T) {
+	t.Parallel()
+	// 直接向已归还的本地队列放入任务, 没有 worker 时由 Shutdown 的 drainQueue 执行
+	p := NewGopool(WithWorkStealing(), WithMaxWorks(1))
+	ref := p.ref()
+	r := ref.ws.acquire(-1)
+	ref.ws.release(-1, r)
+	var executed int32
+	for i := 0; i < 10; i++ {
+		r.push(&task{fn: func() { atomic.AddInt32(&executed, 1) }}, &ref.ws.pending)
+	}
+	if s := p.Stats(); s.Queued != 10 {
+		t.Fatalf("stats=%+v", s)
+	}
+	p.Shutdown()
+	if s := p.Stats(); s.Queued != 0 || executed != 10 {
+		t.Fatalf("executed=%d stats=%+v", executed, s)
+	}
+}
@@ -19,25 +19,65 @@ type TimedCache[K comparable, V any] interface {
     TTL(key K) time.Duration
 }
 
+// CacheWrap 缓存包装, GetOr 未命中时按key合并并发加载: 同一key只有一个调用方执行def, 其余等待并共享结果,
+// 不同key的加载互不阻塞
 type CacheWrap[K comparable, V any] struct {
-    lock sync.Mutex
+    lock  sync.Mutex
+    calls map[K]*cacheCall[V]
     Cache[K, V]
 }
 
+// cacheCall 进行中的加载
+type cacheCall[V any] struct {
+    wg sync.WaitGroup
+    v  V
+    // def 正常返回后为true, def panic 时等待者重新尝试加载
+    ok bool
+}
+
 func (c *CacheWrap[K, V]) GetOr(key K, def func() V) V {
-    get, b := c.Cache.Get(key)
-    if b {
-        return get
-    }
-    c.lock.Lock()
-    if get, b = c.Cache.Get(key); b {
+    for {
+        get, b := c.Cache.Get(key)
+        if b {
+            return get
+        }
+        c.lock.Lock()
+        if get, b = c.Cache.Get(key); b {
+            c.lock.Unlock()
+            return get
+        }
+        if call, ok := c.calls[key]; ok {
+            c.lock.Unlock()
+            call.wg.Wait()
+            if call.ok {
+                return call.v
+            }
+            continue
+        }
+        if c.calls == nil {
+            c.calls = map[K]*cacheCall[V]{}
+        }
+        call := &cacheCall[V]{}
+        call.wg.Add(1)
+        c.calls[key] = call
         c.lock.Unlock()
-        return get
+        return c.load(key, call, def)
     }
-    v := def()
-    c.Cache.Set(key, v)
-    c.lock.Unlock()
-    return v
+}
+
+func (c *CacheWrap[K, V]) load(key K, call *cacheCall[V], def func() V) V {
+    defer func() {
+        c.lock.Lock()
+        if call.ok {
+            c.Cache.Set(key, call.v)
+        }
+        delete(c.calls, key)
+        c.lock.Unlock()
+        call.wg.Done()
+    }()
+    call.v = def()
+    call.ok = true
+    return call.v
 }
 
 func NewCacheWrap[K comparable, V any](cache Cache[K, V]) *CacheWrap[K, V] {
@@ -0,0 +1,160 @@
+package storage
+
+// NewARCCache 创建自适应替换(ARC)缓存
+//
+// 同时维护最近访问一次(T1)与访问多次(T2)的条目, 以及两者最近被淘汰条目的key(幽灵条目B1/B2),
+// 根据幽灵条目的命中情况自适应调整两者的容量分配, 兼顾访问频率且能抵抗一次性的批量扫描;
+// 设置权重时按权重之和代替条目数计算
+func NewARCCache[K comparable, V any](capacity int64, opts ...CacheOpt[K, V]) *BoundedCache[K, V] {
+    return newBoundedCache(capacity, func(capacity int64) cachePolicy[K, V] {
+        p := &arcPolicy[K, V]{capacity: capacity}
+        p.clear()
+        return p
+    }, opts)
+}
+
+type arcPolicy[K comparable, V any] struct {
+    capacity int64
+    // target T1 的目标容量
+    target int64
+    // items T1/T2 中的条目, ghosts B1/B2 中的幽灵条目(不持有值)
+    items  map[K]*cacheEntry[K, V]
+    ghosts map[K]*cacheEntry[K, V]
+    t1, t2 entryList[K, V]
+    b1, b2 entryList[K, V]
+}
+
+func (p *arcPolicy[K, V]) get(key K) (V, bool) {
+    e, ok := p.items[key]
+    if !ok {
+        var zero V
+        return zero, false
+    }
+    if e.list == &p.t1 {
+        p.t1.remove(e)
+        p.t2.pushFront(e)
+    } else {
+        p.t2.moveToFront(e)
+    }
+    return e.value, true
+}
+
+func (p *arcPolicy[K, V]) set(key K, value V, weight int64, ev *[]cacheKV[K, V]) {
+    if e, ok := p.items[key]; ok {
+        // 覆盖写入视为再次访问, 先移出避免淘汰时选中自身
+        e.list.remove(e)
+        delete(p.items, key)
+        if weight > p.capacity {
+            return
+        }
+        e.value, e.weight = value, weight
+        p.replace(weight, false, ev)
+        p.t2.pushFront(e)
+        p.items[key] = e
+        return
+    }
+    if weight > p.capacity {
+        return
+    }
+    if g, ok := p.ghosts[key]; ok {
+        // 幽灵命中: 最近被淘汰的条目再次写入, 说明对应的一侧容量不足
+        inB2 := g.list == &p.b2
+        if inB2 {
+            delta := weight
+            if p.b2.weight < p.b1.weight {
+                delta = weight * p.b1.weight / p.b2.weight
+            }
+            if p.target -= delta; p.target < 0 {
+                p.target = 0
+            }
+        } else {
+            delta := weight
+            if p.b1.weight < p.b2.weight {
+                delta = weight * p.b2.weight / p.b1.weight
+            }
+            if p.target += delta; p.target > p.capacity {
+                p.target = p.capacity
+            }
+        }
+        g.list.remove(g)
+        delete(p.ghosts, key)
+        g.value, g.weight = value, weight
+        p.replace(weight, inB2, ev)
+        p.t2.pushFront(g)
+        p.items[key] = g
+        p.trimGhosts()
+        return
+    }
+    p.replace(weight, false, ev)
+    e := &cacheEntry[K, V]{key: key, value: value, weight: weight}
+    p.t1.pushFront(e)
+    p.items[key] = e
+    p.trimGhosts()
+}
+
+// replace 淘汰条目直到能容纳 weight: T1 超过目标容量时淘汰 T1 中最久未使用的, 否则淘汰 T2 的, 被淘汰的条目转为幽灵条目
+func (p *arcPolicy[K, V]) replace(weight int64, inB2 bool, ev *[]cacheKV[K, V]) {
+    for p.t1.weight+p.t2.weight+weight > p.capacity {
+        var e *cacheEntry[K, V]
+        ghost := &p.b2
+        if p.t1.len > 0 && (p.t1.weight > p.target || inB2 && p.t1.weight == p.target || p.t2.len == 0) {
+            e, ghost = p.t1.back(), &p.b1
+        } else {
+            e = p.t2.back()
+        }
+        e.list.remove(e)
+        delete(p.items, e.key)
+        if ev != nil {
+            *ev = append(*ev, cacheKV[K, V]{e.key, e.value})
+        }
+        var zero V
+        e.value = zero
+        ghost.pushFront(e)
+        p.ghosts[e.key] = e
+    }
+}
+
+// trimGhosts 限制幽灵条目: T1+B1 不超过容量, 全部条目不超过两倍容量
+func (p *arcPolicy[K, V]) trimGhosts() {
+    for p.b1.len > 0 && p.t1.weight+p.b1.weight > p.capacity {
+        p.dropGhost(&p.b1)
+    }
+    for p.b2.len > 0 && p.t1.weight+p.t2.weight+p.b1.weight+p.b2.weight > 2*p.capacity {
+        p.dropGhost(&p.b2)
+    }
+}
+
+func (p *arcPolicy[K, V]) dropGhost(l *entryList[K, V]) {
+    g := l.back()
+    l.remove(g)
+    delete(p.ghosts, g.key)
+}
+
+func (p *arcPolicy[K, V]) delete(key K) {
+    if e, ok := p.items[key]; ok {
+        e.list.remove(e)
+        delete(p.items, key)
+    }
+    if g, ok := p.ghosts[key]; ok {
+        g.list.remove(g)
+        delete(p.ghosts, key)
+    }
+}
+
+func (p *arcPolicy[K, V]) clear() {
+    p.target = 0
+    p.items = map[K]*cacheEntry[K, V]{}
+    p.ghosts = map[K]*cacheEntry[K, V]{}
+    p.t1.init()
+    p.t2.init()
+    p.b1.init()
+    p.b2.init()
+}
+
+func (p *arcPolicy[K, V]) len() int {
+    return len(p.items)
+}
+
+func (p *arcPolicy[K, V]) weight() int64 {
+    return p.t1.weight + p.t2.weight
+}
@@ -0,0 +1,259 @@
+package storage
+
+import (
+    "runtime"
+    "sync"
+
+    "github.com/mzzsfy/go-util/unsafe"
+)
+
+// BoundedCache 按容量淘汰的并发安全缓存, 实现 Cache 接口, 可直接用于 NewCacheWrap
+//
+// key 按哈希分片, 每个分片独立加锁并按淘汰策略(LRU/LFU/ARC)管理, 总容量平均分配到各分片,
+// 淘汰顺序只在分片内精确
+//
+// 通过 NewLRUCache, NewLFUCache, NewARCCache 创建
+type BoundedCache[K comparable, V any] struct {
+    shards   []cacheShard[K, V]
+    mask     uint64
+    hash     unsafe.Hasher[K]
+    capacity int64
+    weigher  func(K, V) int64
+    onEvict  func(K, V)
+}
+
+type cacheShard[K comparable, V any] struct {
+    mu sync.Mutex
+    p  cachePolicy[K, V]
+    _  [unsafe.CachePaddingLength]byte
+}
+
+// cachePolicy 单个分片的淘汰策略, 由分片锁保护
+type cachePolicy[K comparable, V any] interface {
+    get(key K) (V, bool)
+    // set 写入并按需淘汰, ev 不为nil时追加因容量被淘汰的条目
+    set(key K, value V, weight int64, ev *[]cacheKV[K, V])
+    delete(key K)
+    clear()
+    len() int
+    weight() int64
+}
+
+type cacheKV[K comparable, V any] struct {
+    k K
+    v V
+}
+
+type cacheConfig[K comparable, V any] struct {
+    shards  int
+    weigher func(K, V) int64
+    onEvict func(K, V)
+}
+
+// CacheOpt BoundedCache 选项
+type CacheOpt[K comparable, V any] func(*cacheConfig[K, V])
+
+// WithCacheWeigher 设置条目权重, 容量按权重之和计算, 默认每个条目权重为1; 权重小于1时按1计算
+// 权重超过单个分片容量的条目不会被缓存
+func WithCacheWeigher[K comparable, V any](weigher func(K, V) int64) CacheOpt[K, V] {
+    return func(c *cacheConfig[K, V]) {
+        c.weigher = weigher
+    }
+}
+
+// WithCacheShards 设置分片数, 向上取整为2的幂; 默认按 CPU 核数, 并保证每个分片的容量不过小
+func WithCacheShards[K comparable, V any](n int) CacheOpt[K, V] {
+    return func(c *cacheConfig[K, V]) {
+        c.shards = n
+    }
+}
+
+// WithCacheOnEvict 设置因容量不足被淘汰时的回调, 在锁外调用; Delete、Clear 与覆盖写入不会触发
+func WithCacheOnEvict[K comparable, V any](onEvict func(K, V)) CacheOpt[K, V] {
+    return func(c *cacheConfig[K, V]) {
+        c.onEvict = onEvict
+    }
+}
+
+// minShardCapacity 自动选择分片数时每个分片的最小容量
+const minShardCapacity = 64
+
+func newBoundedCache[K comparable, V any](capacity int64, newPolicy func(capacity int64) cachePolicy[K, V], opts []CacheOpt[K, V]) *BoundedCache[K, V] {
+    if capacity < 1 {
+        capacity = 1
+    }
+    cfg := cacheConfig[K, V]{}
+    for _, opt := range opts {
+        opt(&cfg)
+    }
+    n := cfg.shards
+    if n <= 0 {
+        n = runtime.GOMAXPROCS(0) * 4
+        for n > 1 && capacity/int64(n) < minShardCapacity {
+            n >>= 1
+        }
+    }
+    size := 1
+    for size < n {
+        size <<= 1
+    }
+    c := &BoundedCache[K, V]{
+        shards:   make([]cacheShard[K, V], size),
+        mask:     uint64(size - 1),
+        hash:     unsafe.NewHasher[K](),
+        capacity: capacity,
+        weigher:  cfg.weigher,
+        onEvict:  cfg.onEvict,
+    }
+    shardCap := (capacity + int64(size) - 1) / int64(size)
+    for i := range c.shards {
+        c.shards[i].p = newPolicy(shardCap)
+    }
+    return c
+}
+
+func (c *BoundedCache[K, V]) shard(key K) *cacheShard[K, V] {
+    return &c.shards[c.hash.Hash(key)&c.mask]
+}
+
+func (c *BoundedCache[K, V]) Get(key K) (V, bool) {
+    s := c.shard(key)
+    s.mu.Lock()
+    v, ok := s.p.get(key)
+    s.mu.Unlock()
+    return v, ok
+}
+
+func (c *BoundedCache[K, V]) Set(key K, value V) {
+    weight := int64(1)
+    if c.weigher != nil {
+        if weight = c.weigher(key, value); weight < 1 {
+            weight = 1
+        }
+    }
+    s := c.shard(key)
+    if c.onEvict == nil {
+        s.mu.Lock()
+        s.p.set(key, value, weight, nil)
+        s.mu.Unlock()
+        return
+    }
+    var evicted []cacheKV[K, V]
+    s.mu.Lock()
+    s.p.set(key, value, weight, &evicted)
+    s.mu.Unlock()
+    for _, e := range evicted {
+        c.onEvict(e.k, e.v)
+    }
+}
+
+func (c *BoundedCache[K, V]) Delete(key K) {
+    s := c.shard(key)
+    s.mu.Lock()
+    s.p.delete(key)
+    s.mu.Unlock()
+}
+
+func (c *BoundedCache[K, V]) Clear() {
+    for i := range c.shards {
+        s := &c.shards[i]
+        s.mu.Lock()
+        s.p.clear()
+        s.mu.Unlock()
+    }
+}
+
+// Size 返回条目数量
+func (c *BoundedCache[K, V]) Size() int {
+    n := 0
+    for i := range c.shards {
+        s := &c.shards[i]
+        s.mu.Lock()
+        n += s.p.len()
+        s.mu.Unlock()
+    }
+    return n
+}
+
+// Weight 返回当前条目的权重之和
+func (c *BoundedCache[K, V]) Weight() int64 {
+    var w int64
+    for i := range c.shards {
+        s := &c.shards[i]
+        s.mu.Lock()
+        w += s.p.weight()
+        s.mu.Unlock()
+    }
+    return w
+}
+
+// Capacity 返回总容量
+func (c *BoundedCache[K, V]) Capacity() int64 {
+    return c.capacity
+}
+
+// cacheEntry 缓存条目, 同时作为双向链表节点
+type cacheEntry[K comparable, V any] struct {
+    key        K
+    value      V
+    weight     int64
+    prev, next *cacheEntry[K, V]
+    // LFU 使用
+    freq  uint32
+    tick  uint64
+    index int
+    // ARC 使用, 所在链表
+    list *entryList[K, V]
+}
+
+// entryList 带哨兵的双向链表, 队头为最近使用
+type entryList[K comparable, V any] struct {
+    root   cacheEntry[K, V]
+    len    int
+    weight int64
+}
+
+func (l *entryList[K, V]) init() {
+    l.root.prev = &l.root
+    l.root.next = &l.root
+    l.len = 0
+    l.weight = 0
+}
+
+func (l *entryList[K, V]) pushFront(e *cacheEntry[K, V]) {
+    e.prev = &l.root
+    e.next = l.root.next
+    l.root.next.prev = e
+    l.root.next = e
+    e.list = l
+    l.len++
+    l.weight += e.weight
+}
+
+func (l *entryList[K, V]) remove(e *cacheEntry[K, V]) {
+    e.prev.next = e.next
+    e.next.prev = e.prev
+    e.prev, e.next, e.list = nil, nil, nil
+    l.len--
+    l.weight -= e.weight
+}
+
+func (l *entryList[K, V]) moveToFront(e *cacheEntry[K, V]) {
+    if l.root.next == e {
+        return
+    }
+    e.prev.next = e.next
+    e.next.prev = e.prev
+    e.prev = &l.root
+    e.next = l.root.next
+    l.root.next.prev = e
+    l.root.next = e
+}
+
+// back 返回最久未使用的条目, 链表为空时返回nil
+func (l *entryList[K, V]) back() *cacheEntry[K, V] {
+    if l.len == 0 {
+        return nil
+    }
+    return l.root.prev
+}
@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+type boundedCacheCase struct {
+	name string
+	new  func(capacity int64, opts ...CacheOpt[int, int]) *BoundedCache[int, int]
+}
+
+var boundedCacheCases = []boundedCacheCase{
+	{"lru", NewLRUCache[int, int]},
+	{"lfu", NewLFUCache[int, int]},
+	{"arc", NewARCCache[int, int]},
+}
+
+// TestBoundedCache_Basic 各实现的基本读写与容量限制
+func Test_BoundedCache_Basic(t *testing.T) {
+	for _, c := range boundedCacheCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			var evicted int
+			cache := c.new(100, WithCacheShards[int, int](1), WithCacheOnEvict(func(k, v int) {
+				if k != v {
+					t.Errorf("淘汰回调 k=%d v=%d", k, v)
+				}
+				evicted++
+			}))
+			for i := 0; i < 1000; i++ {
+				cache.Set(i, i)
+				if v, ok := cache.Get(i); !ok || v != i {
+					t.Fatalf("Get(%d)=%d,%v", i, v, ok)
+				}
+			}
+			if cache.Size() != 100 || cache.Weight() != 100 || evicted != 900 {
+				t.Fatalf("size=%d weight=%d evicted=%d", cache.Size(), cache.Weight(), evicted)
+			}
+
+			cache.Set(999, -1)
+			if v, _ := cache.Get(999); v != -1 || cache.Size() != 100 {
+				t.Fatalf("覆盖写入: v=%d size=%d", v, cache.Size())
+			}
+			cache.Delete(999)
+			if _, ok := cache.Get(999); ok || cache.Size() != 99 {
+				t.Fatal("Delete 后不应命中")
+			}
+			cache.Clear()
+			if cache.Size() != 0 || cache.Weight() != 0 {
+				t.Fatal("Clear 后应为空")
+			}
+			if evicted != 900 {
+				t.Fatalf("覆盖写入、Delete、Clear 不应触发淘汰回调, evicted=%d", evicted)
+			}
+		})
+	}
+}
+
+// TestBoundedCache_Weigher 按权重限制容量, 超过分片容量的条目不缓存
+func Test_BoundedCache_Weigher(t *testing.T) {
+	for _, c := range boundedCacheCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			cache := c.new(100, WithCacheShards[int, int](1), WithCacheWeigher(func(k, v int) int64 {
+				return int64(v)
+			}))
+			for i := 0; i < 50; i++ {
+				cache.Set(i, 10)
+				if cache.Weight() > 100 {
+					t.Fatalf("weight=%d", cache.Weight())
+				}
+			}
+			if cache.Size() != 10 {
+				t.Fatalf("size=%d", cache.Size())
+			}
+			cache.Set(49, 101)
+			if _, ok := cache.Get(49); ok || cache.Weight() != 90 {
+				t.Fatalf("超过容量的条目不应缓存且应移除旧值, weight=%d", cache.Weight())
+			}
+		})
+	}
+}
+
+// TestBoundedCache_Policy 各策略的淘汰顺序
+func Test_BoundedCache_Policy(t *testing.T) {
+	t.Run("lru", func(t *testing.T) {
+		cache := NewLRUCache[int, int](3, WithCacheShards[int, int](1))
+		cache.Set(1, 1)
+		cache.Set(2, 2)
+		cache.Set(3, 3)
+		cache.Get(1)
+		cache.Set(4, 4)
+		if _, ok := cache.Get(2); ok {
+			t.Fatal("最久未使用的2应被淘汰")
+		}
+		if _, ok := cache.Get(1); !ok {
+			t.Fatal("最近访问的1不应被淘汰")
+		}
+	})
+	t.Run("lfu", func(t *testing.T) {
+		cache := NewLFUCache[int, int](3, WithCacheShards[int, int](1))
+		cache.Set(1, 1)
+		cache.Set(2, 2)
+		cache.Set(3, 3)
+		for i := 0; i < 5; i++ {
+			cache.Get(1)
+			cache.Get(3)
+		}
+		cache.Get(2)
+		cache.Set(4, 4)
+		if _, ok := cache.Get(2); ok {
+			t.Fatal("访问最少的2应被淘汰")
+		}
+		// 频率衰减后, 不再访问的旧热点可以被新的热点淘汰
+		for i := 0; i < 200; i++ {
+			cache.Get(3)
+			cache.Get(4)
+		}
+		cache.Set(5, 5)
+		if _, ok := cache.Get(1); ok {
+			t.Fatal("衰减后旧热点1应被淘汰")
+		}
+	})
+	t.Run("arc", func(t *testing.T) {
+		cache := NewARCCache[int, int](4, WithCacheShards[int, int](1))
+		// 1,2 被多次访问进入 T2
+		for _, k := range []int{1, 2} {
+			cache.Set(k, k)
+			cache.Get(k)
+		}
+		// 一次性扫描不应冲掉多次访问的条目
+		for k := 100; k < 120; k++ {
+			cache.Set(k, k)
+		}
+		for _, k := range []int{1, 2} {
+			if _, ok := cache.Get(k); !ok {
+				t.Fatalf("多次访问的 %d 不应被扫描淘汰", k)
+			}
+		}
+	})
+}
+
+// TestBoundedCache_CacheWrap 可直接用于 NewCacheWrap, 并发读写安全
+func Test_BoundedCache_CacheWrap(t *testing.T) {
+	for _, c := range boundedCacheCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			cache := c.new(1000)
+			wrap := NewCacheWrap[int, int](cache)
+			var wg sync.WaitGroup
+			for g := 0; g < 8; g++ {
+				g := g
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 2000; i++ {
+						k := (i*7 + g) % 1500
+						if v := wrap.GetOr(k, func() int { return k * 2 }); v != k*2 {
+							t.Errorf("GetOr(%d)=%d", k, v)
+							return
+						}
+						if i%10 == 0 {
+							cache.Delete(k)
+						}
+					}
+				}()
+			}
+			wg.Wait()
+			if cache.Weight() > cache.Capacity()+int64(len(cache.shards)) {
+				t.Fatalf("weight=%d capacity=%d", cache.Weight(), cache.Capacity())
+			}
+		})
+	}
+}
+
+func BenchmarkBoundedCache(b *testing.B) {
+	keys := make([]string, 4096)
+	for i := range keys {
+		keys[i] = "key-" + strconv.Itoa(i)
+	}
+	for _, c := range []struct {
+		name string
+		new  func(int64, ...CacheOpt[string, int]) *BoundedCache[string, int]
+	}{
+		{"lru", NewLRUCache[string, int]},
+		{"lfu", NewLFUCache[string, int]},
+		{"arc", NewARCCache[string, int]},
+	} {
+		b.Run(c.name, func(b *testing.B) {
+			cache := c.new(2048)
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					k := keys[i&4095]
+					if _, ok := cache.Get(k); !ok {
+						cache.Set(k, i)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package storage
+
+import "container/heap"
+
+// lfuDecayFactor 访问次数达到条目数的该倍数后, 所有条目的访问频率减半
+const lfuDecayFactor = 8
+
+// NewLFUCache 创建按最不经常使用(LFU)淘汰的缓存, 频率相同时淘汰最久未访问的条目
+//
+// 访问频率会周期性减半(衰减), 曾经的热点数据不再被访问后也能逐渐被淘汰
+func NewLFUCache[K comparable, V any](capacity int64, opts ...CacheOpt[K, V]) *BoundedCache[K, V] {
+    return newBoundedCache(capacity, func(capacity int64) cachePolicy[K, V] {
+        return &lfuPolicy[K, V]{capacity: capacity, items: map[K]*cacheEntry[K, V]{}}
+    }, opts)
+}
+
+type lfuPolicy[K comparable, V any] struct {
+    capacity int64
+    items    map[K]*cacheEntry[K, V]
+    // heap 按 (freq, tick) 排序的最小堆, 堆顶为下一个被淘汰的条目
+    heap    lfuHeap[K, V]
+    total   int64
+    tick    uint64
+    // accesses 上次衰减后的访问次数
+    accesses int
+}
+
+func (p *lfuPolicy[K, V]) touch(e *cacheEntry[K, V]) {
+    p.tick++
+    e.tick = p.tick
+    if e.freq < ^uint32(0) {
+        e.freq++
+    }
+    p.accesses++
+}
+
+// decay 访问次数足够多时将所有频率减半, 减半会破坏频率相同时按 tick 的顺序, 需要重建堆
+func (p *lfuPolicy[K, V]) decay() {
+    if p.accesses < lfuDecayFactor*(len(p.items)+8) {
+        return
+    }
+    p.accesses = 0
+    for _, e := range p.heap {
+        e.freq = (e.freq + 1) / 2
+    }
+    heap.Init(&p.heap)
+}
+
+func (p *lfuPolicy[K, V]) get(key K) (V, bool) {
+    e, ok := p.items[key]
+    if !ok {
+        var zero V
+        return zero, false
+    }
+    p.touch(e)
+    heap.Fix(&p.heap, e.index)
+    p.decay()
+    return e.value, true
+}
+
+func (p *lfuPolicy[K, V]) set(key K, value V, weight int64, ev *[]cacheKV[K, V]) {
+    // 覆盖写入时先移出并保留访问频率, 避免淘汰时选中自身
+    e, ok := p.items[key]
+    if ok {
+        p.remove(e)
+    }
+    if weight > p.capacity {
+        return
+    }
+    for p.total+weight > p.capacity {
+        old := p.heap[0]
+        p.remove(old)
+        if ev != nil {
+            *ev = append(*ev, cacheKV[K, V]{old.key, old.value})
+        }
+    }
+    if e == nil {
+        e = &cacheEntry[K, V]{key: key}
+    }
+    e.value, e.weight = value, weight
+    p.touch(e)
+    p.items[key] = e
+    p.total += weight
+    heap.Push(&p.heap, e)
+    p.decay()
+}
+
+func (p *lfuPolicy[K, V]) remove(e *cacheEntry[K, V]) {
+    heap.Remove(&p.heap, e.index)
+    delete(p.items, e.key)
+    p.total -= e.weight
+}
+
+func (p *lfuPolicy[K, V]) delete(key K) {
+    if e, ok := p.items[key]; ok {
+        p.remove(e)
+    }
+}
+
+func (p *lfuPolicy[K, V]) clear() {
+    p.items = map[K]*cacheEntry[K, V]{}
+    p.heap = nil
+    p.total = 0
+    p.accesses = 0
+}
+
+func (p *lfuPolicy[K, V]) len() int {
+    return len(p.items)
+}
+
+func (p *lfuPolicy[K, V]) weight() int64 {
+    return p.total
+}
+
+// lfuHeap 实现 heap.Interface
+type lfuHeap[K comparable, V any] []*cacheEntry[K, V]
+
+func (h lfuHeap[K, V]) Len() int {
+    return len(h)
+}
+
+func (h lfuHeap[K, V]) Less(i, j int) bool {
+    if h[i].freq != h[j].freq {
+        return h[i].freq < h[j].freq
+    }
+    return h[i].tick < h[j].tick
+}
+
+func (h lfuHeap[K, V]) Swap(i, j int) {
+    h[i], h[j] = h[j], h[i]
+    h[i].index = i
+    h[j].index = j
+}
+
+func (h *lfuHeap[K, V]) Push(x any) {
+    e := x.(*cacheEntry[K, V])
+    e.index = len(*h)
+    *h = append(*h, e)
+}
+
+func (h *lfuHeap[K, V]) Pop() any {
+    old := *h
+    n := len(old)
+    e := old[n-1]
+    old[n-1] = nil
+    *h = old[:n-1]
+    return e
+}
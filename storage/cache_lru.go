@@ -0,0 +1,72 @@
+package storage
+
+// NewLRUCache 创建按最近最少使用(LRU)淘汰的缓存, capacity 为容量(默认按条目数, 设置权重后按权重之和)
+func NewLRUCache[K comparable, V any](capacity int64, opts ...CacheOpt[K, V]) *BoundedCache[K, V] {
+    return newBoundedCache(capacity, func(capacity int64) cachePolicy[K, V] {
+        p := &lruPolicy[K, V]{capacity: capacity, items: map[K]*cacheEntry[K, V]{}}
+        p.list.init()
+        return p
+    }, opts)
+}
+
+type lruPolicy[K comparable, V any] struct {
+    capacity int64
+    items    map[K]*cacheEntry[K, V]
+    list     entryList[K, V]
+}
+
+func (p *lruPolicy[K, V]) get(key K) (V, bool) {
+    e, ok := p.items[key]
+    if !ok {
+        var zero V
+        return zero, false
+    }
+    p.list.moveToFront(e)
+    return e.value, true
+}
+
+func (p *lruPolicy[K, V]) set(key K, value V, weight int64, ev *[]cacheKV[K, V]) {
+    // 覆盖写入时先移出, 复用条目, 避免淘汰时选中自身
+    e, ok := p.items[key]
+    if ok {
+        p.list.remove(e)
+        delete(p.items, key)
+    }
+    if weight > p.capacity {
+        return
+    }
+    for p.list.weight+weight > p.capacity {
+        old := p.list.back()
+        p.list.remove(old)
+        delete(p.items, old.key)
+        if ev != nil {
+            *ev = append(*ev, cacheKV[K, V]{old.key, old.value})
+        }
+    }
+    if e == nil {
+        e = &cacheEntry[K, V]{key: key}
+    }
+    e.value, e.weight = value, weight
+    p.list.pushFront(e)
+    p.items[key] = e
+}
+
+func (p *lruPolicy[K, V]) delete(key K) {
+    if e, ok := p.items[key]; ok {
+        p.list.remove(e)
+        delete(p.items, key)
+    }
+}
+
+func (p *lruPolicy[K, V]) clear() {
+    p.items = map[K]*cacheEntry[K, V]{}
+    p.list.init()
+}
+
+func (p *lruPolicy[K, V]) len() int {
+    return len(p.items)
+}
+
+func (p *lruPolicy[K, V]) weight() int64 {
+    return p.list.weight
+}
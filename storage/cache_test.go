@@ -1,7 +1,10 @@
 package storage
 
 import (
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // mockCache 简单的内存缓存实现,用于测试
@@ -123,3 +126,92 @@ func Test_CacheWrap_NewCacheWrap(t *testing.T) {
 		t.Error("CacheWrap 应该正确代理到底层 cache")
 	}
 }
+
+// 并发未命中同一 key 时只执行一次 def, 不同 key 的加载互不阻塞
+func Test_CacheWrap_GetOr_Coalesce(t *testing.T) {
+	t.Parallel()
+	cache := NewCacheWrap[int, int](newSyncMockCache[int, int]())
+	var calls int32
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v := cache.GetOr(1, func() int {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return 42
+			})
+			if v != 42 {
+				t.Errorf("期望 42, 实际 %d", v)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	// key 1 加载中, key 2 不应被阻塞
+	if v := cache.GetOr(2, func() int { return 2 }); v != 2 {
+		t.Fatalf("期望 2, 实际 %d", v)
+	}
+	close(release)
+	wg.Wait()
+	if c := atomic.LoadInt32(&calls); c != 1 {
+		t.Fatalf("def 执行了 %d 次", c)
+	}
+}
+
+// def panic 时等待者重新加载
+func Test_CacheWrap_GetOr_Panic(t *testing.T) {
+	cache := NewCacheWrap[int, int](newSyncMockCache[int, int]())
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("应 panic")
+			}
+		}()
+		cache.GetOr(1, func() int { panic("load failed") })
+	}()
+	if v := cache.GetOr(1, func() int { return 1 }); v != 1 {
+		t.Fatalf("期望 1, 实际 %d", v)
+	}
+}
+
+// syncMockCache 并发安全的 mockCache
+type syncMockCache[K comparable, V any] struct {
+	sync.Mutex
+	m *mockCache[K, V]
+}
+
+func newSyncMockCache[K comparable, V any]() *syncMockCache[K, V] {
+	return &syncMockCache[K, V]{m: newMockCache[K, V]()}
+}
+
+func (s *syncMockCache[K, V]) Get(key K) (V, bool) {
+	s.Lock()
+	defer s.Unlock()
+	return s.m.Get(key)
+}
+
+func (s *syncMockCache[K, V]) Set(key K, value V) {
+	s.Lock()
+	defer s.Unlock()
+	s.m.Set(key, value)
+}
+
+func (s *syncMockCache[K, V]) Delete(key K) {
+	s.Lock()
+	defer s.Unlock()
+	s.m.Delete(key)
+}
+
+func (s *syncMockCache[K, V]) Clear() {
+	s.Lock()
+	defer s.Unlock()
+	s.m.Clear()
+}
+
+func (s *syncMockCache[K, V]) Size() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.m.Size()
+}
@@ -1,5 +1,5 @@
 // Package storage 提供高性能存储组件
 //
 // 包含多种Map实现(Go原生、Swiss、并发变体)、
-// Goroutine本地存储(GLS)、缓存接口等
+// Goroutine本地存储(GLS)、缓存接口及 LRU/LFU/ARC 有界缓存等
 package storage
\ No newline at end of file
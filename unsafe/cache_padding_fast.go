@@ -0,0 +1,6 @@
+//go:build concurrent_fast
+
+package unsafe
+
+// CachePaddingLength 缓解伪共享的填充字节数, 由 concurrent_fast / concurrent_memory 编译标签调整
+const CachePaddingLength = 120